@@ -0,0 +1,472 @@
+// Package localiface checks that interfaces are declared where they're
+// used, and no bigger than they need to be.
+//
+// It reports three things:
+//
+//   - An exported interface that sits next to its only implementation.
+//     That's an interface written for the producer, not the consumer, and
+//     the consumer should declare its own.
+//   - A function that takes a concrete type from somewhere else but only
+//     calls one of its methods.  A one-method local interface says the
+//     same thing and is much easier to test.
+//   - A method on a consumer's interface that the consumer never calls.
+//     Every method on a local interface is a promise about what the
+//     package needs, so unused ones are lies.
+//
+// Run it with go vet:
+//
+//	go install ./cmd/localiface
+//	go vet -vettool=$(which localiface) ./...
+package localiface
+
+import (
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/Evertras/go-interface-examples/internal/codegen"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Analyzer reports interfaces defined by the wrong side, concrete
+// dependencies that should be interfaces, and interface methods nobody calls
+var Analyzer = &analysis.Analyzer{
+	Name: "localiface",
+	Doc:  "check that interfaces are small and defined by the code that uses them",
+	Run:  run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	accepted := acceptedInterfaces(pass)
+
+	checkProducerInterfaces(pass, accepted)
+	checkConcreteParams(pass)
+	checkUnusedInterfaceMethods(pass, accepted)
+
+	return nil, nil
+}
+
+// acceptedInterfaces are this package's interfaces that its own functions
+// take as params, which makes them consumer interfaces
+func acceptedInterfaces(pass *analysis.Pass) map[*types.TypeName]bool {
+	accepted := make(map[*types.TypeName]bool)
+
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+
+			if !ok {
+				continue
+			}
+
+			// Constraining a type param is accepting the interface too,
+			// just without boxing it
+			fields := fn.Type.Params.List
+
+			if fn.Type.TypeParams != nil {
+				fields = append(fields[:len(fields):len(fields)], fn.Type.TypeParams.List...)
+			}
+
+			for _, field := range fields {
+				named, ok := pass.TypesInfo.TypeOf(field.Type).(*types.Named)
+
+				if ok && named.Obj().Pkg() == pass.Pkg && types.IsInterface(named) {
+					accepted[named.Obj()] = true
+				}
+			}
+		}
+	}
+
+	return accepted
+}
+
+// checkProducerInterfaces reports exported interfaces that are only
+// implemented by one type in the same package, and that the package
+// doesn't use itself
+func checkProducerInterfaces(pass *analysis.Pass, accepted map[*types.TypeName]bool) {
+	scope := pass.Pkg.Scope()
+
+	var concrete []*types.TypeName
+
+	for _, name := range scope.Names() {
+		typeName, ok := scope.Lookup(name).(*types.TypeName)
+
+		if !ok || typeName.IsAlias() || types.IsInterface(typeName.Type()) {
+			continue
+		}
+
+		// Mocks in tests are implementations too, but they're not the
+		// kind we're worried about
+		if strings.HasSuffix(pass.Fset.Position(typeName.Pos()).Filename, "_test.go") {
+			continue
+		}
+
+		concrete = append(concrete, typeName)
+	}
+
+	for _, name := range scope.Names() {
+		typeName, ok := scope.Lookup(name).(*types.TypeName)
+
+		if !ok || !typeName.Exported() || accepted[typeName] || !types.IsInterface(typeName.Type()) {
+			continue
+		}
+
+		iface := typeName.Type().Underlying().(*types.Interface)
+
+		if iface.NumMethods() == 0 || !iface.IsMethodSet() {
+			continue
+		}
+
+		var implementations []*types.TypeName
+
+		for _, candidate := range concrete {
+			if types.Implements(candidate.Type(), iface) || types.Implements(types.NewPointer(candidate.Type()), iface) {
+				implementations = append(implementations, candidate)
+			}
+		}
+
+		if len(implementations) == 1 {
+			pass.Reportf(typeName.Pos(), "exported interface %s is only implemented by %s in the same package; let the code that uses it declare its own interface", typeName.Name(), implementations[0].Name())
+		}
+	}
+}
+
+// checkConcreteParams reports params of a named type from another package
+// that are only ever used to call one of its methods
+func checkConcreteParams(pass *analysis.Pass) {
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+
+			if !ok || fn.Body == nil {
+				continue
+			}
+
+			for _, field := range fn.Type.Params.List {
+				for _, ident := range field.Names {
+					checkConcreteParam(pass, fn, ident)
+				}
+			}
+		}
+	}
+}
+
+func checkConcreteParam(pass *analysis.Pass, fn *ast.FuncDecl, ident *ast.Ident) {
+	param, ok := pass.TypesInfo.Defs[ident].(*types.Var)
+
+	if !ok || param.Name() == "_" {
+		return
+	}
+
+	named := concreteNamed(param.Type())
+
+	if named == nil || named.Obj().Pkg() == nil || named.Obj().Pkg() == pass.Pkg || codegen.IsStandard(named.Obj().Pkg().Path()) {
+		return
+	}
+
+	// Every use of the param has to be calling a method on it.  Anything
+	// else, like passing it along or reading a field, needs the real thing.
+	methods := make(map[string]bool)
+	onlyMethodCalls := true
+
+	// Method calls are x.M(...), so remember which idents are the x
+	receivers := make(map[*ast.Ident]string)
+
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		selector, ok := node.(*ast.SelectorExpr)
+
+		if !ok {
+			return true
+		}
+
+		receiver, ok := selector.X.(*ast.Ident)
+
+		if !ok || pass.TypesInfo.Uses[receiver] != param {
+			return true
+		}
+
+		selection, ok := pass.TypesInfo.Selections[selector]
+
+		if ok && selection.Kind() == types.MethodVal {
+			receivers[receiver] = selector.Sel.Name
+		}
+
+		return true
+	})
+
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		use, ok := node.(*ast.Ident)
+
+		if !ok || pass.TypesInfo.Uses[use] != param {
+			return true
+		}
+
+		method, ok := receivers[use]
+
+		if !ok {
+			onlyMethodCalls = false
+			return false
+		}
+
+		methods[method] = true
+
+		return true
+	})
+
+	if !onlyMethodCalls || len(methods) != 1 {
+		return
+	}
+
+	var method string
+
+	for name := range methods {
+		method = name
+	}
+
+	pass.Reportf(ident.Pos(), "%s is a %s but %s only calls %s; accept a local interface with just that method instead", param.Name(), types.TypeString(param.Type(), types.RelativeTo(pass.Pkg)), funcName(fn), method)
+}
+
+// concreteNamed is the named type behind T or *T, as long as it looks like a
+// dependency.  Structs with exported fields are data, like a User, and
+// passing those around whole is fine.
+func concreteNamed(t types.Type) *types.Named {
+	if pointer, ok := t.(*types.Pointer); ok {
+		t = pointer.Elem()
+	}
+
+	named, ok := t.(*types.Named)
+
+	if !ok || types.IsInterface(named) {
+		return nil
+	}
+
+	structType, ok := named.Underlying().(*types.Struct)
+
+	if !ok {
+		return nil
+	}
+
+	for i := 0; i < structType.NumFields(); i++ {
+		if structType.Field(i).Exported() {
+			return nil
+		}
+	}
+
+	return named
+}
+
+// checkUnusedInterfaceMethods reports methods of this package's interfaces
+// that this package never calls, for interfaces the package accepts as
+// params
+func checkUnusedInterfaceMethods(pass *analysis.Pass, accepted map[*types.TypeName]bool) {
+	called := make(map[*types.TypeName]map[string]bool)
+
+	for selector, selection := range pass.TypesInfo.Selections {
+		if selection.Kind() != types.MethodVal && selection.Kind() != types.MethodExpr {
+			continue
+		}
+
+		for _, typeName := range declaringInterfaces(selection.Recv(), selector.Sel.Name) {
+			if !accepted[typeName] {
+				continue
+			}
+
+			if called[typeName] == nil {
+				called[typeName] = make(map[string]bool)
+			}
+
+			called[typeName][selector.Sel.Name] = true
+		}
+	}
+
+	// Handing one to something that wants another interface calls
+	// whatever that one needs, just somewhere else
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+
+			if !ok {
+				return true
+			}
+
+			for i, arg := range call.Args {
+				want, ok := wantedInterface(pass, call, i).(*types.Interface)
+
+				if !ok {
+					continue
+				}
+
+				for j := 0; j < want.NumMethods(); j++ {
+					method := want.Method(j).Name()
+
+					for _, typeName := range declaringInterfaces(pass.TypesInfo.TypeOf(arg), method) {
+						if !accepted[typeName] {
+							continue
+						}
+
+						if called[typeName] == nil {
+							called[typeName] = make(map[string]bool)
+						}
+
+						called[typeName][method] = true
+					}
+				}
+			}
+
+			return true
+		})
+	}
+
+	// Report in a stable order so the output doesn't jump around
+	ifaces := make([]*types.TypeName, 0, len(accepted))
+
+	for typeName := range accepted {
+		ifaces = append(ifaces, typeName)
+	}
+
+	sort.Slice(ifaces, func(i, j int) bool {
+		return ifaces[i].Pos() < ifaces[j].Pos()
+	})
+
+	for _, typeName := range ifaces {
+		iface := typeName.Type().Underlying().(*types.Interface)
+
+		for i := 0; i < iface.NumMethods(); i++ {
+			method := iface.Method(i)
+
+			if called[typeName][method.Name()] {
+				continue
+			}
+
+			pos := method.Pos()
+
+			// Methods from an embedded interface elsewhere get reported on
+			// the interface itself
+			if pass.Fset.File(pos) == nil || method.Pkg() != pass.Pkg {
+				pos = typeName.Pos()
+			}
+
+			pass.Reportf(pos, "%s declares %s but %s never calls it; drop it from the interface", typeName.Name(), method.Name(), pass.Pkg.Name())
+		}
+	}
+}
+
+// wantedInterface is the underlying type of whatever the call's i-th arg
+// ends up as, either the param it's passed to or the type it's converted to
+func wantedInterface(pass *analysis.Pass, call *ast.CallExpr, i int) types.Type {
+	fun := pass.TypesInfo.Types[call.Fun]
+
+	if fun.Type == nil {
+		return nil
+	}
+
+	if fun.IsType() {
+		return fun.Type.Underlying()
+	}
+
+	sig, ok := fun.Type.Underlying().(*types.Signature)
+
+	if !ok || sig.Params().Len() == 0 {
+		return nil
+	}
+
+	if i < sig.Params().Len()-1 || !sig.Variadic() {
+		if i >= sig.Params().Len() {
+			return nil
+		}
+
+		return sig.Params().At(i).Type().Underlying()
+	}
+
+	last := sig.Params().At(sig.Params().Len() - 1).Type()
+
+	// f(xs...) passes the slice itself
+	if call.Ellipsis.IsValid() {
+		return last.Underlying()
+	}
+
+	return last.(*types.Slice).Elem().Underlying()
+}
+
+// declaringInterfaces are the named interfaces a method call on recv goes
+// through.  That's recv itself for a plain interface, and whatever
+// constraint it has for a type param, since in
+//
+//	func Run[S userStore](store S) { store.GetUser() }
+//
+// calling GetUser on an S is calling it on a userStore.
+func declaringInterfaces(recv types.Type, method string) []*types.TypeName {
+	switch recv := recv.(type) {
+	case *types.Named:
+		if types.IsInterface(recv) {
+			return []*types.TypeName{recv.Obj()}
+		}
+
+	case *types.TypeParam:
+		return constraintInterfaces(recv.Constraint(), method)
+	}
+
+	return nil
+}
+
+// constraintInterfaces are the named interfaces in a constraint that have
+// the method, looking inside constraints like interface{ userStore; comparable }
+func constraintInterfaces(constraint types.Type, method string) []*types.TypeName {
+	if named, ok := constraint.(*types.Named); ok {
+		if !hasMethod(named, method) {
+			return nil
+		}
+
+		return []*types.TypeName{named.Obj()}
+	}
+
+	iface, ok := constraint.(*types.Interface)
+
+	if !ok {
+		return nil
+	}
+
+	var found []*types.TypeName
+
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		found = append(found, constraintInterfaces(iface.EmbeddedType(i), method)...)
+	}
+
+	return found
+}
+
+func hasMethod(named *types.Named, method string) bool {
+	iface, ok := named.Underlying().(*types.Interface)
+
+	if !ok {
+		return false
+	}
+
+	for i := 0; i < iface.NumMethods(); i++ {
+		if iface.Method(i).Name() == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// funcName is the function's name, or Type.Method for methods
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	recv := fn.Recv.List[0].Type
+
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name + "." + fn.Name.Name
+	}
+
+	return fn.Name.Name
+}
@@ -0,0 +1,25 @@
+package localiface_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/Evertras/go-interface-examples/analysis/localiface"
+)
+
+func TestProducerInterfaces(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), localiface.Analyzer, "example.com/producer")
+}
+
+func TestConcreteParams(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), localiface.Analyzer, "example.com/concrete")
+}
+
+func TestUnusedInterfaceMethods(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), localiface.Analyzer, "example.com/consumer")
+}
+
+func TestUnusedInterfaceMethodsWithTypeParams(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), localiface.Analyzer, "example.com/generic")
+}
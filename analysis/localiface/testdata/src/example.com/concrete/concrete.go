@@ -0,0 +1,50 @@
+package concrete
+
+import (
+	"fmt"
+	"strings"
+
+	"example.com/store"
+)
+
+func Score(s *store.Store, id string) int { // want `s is a \*example.com/store.Store but Score only calls GetUser; accept a local interface with just that method instead`
+	user := s.GetUser(id)
+
+	return user.Score
+}
+
+type Handler struct{}
+
+func (h *Handler) Delete(s *store.Store, ids []string) { // want `s is a \*example.com/store.Store but Handler.Delete only calls DeleteUser`
+	for _, id := range ids {
+		s.DeleteUser(id)
+	}
+}
+
+// Two methods could still be an interface, but that's a judgement call
+func Report(s *store.Store, id string) string {
+	return fmt.Sprintf("%d of %d", s.GetUser(id).Score, s.Count())
+}
+
+// Passing it along needs the real type
+func Forward(s *store.Store, id string) int {
+	return Score(s, id)
+}
+
+// Reading fields isn't a method call
+func Name(u store.User) string {
+	return u.ID
+}
+
+// The standard library is left alone
+func Upper(b *strings.Builder) string {
+	return strings.ToUpper(b.String())
+}
+
+// Unused params aren't this check's problem
+func Ignore(s *store.Store) {}
+
+// Data with exported fields is fine to pass around whole
+func Copy(u *store.User) *store.User {
+	return u.Clone()
+}
@@ -0,0 +1,68 @@
+package consumer
+
+import "io"
+
+type UserStore interface {
+	GetUserScore(id string) (int, error)
+	DeleteUser(id string) error // want `UserStore declares DeleteUser but consumer never calls it; drop it from the interface`
+}
+
+type ReadStore interface { // want `ReadStore declares Close but consumer never calls it`
+	io.Closer
+
+	GetUserScore(id string) (int, error)
+}
+
+func Score(store UserStore, id string) int {
+	score, _ := store.GetUserScore(id)
+
+	return score
+}
+
+func ScoreAgain(store ReadStore, id string) int {
+	score, _ := store.GetUserScore(id)
+
+	return score
+}
+
+// Interfaces that aren't accepted anywhere aren't a consumer's interface
+type Unused interface {
+	Whatever()
+}
+
+// Method expressions count as calls
+type Getter interface {
+	Get() string
+}
+
+func GetAll(getters []Getter) []string {
+	get := Getter.Get
+
+	var out []string
+
+	for _, getter := range getters {
+		out = append(out, get(getter))
+	}
+
+	return out
+}
+
+func Accept(getter Getter) {}
+
+// Passing one on to something that needs more counts as calling it
+type Resolver interface {
+	Canonical(name string) string
+	RaceOf(name string) string
+}
+
+type raceResolver interface {
+	RaceOf(name string) string
+}
+
+func Describe(resolver Resolver, name string) string {
+	return resolver.Canonical(name) + " " + race(resolver, name)
+}
+
+func race(resolver raceResolver, name string) string {
+	return resolver.RaceOf(name)
+}
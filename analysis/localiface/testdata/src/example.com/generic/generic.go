@@ -0,0 +1,32 @@
+package generic
+
+type store interface {
+	GetUser(id string) (string, error)
+	GetUserScore(id string) (int, error)
+	DeleteUser(id string) error // want `store declares DeleteUser but generic never calls it; drop it from the interface`
+}
+
+// Calls on a type param count for its constraint
+func Check[S store](newStore func() S) {
+	s := newStore()
+
+	s.GetUser("evertras")
+}
+
+type scorer interface {
+	GetUserScore(id string) (int, error)
+}
+
+// Including when the constraint is wrapped in something else
+func Compare[S interface {
+	store
+	scorer
+	comparable
+}](a S, b S) bool {
+	scoreA, _ := a.GetUserScore("evertras")
+	scoreB, _ := b.GetUserScore("evertras")
+
+	return a == b || scoreA == scoreB
+}
+
+func Score(s scorer) {}
@@ -0,0 +1,52 @@
+package producer
+
+type Notifier interface { // want `exported interface Notifier is only implemented by EmailNotifier in the same package`
+	Notify(id string) error
+}
+
+type EmailNotifier struct{}
+
+func (n *EmailNotifier) Notify(id string) error { return nil }
+
+// Two implementations means the interface is doing a job here
+type Sender interface {
+	Send(msg string) error
+}
+
+type SMSSender struct{}
+
+func (s SMSSender) Send(msg string) error { return nil }
+
+type PushSender struct{}
+
+func (s *PushSender) Send(msg string) error { return nil }
+
+// Nothing here implements it, so it's someone's local interface
+type Clock interface {
+	Now() int64
+}
+
+// Unexported interfaces aren't anyone else's business
+type closer interface {
+	Close() error
+}
+
+type file struct{}
+
+func (f *file) Close() error { return nil }
+
+var _ closer = &file{}
+
+// Used by this package, so it's a consumer interface that happens to have
+// one implementation here
+type Saver interface {
+	Save(data string) error
+}
+
+type DiskSaver struct{}
+
+func (d DiskSaver) Save(data string) error { return nil }
+
+func Persist(saver Saver, data string) error {
+	return saver.Save(data)
+}
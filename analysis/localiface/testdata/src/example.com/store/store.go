@@ -0,0 +1,18 @@
+package store
+
+type User struct {
+	ID    string
+	Score int
+}
+
+type Store struct {
+	users map[string]*User
+}
+
+func (s *Store) GetUser(id string) *User { return s.users[id] }
+
+func (s *Store) DeleteUser(id string) { delete(s.users, id) }
+
+func (s *Store) Count() int { return len(s.users) }
+
+func (u *User) Clone() *User { return &User{ID: u.ID, Score: u.Score} }
@@ -0,0 +1,250 @@
+// Package chaos makes dependencies fail on purpose.
+//
+// Setting pendingError on a mock tests one failure at a time.  Real
+// dependencies are slow sometimes, fail sometimes, blow through deadlines
+// and half-finish batches, and code that only ever saw pendingError tends
+// to find that out in production.
+//
+// An Injector decides when things go wrong, either randomly from a seed so
+// a failing run can be repeated exactly, or from a scenario that says
+// "the third GetTopUsers call times out".  Wrap anything with it:
+//
+//	injector := chaos.New(chaos.Config{Seed: 1, ErrorRate: 0.2})
+//
+//	store := chaos.NewDb(db.New(), injector)
+//	notifier := chaos.NewNotifier(notifications.New(logger), injector)
+//	getter := NewCurrentChampionGetterDecorator(dataStore, injector.Middleware())
+//
+// The wrappers satisfy the same local interfaces as what they wrap, so the
+// code under test has no idea.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/decorate"
+)
+
+var (
+	// ErrInjected is what an injected failure returns unless the config
+	// says otherwise
+	ErrInjected = errors.New("chaos: injected failure")
+
+	// ErrPartialBatch means only some of a batch went through
+	ErrPartialBatch = errors.New("chaos: partial batch failure")
+)
+
+// Fault is one thing that can go wrong with a call
+type Fault string
+
+const (
+	// FaultNone lets the call through untouched
+	FaultNone Fault = ""
+
+	// FaultError fails the call without calling the real thing
+	FaultError Fault = "error"
+
+	// FaultDeadline hangs until the context gives up, like a dependency
+	// that stopped answering
+	FaultDeadline Fault = "deadline"
+
+	// FaultPartial lets some of a batch through and then fails.  Calls
+	// that aren't batches just fail.
+	FaultPartial Fault = "partial"
+)
+
+// Config says how often things go wrong when nothing is scripted
+type Config struct {
+	// Seed makes the random choices repeatable
+	Seed int64
+
+	// Latency is added to every call, plus up to Jitter more
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// The chance of each fault, from 0 to 1.  They're checked in this
+	// order and only one happens per call.
+	ErrorRate    float64
+	DeadlineRate float64
+	PartialRate  float64
+
+	// Err is the error injected failures return, ErrInjected if nil
+	Err error
+
+	// Methods only injects into these methods, or every method if empty
+	Methods []string
+}
+
+// Decision is what the injector decided for one call
+type Decision struct {
+	Fault   Fault
+	Latency time.Duration
+
+	// Keep is how many of a batch go through for FaultPartial, or -1 to
+	// let the injector pick
+	Keep int
+}
+
+// Injector decides which calls fail and how.  It's safe to share between
+// wrappers, and calls are numbered per method across all of them.
+type Injector struct {
+	mu       sync.Mutex
+	rng      *rand.Rand
+	config   Config
+	scenario *Scenario
+	calls    map[string]int
+}
+
+// New returns an Injector that fails calls at random
+func New(config Config) *Injector {
+	if config.Err == nil {
+		config.Err = ErrInjected
+	}
+
+	return &Injector{
+		rng:    rand.New(rand.NewSource(config.Seed)),
+		config: config,
+		calls:  make(map[string]int),
+	}
+}
+
+// NewScripted returns an Injector that does exactly what the scenario
+// says.  Calls the scenario doesn't mention fall back to the scenario's
+// config, which by default never fails.
+func NewScripted(scenario *Scenario) *Injector {
+	injector := New(scenario.Config)
+	injector.scenario = scenario
+
+	return injector
+}
+
+// Decide numbers the call and decides what happens to it
+func (i *Injector) Decide(method string) Decision {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.calls[method]++
+
+	if i.scenario != nil {
+		if step, ok := i.scenario.find(method, i.calls[method]); ok {
+			return step.decision()
+		}
+	}
+
+	if !i.applies(method) {
+		return Decision{}
+	}
+
+	decision := Decision{Latency: i.config.Latency, Keep: -1}
+
+	if i.config.Jitter > 0 {
+		decision.Latency += time.Duration(i.rng.Int63n(int64(i.config.Jitter)))
+	}
+
+	// Always draw, so changing one rate doesn't shuffle every later choice
+	roll := i.rng.Float64()
+
+	switch {
+	case roll < i.config.ErrorRate:
+		decision.Fault = FaultError
+	case roll < i.config.ErrorRate+i.config.DeadlineRate:
+		decision.Fault = FaultDeadline
+	case roll < i.config.ErrorRate+i.config.DeadlineRate+i.config.PartialRate:
+		decision.Fault = FaultPartial
+	}
+
+	return decision
+}
+
+func (i *Injector) applies(method string) bool {
+	if len(i.config.Methods) == 0 {
+		return true
+	}
+
+	for _, name := range i.config.Methods {
+		if name == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Calls is how many times the method has been called so far
+func (i *Injector) Calls(method string) int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.calls[method]
+}
+
+// before waits out the latency and applies whole-call faults.  A nil error
+// means go ahead with the call.
+func (i *Injector) before(ctx context.Context, method string, decision Decision) error {
+	if decision.Latency > 0 {
+		timer := time.NewTimer(decision.Latency)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	switch decision.Fault {
+	case FaultError:
+		return fmt.Errorf("%s: %w", method, i.config.Err)
+
+	case FaultDeadline:
+		// Without a deadline this would hang forever, which isn't a
+		// useful test of anything
+		if _, ok := ctx.Deadline(); !ok {
+			return fmt.Errorf("%s: %w", method, context.DeadlineExceeded)
+		}
+
+		<-ctx.Done()
+
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// keep is how many of a batch of size n get through
+func (i *Injector) keep(decision Decision, n int) int {
+	if decision.Keep >= 0 {
+		if decision.Keep > n {
+			return n
+		}
+
+		return decision.Keep
+	}
+
+	if n <= 1 {
+		return 0
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	// At least one through and at least one not, or it isn't partial
+	return 1 + i.rng.Intn(n-1)
+}
+
+// Middleware injects faults into any generated decorator.  It can't split
+// up a batch, so partial failures fail the whole call.
+func (i *Injector) Middleware() decorate.Middleware {
+	return func(ctx context.Context, call decorate.Call, next decorate.Invoke) error {
+		if err := i.wholeCall(ctx, call.Method); err != nil {
+			return err
+		}
+
+		return next(ctx)
+	}
+}
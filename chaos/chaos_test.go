@@ -0,0 +1,192 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/decorate"
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+	"github.com/Evertras/go-interface-examples/local-interfaces/db/dbtest"
+)
+
+func TestSameSeedSameFaults(t *testing.T) {
+	config := Config{Seed: 42, ErrorRate: 0.3, DeadlineRate: 0.2, PartialRate: 0.1, Jitter: time.Millisecond}
+
+	a := New(config)
+	b := New(config)
+
+	for i := 0; i < 100; i++ {
+		if da, db := a.Decide("GetTopUsers"), b.Decide("GetTopUsers"); da != db {
+			t.Fatalf("Call %d: expected the same decision but got %+v and %+v", i+1, da, db)
+		}
+	}
+}
+
+func TestRatesAreRoughlyRight(t *testing.T) {
+	injector := New(Config{Seed: 1, ErrorRate: 0.25, DeadlineRate: 0.25})
+	counts := make(map[Fault]int)
+
+	for i := 0; i < 10000; i++ {
+		counts[injector.Decide("GetUser").Fault]++
+	}
+
+	for _, fault := range []Fault{FaultError, FaultDeadline} {
+		if counts[fault] < 2300 || counts[fault] > 2700 {
+			t.Errorf("Expected about 2500 %q faults but got %d", fault, counts[fault])
+		}
+	}
+
+	if counts[FaultPartial] != 0 {
+		t.Errorf("Expected no partial faults but got %d", counts[FaultPartial])
+	}
+}
+
+func TestMethodsLimitsWhereFaultsGo(t *testing.T) {
+	injector := New(Config{ErrorRate: 1, Methods: []string{"DeleteUser"}})
+
+	if fault := injector.Decide("GetUser").Fault; fault != FaultNone {
+		t.Errorf("Expected GetUser to be left alone but got %q", fault)
+	}
+
+	if fault := injector.Decide("DeleteUser").Fault; fault != FaultError {
+		t.Errorf("Expected DeleteUser to fail but got %q", fault)
+	}
+}
+
+func TestMiddlewareFailsWithoutCallingThrough(t *testing.T) {
+	custom := errors.New("custom")
+	middleware := New(Config{ErrorRate: 1, Err: custom}).Middleware()
+
+	err := middleware(context.Background(), decorate.Call{Method: "GetCurrentChampion"}, func(ctx context.Context) error {
+		t.Error("Shouldn't have called through")
+		return nil
+	})
+
+	if !errors.Is(err, custom) {
+		t.Errorf("Expected the configured error but got %v", err)
+	}
+}
+
+func TestDeadlineFaultWaitsForTheContext(t *testing.T) {
+	store := NewDb(db.New(), New(Config{DeadlineRate: 1}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := store.GetTopUsers(ctx, 3)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded but got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Expected to hang until the deadline but returned after %v", elapsed)
+	}
+
+	// No deadline means nothing to wait for
+	_, err = store.GetTopUsers(context.Background(), 3)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded without a deadline too but got %v", err)
+	}
+}
+
+func TestLatencyGivesUpWithTheContext(t *testing.T) {
+	store := NewDb(db.New(), New(Config{Latency: time.Hour}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := store.GetTopUsers(ctx, 3)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded but got %v", err)
+	}
+}
+
+func TestScriptedScenario(t *testing.T) {
+	scenario, err := LoadScenario("testdata/notify-second-fails.json")
+
+	if err != nil {
+		t.Fatal("LoadScenario:", err)
+	}
+
+	ctx := context.Background()
+	injector := NewScripted(scenario)
+	real := db.New()
+	store := NewDb(real, injector)
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := store.CreateUser(ctx, id); err != nil {
+			t.Fatal("CreateUser:", err)
+		}
+	}
+
+	err = store.AwardPoints(ctx, []string{"a", "b", "c"}, 5)
+
+	if !errors.Is(err, ErrPartialBatch) {
+		t.Fatalf("Expected ErrPartialBatch but got %v", err)
+	}
+
+	for id, expected := range map[string]int{"a": 5, "b": 0, "c": 0} {
+		score, err := real.GetUserScore(ctx, id)
+
+		if err != nil || score != expected {
+			t.Errorf("Expected %s to have %d but got %d (%v)", id, expected, score, err)
+		}
+	}
+
+	// The second call isn't scripted, so it goes through
+	if err := store.AwardPoints(ctx, []string{"b"}, 1); err != nil {
+		t.Errorf("Expected the second award to work but got %v", err)
+	}
+
+	if calls := injector.Calls("AwardPoints"); calls != 2 {
+		t.Errorf("Expected 2 AwardPoints calls but counted %d", calls)
+	}
+}
+
+func TestRandomPartialKeepsSomeButNotAll(t *testing.T) {
+	injector := New(Config{Seed: 7, PartialRate: 1})
+
+	for i := 0; i < 100; i++ {
+		keep := injector.keep(injector.Decide("AwardPoints"), 5)
+
+		if keep < 1 || keep > 4 {
+			t.Fatalf("Expected between 1 and 4 of 5 to go through but got %d", keep)
+		}
+	}
+}
+
+func TestScenarioValidation(t *testing.T) {
+	negative := -1
+
+	tests := []struct {
+		name     string
+		scenario Scenario
+	}{
+		{"NoMethod", Scenario{Steps: []Step{{Call: 1}}}},
+		{"CallZero", Scenario{Steps: []Step{{Method: "GetUser"}}}},
+		{"UnknownFault", Scenario{Steps: []Step{{Method: "GetUser", Call: 1, Fault: "gremlins"}}}},
+		{"NegativeKeep", Scenario{Steps: []Step{{Method: "AwardPoints", Call: 1, Fault: FaultPartial, Keep: &negative}}}},
+		{"Duplicate", Scenario{Steps: []Step{{Method: "GetUser", Call: 1}, {Method: "GetUser", Call: 1, Fault: FaultError}}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.scenario.Validate(); err == nil {
+				t.Error("Expected an error but got nil")
+			}
+		})
+	}
+}
+
+// With nothing going wrong, the wrapper has to be a perfectly good db
+func TestQuietDbConforms(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T) *Db {
+		return NewDb(db.New(), New(Config{}))
+	})
+}
@@ -0,0 +1,154 @@
+package chaos
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Scenario is a script of exactly which calls go wrong
+//
+// It's written as JSON so a failure found at random can be written down
+// and kept as a regression test:
+//
+//	{
+//	  "steps": [
+//	    {"method": "GetTopUsers", "call": 1, "latency": "50ms"},
+//	    {"method": "NotifyTopScore", "call": 2, "fault": "error"},
+//	    {"method": "AwardPoints", "call": 1, "fault": "partial", "keep": 1}
+//	  ]
+//	}
+type Scenario struct {
+	// Config covers any call the steps don't mention.  Leave it out and
+	// they all go through.
+	Config Config `json:"-"`
+
+	Steps []Step `json:"steps"`
+}
+
+// Step is what happens to one call
+type Step struct {
+	Method string `json:"method"`
+
+	// Call is which call to the method this is, starting from 1
+	Call int `json:"call"`
+
+	Fault   Fault    `json:"fault,omitempty"`
+	Latency Duration `json:"latency,omitempty"`
+
+	// Keep is how many of a batch get through for a partial fault, or
+	// left out to pick at random
+	Keep *int `json:"keep,omitempty"`
+}
+
+func (s Step) decision() Decision {
+	decision := Decision{
+		Fault:   s.Fault,
+		Latency: time.Duration(s.Latency),
+		Keep:    -1,
+	}
+
+	if s.Keep != nil {
+		decision.Keep = *s.Keep
+	}
+
+	return decision
+}
+
+// Duration is a time.Duration written like "50ms" in JSON
+type Duration time.Duration
+
+// UnmarshalJSON reads durations like "50ms"
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("duration should be a string like \"50ms\": %w", err)
+	}
+
+	parsed, err := time.ParseDuration(text)
+
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// MarshalJSON writes durations like "50ms"
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (s *Scenario) find(method string, call int) (Step, bool) {
+	for _, step := range s.Steps {
+		if step.Method == method && step.Call == call {
+			return step, true
+		}
+	}
+
+	return Step{}, false
+}
+
+// Validate checks the steps make sense
+func (s *Scenario) Validate() error {
+	seen := make(map[string]bool)
+
+	for i, step := range s.Steps {
+		if step.Method == "" {
+			return fmt.Errorf("step %d has no method", i+1)
+		}
+
+		if step.Call < 1 {
+			return fmt.Errorf("step %d: call numbers start at 1, got %d", i+1, step.Call)
+		}
+
+		switch step.Fault {
+		case FaultNone, FaultError, FaultDeadline, FaultPartial:
+		default:
+			return fmt.Errorf("step %d: unknown fault %q", i+1, step.Fault)
+		}
+
+		if step.Keep != nil && *step.Keep < 0 {
+			return fmt.Errorf("step %d: keep can't be negative", i+1)
+		}
+
+		key := fmt.Sprintf("%s#%d", step.Method, step.Call)
+
+		if seen[key] {
+			return fmt.Errorf("step %d: %s call %d is scripted twice", i+1, step.Method, step.Call)
+		}
+
+		seen[key] = true
+	}
+
+	return nil
+}
+
+// LoadScenario reads a scenario from a JSON file
+func LoadScenario(filename string) (*Scenario, error) {
+	data, err := os.ReadFile(filename)
+
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	var scenario Scenario
+
+	err = json.Unmarshal(data, &scenario)
+
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	err = scenario.Validate()
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return &scenario, nil
+}
@@ -0,0 +1,159 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+)
+
+// UserStore is everything db.Db does, which is what Db wraps
+type UserStore interface {
+	GetUser(ctx context.Context, id string) (*db.User, error)
+	GetUserScore(ctx context.Context, id string) (int, error)
+	CreateUser(ctx context.Context, id string) error
+	DeleteUser(ctx context.Context, id string) error
+	GetTopUsers(ctx context.Context, count int) ([]*db.User, error)
+	AwardPoints(ctx context.Context, ids []string, score int) error
+}
+
+// Db wraps a user store, and can stand in for db.Db anywhere
+type Db struct {
+	next     UserStore
+	injector *Injector
+}
+
+// NewDb wraps next with faults from the injector
+func NewDb(next UserStore, injector *Injector) *Db {
+	return &Db{
+		next:     next,
+		injector: injector,
+	}
+}
+
+// GetUser might fail before asking the real store
+func (d *Db) GetUser(ctx context.Context, id string) (*db.User, error) {
+	if err := d.injector.wholeCall(ctx, "GetUser"); err != nil {
+		return nil, err
+	}
+
+	return d.next.GetUser(ctx, id)
+}
+
+// GetUserScore might fail before asking the real store
+func (d *Db) GetUserScore(ctx context.Context, id string) (int, error) {
+	if err := d.injector.wholeCall(ctx, "GetUserScore"); err != nil {
+		return 0, err
+	}
+
+	return d.next.GetUserScore(ctx, id)
+}
+
+// CreateUser might fail before asking the real store
+func (d *Db) CreateUser(ctx context.Context, id string) error {
+	if err := d.injector.wholeCall(ctx, "CreateUser"); err != nil {
+		return err
+	}
+
+	return d.next.CreateUser(ctx, id)
+}
+
+// DeleteUser might fail before asking the real store
+func (d *Db) DeleteUser(ctx context.Context, id string) error {
+	if err := d.injector.wholeCall(ctx, "DeleteUser"); err != nil {
+		return err
+	}
+
+	return d.next.DeleteUser(ctx, id)
+}
+
+// GetTopUsers might fail before asking the real store
+func (d *Db) GetTopUsers(ctx context.Context, count int) ([]*db.User, error) {
+	if err := d.injector.wholeCall(ctx, "GetTopUsers"); err != nil {
+		return nil, err
+	}
+
+	return d.next.GetTopUsers(ctx, count)
+}
+
+// AwardPoints might fail, or might award some of the users and then fail
+// with ErrPartialBatch.  The real db.Db never does that, but a real
+// database behind a flaky network can look exactly like it did.
+func (d *Db) AwardPoints(ctx context.Context, ids []string, score int) error {
+	decision := d.injector.Decide("AwardPoints")
+
+	if decision.Fault != FaultPartial {
+		if err := d.injector.before(ctx, "AwardPoints", decision); err != nil {
+			return err
+		}
+
+		return d.next.AwardPoints(ctx, ids, score)
+	}
+
+	decision.Fault = FaultNone
+
+	if err := d.injector.before(ctx, "AwardPoints", decision); err != nil {
+		return err
+	}
+
+	keep := d.injector.keep(decision, len(ids))
+
+	if keep > 0 {
+		if err := d.next.AwardPoints(ctx, ids[:keep], score); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("AwardPoints: %w: awarded %d of %d users", ErrPartialBatch, keep, len(ids))
+}
+
+// Notifier wraps anything that sends the notifications
+// notifications.Notifier does
+type Notifier struct {
+	next     NotificationSender
+	injector *Injector
+}
+
+// NotificationSender is everything notifications.Notifier does
+type NotificationSender interface {
+	NotifyTopScore(ctx context.Context, id string, score int) error
+	NotifyPasswordUpdate(ctx context.Context, id string) error
+}
+
+// NewNotifier wraps next with faults from the injector
+func NewNotifier(next NotificationSender, injector *Injector) *Notifier {
+	return &Notifier{
+		next:     next,
+		injector: injector,
+	}
+}
+
+// NotifyTopScore might fail before sending
+func (n *Notifier) NotifyTopScore(ctx context.Context, id string, score int) error {
+	if err := n.injector.wholeCall(ctx, "NotifyTopScore"); err != nil {
+		return err
+	}
+
+	return n.next.NotifyTopScore(ctx, id, score)
+}
+
+// NotifyPasswordUpdate might fail before sending
+func (n *Notifier) NotifyPasswordUpdate(ctx context.Context, id string) error {
+	if err := n.injector.wholeCall(ctx, "NotifyPasswordUpdate"); err != nil {
+		return err
+	}
+
+	return n.next.NotifyPasswordUpdate(ctx, id)
+}
+
+// wholeCall decides and applies the fault for a call that can't be split,
+// where a partial failure is just a failure
+func (i *Injector) wholeCall(ctx context.Context, method string) error {
+	decision := i.Decide(method)
+
+	if decision.Fault == FaultPartial {
+		decision.Fault = FaultError
+	}
+
+	return i.before(ctx, method, decision)
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/Evertras/go-interface-examples/internal/codegen"
+	"golang.org/x/tools/go/packages"
+)
+
+// extractConfig says what to look for and what to call the result
+type extractConfig struct {
+	// pattern is the consumer package, like ./local-interfaces/handlers
+	pattern string
+
+	// typeName is the concrete dependency, like
+	// github.com/Evertras/go-interface-examples/local-interfaces/db.Db
+	typeName string
+
+	// funcName optionally limits the search to one function, or a method
+	// written as Type.Method
+	funcName string
+
+	// interfaceName is what to call the interface we emit
+	interfaceName string
+
+	// dir is where to run the go command from
+	dir string
+}
+
+// usedMethod is a method of the dependency the consumer actually calls
+type usedMethod struct {
+	method *types.Func
+	doc    string
+}
+
+func loadMode() packages.LoadMode {
+	return packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps
+}
+
+// extract finds the methods of the dependency the consumer calls and
+// renders the smallest interface that covers them, as a whole Go file
+func extract(config extractConfig) ([]byte, error) {
+	typePath, typeName, err := splitTypeName(config.typeName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	consumer, err := loadOne(config.dir, config.pattern)
+
+	if err != nil {
+		return nil, err
+	}
+
+	methods, err := findUsedMethods(consumer, typePath, typeName, config.funcName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("%s doesn't call any methods of %s", consumer.PkgPath, config.typeName)
+	}
+
+	// The docs live with the dependency's source, which the consumer's
+	// type information doesn't carry
+	dependency, err := loadOne(config.dir, typePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	addDocs(dependency, typeName, methods)
+
+	return render(consumer, config, methods)
+}
+
+func splitTypeName(fullName string) (string, string, error) {
+	dot := strings.LastIndex(fullName, ".")
+
+	if dot <= 0 || dot == len(fullName)-1 {
+		return "", "", fmt.Errorf("type should look like import/path.TypeName, got %q", fullName)
+	}
+
+	return fullName[:dot], fullName[dot+1:], nil
+}
+
+func loadOne(dir string, pattern string) (*packages.Package, error) {
+	pkgs, err := packages.Load(&packages.Config{Mode: loadMode(), Dir: dir}, pattern)
+
+	if err != nil {
+		return nil, fmt.Errorf("packages.Load: %w", err)
+	}
+
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected %q to match one package but it matched %d", pattern, len(pkgs))
+	}
+
+	if len(pkgs[0].Errors) > 0 {
+		return nil, fmt.Errorf("failed to load %s: %v", pattern, pkgs[0].Errors[0])
+	}
+
+	return pkgs[0], nil
+}
+
+func findUsedMethods(pkg *packages.Package, typePath string, typeName string, funcName string) ([]*usedMethod, error) {
+	found := make(map[string]*usedMethod)
+	sawFunc := funcName == ""
+
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+
+			if !ok || (funcName != "" && funcDeclName(fn) != funcName) {
+				continue
+			}
+
+			sawFunc = true
+
+			ast.Inspect(fn, func(node ast.Node) bool {
+				selector, ok := node.(*ast.SelectorExpr)
+
+				if !ok {
+					return true
+				}
+
+				selection, ok := pkg.TypesInfo.Selections[selector]
+
+				if !ok || (selection.Kind() != types.MethodVal && selection.Kind() != types.MethodExpr) {
+					return true
+				}
+
+				if !isNamed(selection.Recv(), typePath, typeName) {
+					return true
+				}
+
+				method := selection.Obj().(*types.Func)
+				found[method.Name()] = &usedMethod{method: method}
+
+				return true
+			})
+		}
+	}
+
+	if !sawFunc {
+		return nil, fmt.Errorf("no function %q in %s", funcName, pkg.PkgPath)
+	}
+
+	methods := make([]*usedMethod, 0, len(found))
+
+	for _, method := range found {
+		methods = append(methods, method)
+	}
+
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].method.Name() < methods[j].method.Name()
+	})
+
+	return methods, nil
+}
+
+// funcDeclName is the function's name, or Type.Method for methods
+func funcDeclName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	recv := fn.Recv.List[0].Type
+
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name + "." + fn.Name.Name
+	}
+
+	return fn.Name.Name
+}
+
+func isNamed(t types.Type, typePath string, typeName string) bool {
+	if pointer, ok := t.(*types.Pointer); ok {
+		t = pointer.Elem()
+	}
+
+	named, ok := t.(*types.Named)
+
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+
+	return named.Obj().Pkg().Path() == typePath && named.Obj().Name() == typeName
+}
+
+func addDocs(pkg *packages.Package, typeName string, methods []*usedMethod) {
+	byName := make(map[string]*usedMethod, len(methods))
+
+	for _, method := range methods {
+		byName[typeName+"."+method.method.Name()] = method
+	}
+
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+
+			if !ok || fn.Doc == nil {
+				continue
+			}
+
+			if method, ok := byName[funcDeclName(fn)]; ok {
+				method.doc = fn.Doc.Text()
+			}
+		}
+	}
+}
+
+func render(consumer *packages.Package, config extractConfig, methods []*usedMethod) ([]byte, error) {
+	imports := make(map[string]string)
+
+	// Types from the consumer's own package don't need a prefix, everything
+	// else gets its package name and an import
+	qualifier := func(pkg *types.Package) string {
+		if pkg.Path() == consumer.PkgPath {
+			return ""
+		}
+
+		imports[pkg.Path()] = pkg.Name()
+
+		return pkg.Name()
+	}
+
+	var body bytes.Buffer
+
+	fmt.Fprintf(&body, "// %s is the part of %s that %s uses\n", config.interfaceName, config.typeName[strings.LastIndex(config.typeName, "/")+1:], consumer.Name)
+	fmt.Fprintf(&body, "type %s interface {\n", config.interfaceName)
+
+	for i, method := range methods {
+		if i > 0 {
+			body.WriteString("\n")
+		}
+
+		if method.doc != "" {
+			for _, line := range strings.Split(strings.TrimSpace(method.doc), "\n") {
+				fmt.Fprintln(&body, strings.TrimRight("\t// "+line, " "))
+			}
+		}
+
+		signature := types.TypeString(method.method.Type(), qualifier)
+
+		fmt.Fprintf(&body, "\t%s%s\n", method.method.Name(), strings.TrimPrefix(signature, "func"))
+	}
+
+	body.WriteString("}\n")
+
+	var file bytes.Buffer
+
+	fmt.Fprintf(&file, "package %s\n\n", consumer.Name)
+
+	if len(imports) > 0 {
+		paths := make([]string, 0, len(imports))
+
+		for path := range imports {
+			paths = append(paths, path)
+		}
+
+		// Standard library first, then everything else, like goimports
+		sort.Slice(paths, func(i, j int) bool {
+			if codegen.IsStandard(paths[i]) != codegen.IsStandard(paths[j]) {
+				return codegen.IsStandard(paths[i])
+			}
+
+			return paths[i] < paths[j]
+		})
+
+		file.WriteString("import (\n")
+
+		for i, path := range paths {
+			if i > 0 && codegen.IsStandard(paths[i-1]) && !codegen.IsStandard(path) {
+				file.WriteString("\n")
+			}
+
+			fmt.Fprintf(&file, "\t%q\n", path)
+		}
+
+		file.WriteString(")\n\n")
+	}
+
+	file.Write(body.Bytes())
+
+	formatted, err := format.Source(file.Bytes())
+
+	if err != nil {
+		return nil, errors.New("generated code doesn't parse, this is a bug: " + err.Error())
+	}
+
+	return formatted, nil
+}
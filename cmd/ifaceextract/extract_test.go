@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDbType = "github.com/Evertras/go-interface-examples/local-interfaces/db.Db"
+
+func TestExtractFindsOnlyCalledMethods(t *testing.T) {
+	source, err := extract(extractConfig{
+		pattern:       "./testdata/consumer",
+		typeName:      testDbType,
+		interfaceName: "UserStore",
+	})
+
+	if err != nil {
+		t.Fatal("extract:", err)
+	}
+
+	expected := `package consumer
+
+import (
+	"context"
+
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+)
+
+// UserStore is the part of db.Db that consumer uses
+type UserStore interface {
+	// AwardPoints gives points to all the users in the ids array
+`
+
+	if !strings.HasPrefix(string(source), expected) {
+		t.Fatalf("Unexpected start of output:\n%s", source)
+	}
+
+	for _, method := range []string{
+		"\tAwardPoints(ctx context.Context, ids []string, score int) error\n",
+		"\tCreateUser(ctx context.Context, id string) error\n",
+		"\tGetTopUsers(ctx context.Context, count int) ([]*db.User, error)\n",
+	} {
+		if !strings.Contains(string(source), method) {
+			t.Errorf("Expected output to contain %q", method)
+		}
+	}
+
+	for _, unused := range []string{"DeleteUser", "GetUserScore", "GetUser("} {
+		if strings.Contains(string(source), unused) {
+			t.Errorf("Output shouldn't mention %s, nothing calls it", unused)
+		}
+	}
+}
+
+func TestExtractCanLookAtOneFunction(t *testing.T) {
+	source, err := extract(extractConfig{
+		pattern:       "./testdata/consumer",
+		typeName:      testDbType,
+		funcName:      "Leaders",
+		interfaceName: "TopUserGetter",
+	})
+
+	if err != nil {
+		t.Fatal("extract:", err)
+	}
+
+	if !strings.Contains(string(source), "GetTopUsers(") || strings.Contains(string(source), "CreateUser(") {
+		t.Errorf("Expected only GetTopUsers but got:\n%s", source)
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config extractConfig
+	}{
+		{"BadTypeName", extractConfig{pattern: "./testdata/consumer", typeName: "Db", interfaceName: "X"}},
+		{"MissingFunction", extractConfig{pattern: "./testdata/consumer", typeName: testDbType, funcName: "Nope", interfaceName: "X"}},
+		{"NothingCalled", extractConfig{pattern: "./testdata/consumer", typeName: "net/http.Client", interfaceName: "X"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := extract(test.config)
+
+			if err == nil {
+				t.Error("Expected an error but got nil")
+			}
+		})
+	}
+}
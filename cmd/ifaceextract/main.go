@@ -0,0 +1,62 @@
+// ifaceextract writes the smallest interface a package needs from one of
+// its concrete dependencies
+//
+// Point it at a consumer package and the concrete type it takes, and it
+// finds every method of that type the consumer actually calls:
+//
+//	ifaceextract -type github.com/Evertras/go-interface-examples/local-interfaces/db.Db \
+//		-name UserDataStore ./local-interfaces/handlers
+//
+// The result is a local interface, doc comments and all, ready to replace
+// the concrete type in the consumer's function signatures.  Use -func to
+// only look at one function, which is handy when different parts of a
+// package need different slices of the same dependency.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	typeName := flag.String("type", "", "concrete dependency, as import/path.TypeName")
+	interfaceName := flag.String("name", "", "name of the interface to write")
+	funcName := flag.String("func", "", "only look inside this function, or Type.Method")
+	output := flag.String("o", "", "file to write, defaults to stdout")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -type import/path.Type -name Interface [-func Name] [-o file] package\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if *typeName == "" || *interfaceName == "" || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	source, err := extract(extractConfig{
+		pattern:       flag.Arg(0),
+		typeName:      *typeName,
+		funcName:      *funcName,
+		interfaceName: *interfaceName,
+	})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(source)
+		return
+	}
+
+	err = os.WriteFile(*output, source, 0644)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,25 @@
+// Package consumer takes a concrete *db.Db, which is exactly what
+// ifaceextract is for
+package consumer
+
+import (
+	"context"
+
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+)
+
+// Welcome creates a user and gives them some points to start with
+func Welcome(ctx context.Context, database *db.Db, id string) error {
+	err := database.CreateUser(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	return database.AwardPoints(ctx, []string{id}, 10)
+}
+
+// Leaders gets the top users, but only in this function
+func Leaders(ctx context.Context, database *db.Db) ([]*db.User, error) {
+	return database.GetTopUsers(ctx, 3)
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"strings"
+	"text/template"
+
+	"github.com/Evertras/go-interface-examples/internal/codegen"
+	"golang.org/x/tools/go/packages"
+)
+
+const decoratePath = "github.com/Evertras/go-interface-examples/decorate"
+
+// generateConfig says which interface to decorate and what to call the
+// decorator
+type generateConfig struct {
+	// pattern is the package with the interface, usually "."
+	pattern string
+
+	// interfaceName is the interface to decorate, like CurrentChampionGetter
+	interfaceName string
+
+	// decoratorName defaults to the interface name followed by Decorator
+	decoratorName string
+
+	// dir is where to run the go command from
+	dir string
+}
+
+type decoratorParam struct {
+	Name     string
+	Type     string
+	Variadic bool
+}
+
+type decoratorMethod struct {
+	Name   string
+	Params []decoratorParam
+
+	// Results are the result types, the last one might be the error
+	Results []string
+
+	// ReturnsError is true when the last result is an error
+	ReturnsError bool
+
+	// ContextName is the name of the context param, empty if there isn't
+	// one
+	ContextName string
+}
+
+// Signature is the parameter and result lists, as written in a method
+func (m decoratorMethod) Signature() string {
+	params := make([]string, len(m.Params))
+
+	for i, param := range m.Params {
+		params[i] = param.Name + " " + param.Type
+	}
+
+	signature := "(" + strings.Join(params, ", ") + ")"
+
+	switch len(m.Results) {
+	case 0:
+	case 1:
+		signature += " " + m.Results[0]
+	default:
+		signature += " (" + strings.Join(m.Results, ", ") + ")"
+	}
+
+	return signature
+}
+
+// Args is how to pass the parameters on to the wrapped value
+func (m decoratorMethod) Args() string {
+	args := make([]string, len(m.Params))
+
+	for i, param := range m.Params {
+		args[i] = param.Name
+
+		if param.Variadic {
+			args[i] += "..."
+		}
+	}
+
+	return strings.Join(args, ", ")
+}
+
+// CallArgs is every param but the context, for decorate.Call
+func (m decoratorMethod) CallArgs() string {
+	var args []string
+
+	for _, param := range m.Params {
+		if param.Name != m.ContextName {
+			args = append(args, param.Name)
+		}
+	}
+
+	return strings.Join(args, ", ")
+}
+
+type decoratorValue struct {
+	Name string
+	Type string
+}
+
+// Values are the non-error results, named result0 and so on
+func (m decoratorMethod) Values() []decoratorValue {
+	count := len(m.Results)
+
+	if m.ReturnsError {
+		count--
+	}
+
+	values := make([]decoratorValue, count)
+
+	for i := range values {
+		values[i] = decoratorValue{Name: fmt.Sprintf("result%d", i), Type: m.Results[i]}
+	}
+
+	return values
+}
+
+// ResultPointers are the addresses of the values, for decorate.Call
+func (m decoratorMethod) ResultPointers() string {
+	var pointers []string
+
+	for _, value := range m.Values() {
+		pointers = append(pointers, "&"+value.Name)
+	}
+
+	return strings.Join(pointers, ", ")
+}
+
+// Assign is the left hand side when calling the wrapped value
+func (m decoratorMethod) Assign() string {
+	var names []string
+
+	for _, value := range m.Values() {
+		names = append(names, value.Name)
+	}
+
+	if m.ReturnsError {
+		names = append(names, "err")
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// OuterContext is the context handed to the middleware
+func (m decoratorMethod) OuterContext() string {
+	if m.ContextName == "" {
+		return "context.Background()"
+	}
+
+	return m.ContextName
+}
+
+// InnerContext is what the invoke func calls its context param
+func (m decoratorMethod) InnerContext() string {
+	if m.ContextName == "" {
+		return "_"
+	}
+
+	return m.ContextName
+}
+
+type decoratorData struct {
+	Package     string
+	Interface   string
+	Decorator   string
+	Constructor string
+	Imports     []string
+	Methods     []decoratorMethod
+}
+
+// generate renders a decorator for the interface, as a whole Go file
+func generate(config generateConfig) ([]byte, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedDeps | packages.NeedImports,
+		Dir:  config.dir,
+	}, config.pattern)
+
+	if err != nil {
+		return nil, fmt.Errorf("packages.Load: %w", err)
+	}
+
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected %q to match one package but it matched %d", config.pattern, len(pkgs))
+	}
+
+	pkg := pkgs[0]
+
+	// The package is allowed to have errors, since it might already be
+	// calling the decorator we're about to generate.  As long as the
+	// interface itself made it through, we're fine.
+	var obj types.Object
+
+	if pkg.Types != nil {
+		obj = pkg.Types.Scope().Lookup(config.interfaceName)
+	}
+
+	if obj == nil {
+		if len(pkg.Errors) > 0 {
+			return nil, fmt.Errorf("failed to load %s: %v", config.pattern, pkg.Errors[0])
+		}
+
+		return nil, fmt.Errorf("no %s in %s", config.interfaceName, pkg.PkgPath)
+	}
+
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+
+	if !ok {
+		return nil, fmt.Errorf("%s isn't an interface", config.interfaceName)
+	}
+
+	data := decoratorData{
+		Package:   pkg.Name,
+		Interface: config.interfaceName,
+		Decorator: config.decoratorName,
+	}
+
+	if data.Decorator == "" {
+		data.Decorator = config.interfaceName + "Decorator"
+	}
+
+	// An unexported decorator gets an unexported constructor
+	if ast.IsExported(data.Decorator) {
+		data.Constructor = "New" + data.Decorator
+	} else {
+		data.Constructor = "new" + codegen.UpperFirst(data.Decorator)
+	}
+
+	imports := map[string]bool{"context": true, decoratePath: true}
+
+	qualifier := func(other *types.Package) string {
+		if other.Path() == pkg.PkgPath {
+			return ""
+		}
+
+		imports[other.Path()] = true
+
+		return other.Name()
+	}
+
+	for i := 0; i < iface.NumMethods(); i++ {
+		data.Methods = append(data.Methods, buildMethod(iface.Method(i), qualifier))
+	}
+
+	data.Imports = codegen.GroupImports(imports)
+
+	var out bytes.Buffer
+
+	err = decoratorTemplate.Execute(&out, data)
+
+	if err != nil {
+		return nil, fmt.Errorf("decoratorTemplate.Execute: %w", err)
+	}
+
+	formatted, err := format.Source(out.Bytes())
+
+	if err != nil {
+		return nil, fmt.Errorf("generated code doesn't parse, this is a bug: %w\n%s", err, out.String())
+	}
+
+	return formatted, nil
+}
+
+var (
+	errorType   = types.Universe.Lookup("error").Type()
+	reservedArg = map[string]bool{"": true, "_": true, "d": true, "call": true, "err": true}
+)
+
+func buildMethod(fn *types.Func, qualifier types.Qualifier) decoratorMethod {
+	signature := fn.Type().(*types.Signature)
+
+	method := decoratorMethod{
+		Name: fn.Name(),
+	}
+
+	for i := 0; i < signature.Params().Len(); i++ {
+		param := signature.Params().At(i)
+		typeName := types.TypeString(param.Type(), qualifier)
+		isContext := i == 0 && typeName == "context.Context"
+		name := param.Name()
+
+		switch {
+		case isContext && (name == "" || name == "_"):
+			name = "ctx"
+		case reservedArg[name] || strings.HasPrefix(name, "result"):
+			// These would clash with the generated code
+			name = fmt.Sprintf("arg%d", i)
+		}
+
+		if isContext {
+			method.ContextName = name
+		}
+
+		variadic := signature.Variadic() && i == signature.Params().Len()-1
+
+		if variadic {
+			typeName = "..." + strings.TrimPrefix(typeName, "[]")
+		}
+
+		method.Params = append(method.Params, decoratorParam{Name: name, Type: typeName, Variadic: variadic})
+	}
+
+	results := signature.Results()
+
+	for i := 0; i < results.Len(); i++ {
+		method.Results = append(method.Results, types.TypeString(results.At(i).Type(), qualifier))
+	}
+
+	method.ReturnsError = results.Len() > 0 && types.Identical(results.At(results.Len()-1).Type(), errorType)
+
+	return method
+}
+
+var decoratorTemplate = template.Must(template.New("decorator").Parse(`// Code generated by localdecorate; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+{{if .}}	"{{.}}"{{end}}
+{{- end}}
+)
+
+// {{.Decorator}} runs every {{.Interface}} call through middleware, and
+// satisfies {{.Interface}} itself so nobody using it can tell
+type {{.Decorator}} struct {
+	next       {{.Interface}}
+	middleware decorate.Middleware
+}
+
+// {{.Constructor}} wraps next so every call goes through the middleware,
+// the first one outermost
+func {{.Constructor}}(next {{.Interface}}, middleware ...decorate.Middleware) *{{.Decorator}} {
+	return &{{.Decorator}}{
+		next:       next,
+		middleware: decorate.Chain(middleware...),
+	}
+}
+{{range .Methods}}
+// {{.Name}} calls through to the wrapped {{$.Interface}}
+func (d *{{$.Decorator}}) {{.Name}}{{.Signature}} {
+{{- range .Values}}
+	var {{.Name}} {{.Type}}
+{{- end}}
+{{- if .Values}}
+{{end}}
+	call := decorate.Call{
+		Interface: "{{$.Interface}}",
+		Method:    "{{.Name}}",
+		Args:      []interface{}{ {{- .CallArgs -}} },
+		Results:   []interface{}{ {{- .ResultPointers -}} },
+	}
+{{if .ReturnsError}}
+	err := d.middleware({{.OuterContext}}, call, func({{.InnerContext}} context.Context) error {
+{{- if .Values}}
+		var err error
+
+		{{.Assign}} = d.next.{{.Name}}({{.Args}})
+
+		return err
+{{- else}}
+		return d.next.{{.Name}}({{.Args}})
+{{- end}}
+	})
+
+	return {{.Assign}}
+{{- else}}
+	// There's no error to hand back, so whatever the middleware says about
+	// it goes nowhere
+	_ = d.middleware({{.OuterContext}}, call, func({{.InnerContext}} context.Context) error {
+		{{if .Values}}{{.Assign}} = {{end}}d.next.{{.Name}}({{.Args}})
+
+		return nil
+	})
+{{- if .Values}}
+
+	return {{.Assign}}
+{{- end}}
+{{- end}}
+}
+{{end}}`))
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerateHandlesEveryKindOfMethod(t *testing.T) {
+	source, err := generate(generateConfig{
+		pattern:       "./testdata/consumer",
+		interfaceName: "Everything",
+	})
+
+	if err != nil {
+		t.Fatal("generate:", err)
+	}
+
+	for _, expected := range []string{
+		"// Code generated by localdecorate; DO NOT EDIT.\n",
+		"\t\"context\"\n\n\t\"github.com/Evertras/go-interface-examples/decorate\"\n",
+		"func NewEverythingDecorator(next Everything, middleware ...decorate.Middleware) *EverythingDecorator {",
+
+		// Embedded interfaces count too, and without a context the
+		// middleware gets a background one
+		"err := d.middleware(context.Background(), call, func(_ context.Context) error {\n\t\treturn d.next.Close()\n",
+
+		// The context isn't one of the args, and the middleware's context
+		// is the one passed on
+		"Args:      []interface{}{count},",
+		"Results:   []interface{}{&result0},",
+		"result0, err = d.next.GetTopUsers(ctx, count)",
+
+		// Names that clash with the generated code get renamed
+		"func (d *EverythingDecorator) Lookup(ctx context.Context, arg1 string) (*db.User, bool, error) {",
+		"func (d *EverythingDecorator) Pair(arg0 int) (int, string) {",
+
+		// No error means nothing to report the middleware's error to
+		"_ = d.middleware(context.Background(), call, func(_ context.Context) error {\n\t\tresult0, result1 = d.next.Pair(arg0)\n",
+
+		// Variadic args are passed on as they came
+		"d.next.Log(format, args...)",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("Expected output to contain %q", expected)
+		}
+	}
+}
+
+func TestGenerateUnexportedInterfaceGetsUnexportedDecorator(t *testing.T) {
+	source, err := generate(generateConfig{
+		pattern:       "../../local-interfaces/cmd",
+		interfaceName: "userStore",
+	})
+
+	if err != nil {
+		t.Fatal("generate:", err)
+	}
+
+	if !strings.Contains(string(source), "func newUserStoreDecorator(next userStore, middleware ...decorate.Middleware) *userStoreDecorator {") {
+		t.Errorf("Expected an unexported constructor but got:\n%s", source)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config generateConfig
+	}{
+		{"MissingType", generateConfig{pattern: "./testdata/consumer", interfaceName: "Nope"}},
+		{"NotAnInterface", generateConfig{pattern: "../../local-interfaces/db", interfaceName: "Db"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := generate(test.config)
+
+			if err == nil {
+				t.Error("Expected an error but got nil")
+			}
+		})
+	}
+}
+
+// The decorators are checked in, so make sure nobody forgot to regenerate
+// them after changing an interface or the template
+func TestGeneratedDecoratorsAreUpToDate(t *testing.T) {
+	tests := []struct {
+		dir           string
+		interfaceName string
+		decoratorName string
+		file          string
+	}{
+		{"../../local-interfaces/cmd", "userStore", "", "decorate_user_store.go"},
+		{"../../local-interfaces/cmd", "scoreNotifier", "", "decorate_score_notifier.go"},
+		{"../../local-interfaces/cmd", "topPlayersNotifier", "", "decorate_top_players_notifier.go"},
+		{"../../local-interfaces/handlers", "UserDataStore", "recordedUserDataStore", "decorate_user_data_store_test.go"},
+		{"../../outside-world/no-velociraptors", "CurrentChampionGetter", "", "decorate_current_champion_getter.go"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			expected, err := generate(generateConfig{
+				pattern:       test.dir,
+				interfaceName: test.interfaceName,
+				decoratorName: test.decoratorName,
+			})
+
+			if err != nil {
+				t.Fatal("generate:", err)
+			}
+
+			actual, err := os.ReadFile(test.dir + "/" + test.file)
+
+			if err != nil {
+				t.Fatal("os.ReadFile:", err)
+			}
+
+			if !bytes.Equal(expected, actual) {
+				t.Errorf("%s is stale, run go generate in %s", test.file, test.dir)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// localdecorate writes a decorator for an interface, so cross-cutting
+// things like logging, metrics and retries can wrap a dependency without
+// the code using it knowing
+//
+// Put a go:generate line next to the interface and run go generate:
+//
+//	//go:generate go run ../../cmd/localdecorate -type CurrentChampionGetter
+//
+// The decorator takes the value to wrap and any number of
+// decorate.Middleware, and satisfies the same interface.  See the decorate
+// package for the middleware that comes with it, and decorate.Hooks for
+// writing your own.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Evertras/go-interface-examples/internal/codegen"
+)
+
+func main() {
+	interfaceName := flag.String("type", "", "interface to decorate")
+	decoratorName := flag.String("name", "", "name of the decorator, defaults to the interface name followed by Decorator")
+	output := flag.String("o", "", "file to write, defaults to decorate_<interface>.go, or - for stdout")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -type Interface [-name Decorator] [-o file] [package]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if *interfaceName == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	pattern := "."
+
+	if flag.NArg() == 1 {
+		pattern = flag.Arg(0)
+	}
+
+	source, err := generate(generateConfig{
+		pattern:       pattern,
+		interfaceName: *interfaceName,
+		decoratorName: *decoratorName,
+	})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "-" {
+		os.Stdout.Write(source)
+		return
+	}
+
+	if *output == "" {
+		*output = "decorate_" + codegen.SnakeCase(*interfaceName) + ".go"
+	}
+
+	err = os.WriteFile(*output, source, 0644)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,19 @@
+// Package consumer has interfaces for localdecorate's tests to decorate
+package consumer
+
+import (
+	"context"
+	"io"
+
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+)
+
+// Everything covers the shapes of method a decorator has to handle
+type Everything interface {
+	io.Closer
+
+	GetTopUsers(ctx context.Context, count int) ([]*db.User, error)
+	Lookup(_ context.Context, d string) (*db.User, bool, error)
+	Pair(call int) (int, string)
+	Log(format string, args ...interface{})
+}
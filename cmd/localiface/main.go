@@ -0,0 +1,17 @@
+// localiface runs the localiface analyzer as a go vet tool
+//
+//	go install ./cmd/localiface
+//	go vet -vettool=$(which localiface) ./...
+//
+// See the analysis/localiface package for what it checks.
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/unitchecker"
+
+	"github.com/Evertras/go-interface-examples/analysis/localiface"
+)
+
+func main() {
+	unitchecker.Main(localiface.Analyzer)
+}
@@ -0,0 +1,36 @@
+// Package example is a tiny consumer with a mock written by localmock
+//
+// It's here so the generated mock has somewhere to live that isn't one of
+// the tutorial's packages.  Those keep their hand-written mocks, since
+// seeing how small a mock can be is half the point.
+package example
+
+import (
+	"context"
+	"fmt"
+)
+
+//go:generate go run .. -type ScoreGetter
+
+// ScoreGetter is everything Badge needs from a user store
+type ScoreGetter interface {
+	GetUserScore(ctx context.Context, id string) (int, error)
+}
+
+// Badge says how a user is doing, for showing next to their name
+func Badge(ctx context.Context, scoreGetter ScoreGetter, id string) (string, error) {
+	score, err := scoreGetter.GetUserScore(ctx, id)
+
+	if err != nil {
+		return "", fmt.Errorf("scoreGetter.GetUserScore: %w", err)
+	}
+
+	switch {
+	case score >= 1000:
+		return "legend", nil
+	case score >= 100:
+		return "regular", nil
+	}
+
+	return "newcomer", nil
+}
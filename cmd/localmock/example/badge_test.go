@@ -0,0 +1,48 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// mockScoreGetter is generated from ScoreGetter, see badge.go
+
+func TestBadge(t *testing.T) {
+	tests := []struct {
+		score    int
+		expected string
+	}{
+		{0, "newcomer"},
+		{100, "regular"},
+		{9000, "legend"},
+	}
+
+	for _, test := range tests {
+		scoreGetter := &mockScoreGetter{pendingGetUserScoreResult: test.score}
+
+		badge, err := Badge(context.Background(), scoreGetter, "evertras")
+
+		if err != nil {
+			t.Fatal("Badge:", err)
+		}
+
+		if badge != test.expected {
+			t.Errorf("Expected %q for %d but got %q", test.expected, test.score, badge)
+		}
+
+		if len(scoreGetter.getUserScoreCalls) != 1 || scoreGetter.getUserScoreCalls[0].id != "evertras" {
+			t.Errorf("Expected one lookup of evertras but got %+v", scoreGetter.getUserScoreCalls)
+		}
+	}
+}
+
+func TestBadgeFailsWhenScoreGetterFails(t *testing.T) {
+	scoreGetter := &mockScoreGetter{pendingError: errors.New("oh no")}
+
+	_, err := Badge(context.Background(), scoreGetter, "evertras")
+
+	if err == nil {
+		t.Error("Expected an error but got nil")
+	}
+}
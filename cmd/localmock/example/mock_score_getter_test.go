@@ -0,0 +1,53 @@
+// Code generated by localmock; DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+	"sync"
+)
+
+// mockScoreGetter fakes ScoreGetter for tests
+//
+// Set pending values to choose what each method returns.  pendingError is
+// returned by every method that can fail, unless that method has its own
+// pending error set.  Set a method's func to take over completely.  Every
+// call is recorded either way, and len of the calls tells you how many
+// there were.
+type mockScoreGetter struct {
+	mu sync.Mutex
+
+	pendingError error
+
+	// GetUserScore
+	pendingGetUserScoreResult int
+	pendingGetUserScoreError  error
+	getUserScoreFunc          func(ctx context.Context, id string) (int, error)
+	getUserScoreCalls         []mockScoreGetterGetUserScoreCall
+}
+
+// mockScoreGetterGetUserScoreCall is the arguments to one GetUserScore call
+type mockScoreGetterGetUserScoreCall struct {
+	ctx context.Context
+	id  string
+}
+
+func (m *mockScoreGetter) GetUserScore(ctx context.Context, id string) (int, error) {
+	m.mu.Lock()
+	m.getUserScoreCalls = append(m.getUserScoreCalls, mockScoreGetterGetUserScoreCall{ctx, id})
+	override := m.getUserScoreFunc
+	pendingGetUserScoreResult := m.pendingGetUserScoreResult
+	err := m.pendingGetUserScoreError
+
+	if err == nil {
+		err = m.pendingError
+	}
+
+	m.mu.Unlock()
+
+	if override != nil {
+		return override(ctx, id)
+	}
+
+	return pendingGetUserScoreResult, err
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"strings"
+	"text/template"
+
+	"github.com/Evertras/go-interface-examples/internal/codegen"
+	"golang.org/x/tools/go/packages"
+)
+
+// generateConfig says which interface to mock and what to call the mock
+type generateConfig struct {
+	// pattern is the package with the interface, usually "."
+	pattern string
+
+	// interfaceName is the local interface to mock, like UserDataStore
+	interfaceName string
+
+	// mockName defaults to mock followed by the interface name
+	mockName string
+
+	// dir is where to run the go command from
+	dir string
+}
+
+type mockParam struct {
+	Name     string
+	Type     string
+	Variadic bool
+}
+
+type mockResult struct {
+	// Field is the pending field that holds this result, empty for errors
+	// since those use the error fields instead
+	Field   string
+	Type    string
+	IsError bool
+}
+
+type mockMethod struct {
+	Name    string
+	Params  []mockParam
+	Results []mockResult
+
+	// lowerName is the method name with a lowercase first letter, for
+	// field names
+	lowerName string
+}
+
+func (m mockMethod) CallsField() string { return m.lowerName + "Calls" }
+func (m mockMethod) FuncField() string  { return m.lowerName + "Func" }
+func (m mockMethod) ErrorField() string { return "pending" + m.Name + "Error" }
+
+func (m mockMethod) ReturnsError() bool {
+	for _, result := range m.Results {
+		if result.IsError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Signature is the parameter and result lists, as written in a method or
+// func type
+func (m mockMethod) Signature() string {
+	params := make([]string, len(m.Params))
+
+	for i, param := range m.Params {
+		params[i] = param.Name + " " + param.Type
+	}
+
+	results := make([]string, len(m.Results))
+
+	for i, result := range m.Results {
+		results[i] = result.Type
+	}
+
+	signature := "(" + strings.Join(params, ", ") + ")"
+
+	switch len(results) {
+	case 0:
+	case 1:
+		signature += " " + results[0]
+	default:
+		signature += " (" + strings.Join(results, ", ") + ")"
+	}
+
+	return signature
+}
+
+// CallArgs is the parameters in order, for filling in a call record
+func (m mockMethod) CallArgs() string {
+	args := make([]string, len(m.Params))
+
+	for i, param := range m.Params {
+		args[i] = param.Name
+	}
+
+	return strings.Join(args, ", ")
+}
+
+// Args is how to pass the parameters straight on to another function
+func (m mockMethod) Args() string {
+	args := make([]string, len(m.Params))
+
+	for i, param := range m.Params {
+		args[i] = param.Name
+
+		if param.Variadic {
+			args[i] += "..."
+		}
+	}
+
+	return strings.Join(args, ", ")
+}
+
+type mockData struct {
+	Package   string
+	Interface string
+	Mock      string
+	Imports   []string
+	Methods   []mockMethod
+}
+
+// generate renders a mock for the interface, as a whole Go file
+func generate(config generateConfig) ([]byte, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:  packages.NeedName | packages.NeedTypes | packages.NeedDeps | packages.NeedImports,
+		Dir:   config.dir,
+		Tests: false,
+	}, config.pattern)
+
+	if err != nil {
+		return nil, fmt.Errorf("packages.Load: %w", err)
+	}
+
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected %q to match one package but it matched %d", config.pattern, len(pkgs))
+	}
+
+	pkg := pkgs[0]
+
+	if len(pkg.Errors) > 0 {
+		return nil, fmt.Errorf("failed to load %s: %v", config.pattern, pkg.Errors[0])
+	}
+
+	obj := pkg.Types.Scope().Lookup(config.interfaceName)
+
+	if obj == nil {
+		return nil, fmt.Errorf("no %s in %s", config.interfaceName, pkg.PkgPath)
+	}
+
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+
+	if !ok {
+		return nil, fmt.Errorf("%s isn't an interface", config.interfaceName)
+	}
+
+	data := mockData{
+		Package:   pkg.Name,
+		Interface: config.interfaceName,
+		Mock:      config.mockName,
+	}
+
+	if data.Mock == "" {
+		data.Mock = "mock" + config.interfaceName
+	}
+
+	imports := map[string]bool{"sync": true}
+
+	qualifier := func(other *types.Package) string {
+		if other.Path() == pkg.PkgPath {
+			return ""
+		}
+
+		imports[other.Path()] = true
+
+		return other.Name()
+	}
+
+	for i := 0; i < iface.NumMethods(); i++ {
+		data.Methods = append(data.Methods, buildMethod(iface.Method(i), qualifier))
+	}
+
+	data.Imports = codegen.GroupImports(imports)
+
+	var out bytes.Buffer
+
+	err = mockTemplate.Execute(&out, data)
+
+	if err != nil {
+		return nil, fmt.Errorf("mockTemplate.Execute: %w", err)
+	}
+
+	formatted, err := format.Source(out.Bytes())
+
+	if err != nil {
+		return nil, fmt.Errorf("generated code doesn't parse, this is a bug: %w\n%s", err, out.String())
+	}
+
+	return formatted, nil
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+func buildMethod(fn *types.Func, qualifier types.Qualifier) mockMethod {
+	signature := fn.Type().(*types.Signature)
+
+	method := mockMethod{
+		Name:      fn.Name(),
+		lowerName: codegen.LowerFirst(fn.Name()),
+	}
+
+	for i := 0; i < signature.Params().Len(); i++ {
+		param := signature.Params().At(i)
+		name := param.Name()
+
+		// The receiver is always m, and blank or missing names can't be
+		// passed along
+		if name == "" || name == "_" || name == "m" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+
+		typeName := types.TypeString(param.Type(), qualifier)
+		variadic := signature.Variadic() && i == signature.Params().Len()-1
+
+		if variadic {
+			typeName = "..." + strings.TrimPrefix(typeName, "[]")
+		}
+
+		method.Params = append(method.Params, mockParam{Name: name, Type: typeName, Variadic: variadic})
+	}
+
+	nonErrors := 0
+
+	for i := 0; i < signature.Results().Len(); i++ {
+		if !types.Identical(signature.Results().At(i).Type(), errorType) {
+			nonErrors++
+		}
+	}
+
+	for i := 0; i < signature.Results().Len(); i++ {
+		result := signature.Results().At(i)
+
+		if types.Identical(result.Type(), errorType) {
+			method.Results = append(method.Results, mockResult{Type: "error", IsError: true})
+			continue
+		}
+
+		field := "pending" + fn.Name()
+
+		switch {
+		case result.Name() != "" && result.Name() != "_":
+			field += codegen.UpperFirst(result.Name())
+		case nonErrors > 1:
+			field += fmt.Sprintf("Result%d", i)
+		default:
+			field += "Result"
+		}
+
+		method.Results = append(method.Results, mockResult{Field: field, Type: types.TypeString(result.Type(), qualifier)})
+	}
+
+	return method
+}
+
+// Params are stored as they came in, which for variadic ones means a slice
+func callFieldType(param mockParam) string {
+	if param.Variadic {
+		return "[]" + strings.TrimPrefix(param.Type, "...")
+	}
+
+	return param.Type
+}
+
+var mockTemplate = template.Must(template.New("mock").Funcs(template.FuncMap{
+	"callFieldType": callFieldType,
+}).Parse(`// Code generated by localmock; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+{{if .}}	"{{.}}"{{end}}
+{{- end}}
+)
+
+// {{.Mock}} fakes {{.Interface}} for tests
+//
+// Set pending values to choose what each method returns.  pendingError is
+// returned by every method that can fail, unless that method has its own
+// pending error set.  Set a method's func to take over completely.  Every
+// call is recorded either way, and len of the calls tells you how many
+// there were.
+type {{.Mock}} struct {
+	mu sync.Mutex
+
+	pendingError error
+{{range .Methods}}
+	// {{.Name}}
+{{- range .Results}}{{if .Field}}
+	{{.Field}} {{.Type}}
+{{- end}}{{end}}
+{{- if .ReturnsError}}
+	{{.ErrorField}} error
+{{- end}}
+	{{.FuncField}} func{{.Signature}}
+	{{.CallsField}} []{{$.Mock}}{{.Name}}Call
+{{end}}
+}
+{{range .Methods}}
+// {{$.Mock}}{{.Name}}Call is the arguments to one {{.Name}} call
+type {{$.Mock}}{{.Name}}Call struct {
+{{- range .Params}}
+	{{.Name}} {{callFieldType .}}
+{{- end}}
+}
+
+func (m *{{$.Mock}}) {{.Name}}{{.Signature}} {
+	m.mu.Lock()
+	m.{{.CallsField}} = append(m.{{.CallsField}}, {{$.Mock}}{{.Name}}Call{ {{- .CallArgs -}} })
+	override := m.{{.FuncField}}
+{{- range .Results}}{{if .Field}}
+	{{.Field}} := m.{{.Field}}
+{{- end}}{{end}}
+{{- if .ReturnsError}}
+	err := m.{{.ErrorField}}
+
+	if err == nil {
+		err = m.pendingError
+	}
+{{end}}
+	m.mu.Unlock()
+
+	if override != nil {
+		{{if .Results}}return {{end}}override({{.Args}})
+	}
+{{- if .Results}}
+
+	return {{range $i, $result := .Results}}{{if $i}}, {{end}}{{if .IsError}}err{{else}}{{.Field}}{{end}}{{end}}
+{{- end}}
+}
+{{end}}`))
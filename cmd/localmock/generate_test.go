@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerateHandlesEveryKindOfMethod(t *testing.T) {
+	source, err := generate(generateConfig{
+		pattern:       "./testdata/consumer",
+		interfaceName: "Everything",
+	})
+
+	if err != nil {
+		t.Fatal("generate:", err)
+	}
+
+	for _, expected := range []string{
+		"// Code generated by localmock; DO NOT EDIT.\n",
+		"\t\"context\"\n\t\"sync\"\n\n\t\"github.com/Evertras/go-interface-examples/local-interfaces/db\"\n",
+		"type mockEverything struct {",
+
+		// Embedded interfaces count too
+		"func (m *mockEverything) Close() error {",
+
+		// One unnamed result is just Result
+		"pendingGetTopUsersResult []*db.User",
+		"pendingGetTopUsersError  error",
+
+		// Named results keep their names, several unnamed ones get numbers
+		"pendingLookupUser  *db.User",
+		"pendingLookupFound bool",
+		"pendingPairResult0 int",
+		"pendingPairResult1 string",
+
+		// Blank params and params that would clash with the receiver get
+		// renamed so they can be recorded
+		"func (m *mockEverything) Lookup(ctx context.Context, arg1 string) (*db.User, bool, error) {",
+		"func (m *mockEverything) Pair(arg0 int) (int, string) {",
+
+		// Variadic args are recorded as a slice and passed on as they came
+		"\targs   []interface{}\n",
+		"override(format, args...)",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("Expected output to contain %q", expected)
+		}
+	}
+
+	if strings.Contains(string(source), "pendingLogError") || strings.Contains(string(source), "pendingPairError") {
+		t.Error("Methods that can't fail shouldn't get a pending error")
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config generateConfig
+	}{
+		{"MissingType", generateConfig{pattern: "./testdata/consumer", interfaceName: "Nope"}},
+		{"NotAnInterface", generateConfig{pattern: "../../local-interfaces/db", interfaceName: "Db"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := generate(test.config)
+
+			if err == nil {
+				t.Error("Expected an error but got nil")
+			}
+		})
+	}
+}
+
+// The example's mock is checked in, so make sure nobody forgot to
+// regenerate it after changing the interface or the template
+func TestGeneratedExampleMockIsUpToDate(t *testing.T) {
+	const mockFile = "example/mock_score_getter_test.go"
+
+	expected, err := generate(generateConfig{
+		pattern:       "./example",
+		interfaceName: "ScoreGetter",
+	})
+
+	if err != nil {
+		t.Fatal("generate:", err)
+	}
+
+	actual, err := os.ReadFile(mockFile)
+
+	if err != nil {
+		t.Fatal("os.ReadFile:", err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("%s is stale, run go generate ./cmd/localmock/...", mockFile)
+	}
+}
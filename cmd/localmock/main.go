@@ -0,0 +1,76 @@
+// localmock writes a mock for a local interface, in the same shape as the
+// hand-written mocks in this repo
+//
+// Put a go:generate line next to the interface and run go generate:
+//
+//	//go:generate go run ../../cmd/localmock -type ScoreGetter
+//
+// See the example package for one that's been generated.
+//
+// The mock gets pending fields for every result so a test can choose what
+// comes back, a shared pendingError for the simple "everything fails"
+// case, a slice of calls per method to check what was asked for, and an
+// optional func per method for when a test needs to do something clever.
+// It's written to a _test.go file so it never ends up in the real build.
+//
+// Small interfaces make for small mocks.  If the generated mock is getting
+// big, that's the interface telling you something.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Evertras/go-interface-examples/internal/codegen"
+)
+
+func main() {
+	interfaceName := flag.String("type", "", "local interface to mock")
+	mockName := flag.String("name", "", "name of the mock, defaults to mock followed by the interface name")
+	output := flag.String("o", "", "file to write, defaults to mock_<interface>_test.go, or - for stdout")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -type Interface [-name mockName] [-o file] [package]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if *interfaceName == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	pattern := "."
+
+	if flag.NArg() == 1 {
+		pattern = flag.Arg(0)
+	}
+
+	source, err := generate(generateConfig{
+		pattern:       pattern,
+		interfaceName: *interfaceName,
+		mockName:      *mockName,
+	})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "-" {
+		os.Stdout.Write(source)
+		return
+	}
+
+	if *output == "" {
+		*output = "mock_" + codegen.SnakeCase(*interfaceName) + "_test.go"
+	}
+
+	err = os.WriteFile(*output, source, 0644)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
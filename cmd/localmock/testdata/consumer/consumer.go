@@ -0,0 +1,19 @@
+// Package consumer has interfaces for localmock's tests to mock
+package consumer
+
+import (
+	"context"
+	"io"
+
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+)
+
+// Everything covers the shapes of method a mock has to handle
+type Everything interface {
+	io.Closer
+
+	GetTopUsers(ctx context.Context, count int) ([]*db.User, error)
+	Lookup(ctx context.Context, _ string) (user *db.User, found bool, err error)
+	Pair(m int) (int, string)
+	Log(format string, args ...interface{})
+}
@@ -0,0 +1,48 @@
+// tracecollector is a stand-in for an OpenTelemetry collector, for when
+// you want to see traces locally without running the real thing
+//
+// It accepts OTLP/HTTP JSON on /v1/traces, the default OTLP port included,
+// and writes every batch it gets as a line of JSON:
+//
+//	go run ./cmd/tracecollector -o traces.jsonl
+//
+// Point a tracing.HTTPExporter at http://localhost:4318/v1/traces.
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/Evertras/go-interface-examples/tracing"
+)
+
+func main() {
+	address := flag.String("address", "localhost:4318", "where to listen for OTLP/HTTP")
+	output := flag.String("o", "-", "file to append batches to, or - for stdout")
+
+	flag.Parse()
+
+	var out io.Writer = os.Stdout
+
+	if *output != "-" {
+		file, err := os.OpenFile(*output, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		defer file.Close()
+
+		out = file
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/traces", tracing.NewCollector(out))
+
+	log.Println("Collecting traces on", *address)
+
+	log.Fatal(http.ListenAndServe(*address, mux))
+}
@@ -0,0 +1,451 @@
+// Package config fills a struct from layers of configuration, each one
+// overriding the last:
+//
+//	defaults < YAML or JSON file < environment variables < flags
+//
+// Defaults are whatever the struct holds before Load is called, so they
+// live right next to the code that uses them.  Everything else is worked
+// out from the struct itself.  Every field gets a flag and an environment
+// variable, named after its yaml key:
+//
+//	type serverConfig struct {
+//		Address string `yaml:"address" help:"Where to listen"`
+//		Store   struct {
+//			ChampionFile string `yaml:"champion_file"`
+//		} `yaml:"store"`
+//	}
+//
+// gives -address and GSL_ADDRESS, and -store.champion-file and
+// GSL_STORE_CHAMPION_FILE.  The file is picked with -config or GSL_CONFIG.
+//
+// Fields tagged `flag:"-"` don't get a flag, which is what you want for
+// secrets since flags show up in ps.  Fields tagged `secret:"true"` are
+// redacted by -print-config.
+//
+// If the struct has a Validate method, it's called once every layer has
+// been applied.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrPrinted means -print-config was given, and the config has been
+// printed instead of being returned for use.  Exit without running.
+var ErrPrinted = errors.New("config printed")
+
+// redacted replaces secrets when printing
+const redacted = "[redacted]"
+
+// Loader knows where to look for configuration
+type Loader struct {
+	// Name is the program name for usage messages
+	Name string
+
+	// EnvPrefix starts every environment variable, like GSL
+	EnvPrefix string
+
+	// LookupEnv reads the environment, os.LookupEnv if nil
+	LookupEnv func(key string) (string, bool)
+
+	// Output is where usage and -print-config go, os.Stderr and os.Stdout
+	// respectively if nil
+	Output io.Writer
+}
+
+// Validator is a config that can check itself once it's loaded
+type Validator interface {
+	Validate() error
+}
+
+// field is one settable value somewhere in the config struct
+type field struct {
+	key    string
+	value  reflect.Value
+	help   string
+	secret bool
+	noFlag bool
+}
+
+func (f field) flagName() string {
+	return strings.ReplaceAll(f.key, "_", "-")
+}
+
+func (l Loader) envName(f field) string {
+	return l.EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(f.key, ".", "_"))
+}
+
+// flagValue holds a flag until every other layer has been applied, so the
+// flag can win
+type flagValue struct {
+	field *field
+	raw   string
+	set   bool
+}
+
+// String is the default shown in usage, which is hidden when it's zero
+func (v *flagValue) String() string {
+	if v == nil || v.field == nil || v.field.value.IsZero() {
+		return ""
+	}
+
+	return format(v.field.value)
+}
+
+func (v *flagValue) Set(raw string) error {
+	// Check it parses now, so the error points at the flag
+	err := parse(reflect.New(v.field.value.Type()).Elem(), raw)
+
+	if err != nil {
+		return err
+	}
+
+	v.raw = raw
+	v.set = true
+
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.field.value.Kind() == reflect.Bool
+}
+
+// Load fills cfg, a pointer to a struct that already holds the defaults,
+// from the file, environment and args.  Args don't include the program
+// name, so pass os.Args[1:].
+//
+// flag.ErrHelp comes back for -h, and ErrPrinted for -print-config.
+func (l Loader) Load(cfg interface{}, args []string) error {
+	root := reflect.ValueOf(cfg)
+
+	if root.Kind() != reflect.Ptr || root.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config must be a pointer to a struct, not %T", cfg)
+	}
+
+	lookupEnv := l.LookupEnv
+
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	var fields []field
+
+	err := collect(root.Elem(), "", &fields)
+
+	if err != nil {
+		return err
+	}
+
+	flags := flag.NewFlagSet(l.Name, flag.ContinueOnError)
+	configFile := flags.String("config", "", "YAML or JSON file to read, also "+l.EnvPrefix+"_CONFIG")
+	printConfig := flags.Bool("print-config", false, "Print the config that would be used, then exit")
+
+	if l.Output != nil {
+		flags.SetOutput(l.Output)
+	}
+
+	flagValues := make([]*flagValue, len(fields))
+
+	for i := range fields {
+		if fields[i].noFlag {
+			continue
+		}
+
+		flagValues[i] = &flagValue{field: &fields[i]}
+
+		usage := fields[i].help
+
+		if usage != "" {
+			usage += ", "
+		}
+
+		flags.Var(flagValues[i], fields[i].flagName(), usage+"also "+l.envName(fields[i]))
+	}
+
+	err = flags.Parse(args)
+
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q", flags.Args())
+	}
+
+	if *configFile == "" {
+		*configFile, _ = lookupEnv(l.EnvPrefix + "_CONFIG")
+	}
+
+	if *configFile != "" {
+		err = loadFile(cfg, *configFile)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, f := range fields {
+		raw, ok := lookupEnv(l.envName(f))
+
+		if !ok {
+			continue
+		}
+
+		err = parse(f.value, raw)
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", l.envName(f), err)
+		}
+	}
+
+	for _, value := range flagValues {
+		if value == nil || !value.set {
+			continue
+		}
+
+		err = parse(value.field.value, value.raw)
+
+		if err != nil {
+			return fmt.Errorf("-%s: %w", value.field.flagName(), err)
+		}
+	}
+
+	if validator, ok := cfg.(Validator); ok {
+		err = validator.Validate()
+
+		if err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+	}
+
+	if *printConfig {
+		output := l.Output
+
+		if output == nil {
+			output = os.Stdout
+		}
+
+		err = Print(output, cfg)
+
+		if err != nil {
+			return err
+		}
+
+		return ErrPrinted
+	}
+
+	return nil
+}
+
+// collect finds every field we know how to set, depth first
+func collect(value reflect.Value, prefix string, fields *[]field) error {
+	structType := value.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		structField := structType.Field(i)
+
+		if !structField.IsExported() {
+			continue
+		}
+
+		key := yamlKey(structField)
+
+		if key == "-" {
+			continue
+		}
+
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		fieldValue := value.Field(i)
+
+		if fieldValue.Kind() == reflect.Struct && fieldValue.Type() != durationType {
+			err := collect(fieldValue, key, fields)
+
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !settable(fieldValue) {
+			return fmt.Errorf("config field %s is a %s, which isn't supported", key, fieldValue.Type())
+		}
+
+		*fields = append(*fields, field{
+			key:    key,
+			value:  fieldValue,
+			help:   structField.Tag.Get("help"),
+			secret: structField.Tag.Get("secret") == "true",
+			noFlag: structField.Tag.Get("flag") == "-",
+		})
+	}
+
+	return nil
+}
+
+func yamlKey(structField reflect.StructField) string {
+	name := strings.Split(structField.Tag.Get("yaml"), ",")[0]
+
+	if name == "" {
+		return strings.ToLower(structField.Name)
+	}
+
+	return name
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func settable(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
+
+// parse sets value from a string, the way a flag or environment variable
+// would spell it
+func parse(value reflect.Value, raw string) error {
+	if value.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+
+		if err != nil {
+			return err
+		}
+
+		value.SetInt(int64(d))
+
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+
+		if err != nil {
+			return err
+		}
+
+		value.SetBool(b)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, value.Type().Bits())
+
+		if err != nil {
+			return err
+		}
+
+		value.SetFloat(f)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+
+		if err != nil {
+			return err
+		}
+
+		value.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+
+		if err != nil {
+			return err
+		}
+
+		value.SetUint(n)
+	}
+
+	return nil
+}
+
+func format(value reflect.Value) string {
+	if value.Type() == durationType {
+		return time.Duration(value.Int()).String()
+	}
+
+	return fmt.Sprint(value.Interface())
+}
+
+// loadFile lays the file over what's already in cfg.  JSON is YAML as far
+// as the parser is concerned, so one reader does both.  Keys that don't
+// match a field are an error, since a typo would otherwise be silently
+// ignored.
+func loadFile(cfg interface{}, filename string) error {
+	contents, err := os.ReadFile(filename)
+
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(cfg)
+
+	// An empty file is a perfectly good way to say "just the defaults"
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return nil
+}
+
+// Print writes cfg as YAML, with secrets redacted, so it can be checked or
+// saved as a starting point for a config file
+func Print(w io.Writer, cfg interface{}) error {
+	original := reflect.ValueOf(cfg)
+
+	if original.Kind() == reflect.Ptr {
+		original = original.Elem()
+	}
+
+	// Work on a copy so redacting doesn't touch the real thing
+	printable := reflect.New(original.Type())
+	printable.Elem().Set(original)
+
+	var fields []field
+
+	err := collect(printable.Elem(), "", &fields)
+
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if f.secret && f.value.Kind() == reflect.String && f.value.String() != "" {
+			f.value.SetString(redacted)
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	err = encoder.Encode(printable.Interface())
+
+	if err != nil {
+		return fmt.Errorf("yaml.Encode: %w", err)
+	}
+
+	return encoder.Close()
+}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Address string        `yaml:"address" help:"Where to listen"`
+	Timeout time.Duration `yaml:"timeout"`
+	Token   string        `yaml:"token" flag:"-" secret:"true"`
+
+	Store struct {
+		Kind      string  `yaml:"kind"`
+		ErrorRate float64 `yaml:"error_rate"`
+		Retries   int     `yaml:"retries"`
+		Enabled   bool    `yaml:"enabled"`
+	} `yaml:"store"`
+}
+
+func (c *testConfig) Validate() error {
+	if c.Store.Retries < 1 {
+		return errors.New("store.retries must be at least 1")
+	}
+
+	return nil
+}
+
+func defaults() *testConfig {
+	cfg := &testConfig{
+		Address: ":8080",
+		Timeout: 5 * time.Second,
+	}
+
+	cfg.Store.Kind = "memory"
+	cfg.Store.Retries = 3
+
+	return cfg
+}
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := vars[key]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name string, contents string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), name)
+
+	err := os.WriteFile(filename, []byte(contents), 0644)
+
+	if err != nil {
+		t.Fatal("os.WriteFile:", err)
+	}
+
+	return filename
+}
+
+func TestLayersOverrideInOrder(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+address: ":9000"
+timeout: 10s
+store:
+  kind: chaos
+  error_rate: 0.5
+  retries: 5
+`)
+
+	cfg := defaults()
+
+	loader := Loader{
+		Name:      "test",
+		EnvPrefix: "TEST",
+		LookupEnv: env(map[string]string{
+			"TEST_CONFIG":        file,
+			"TEST_TIMEOUT":       "20s",
+			"TEST_STORE_RETRIES": "7",
+			"TEST_TOKEN":         "shh",
+		}),
+	}
+
+	err := loader.Load(cfg, []string{"-store.retries", "9", "-store.enabled"})
+
+	if err != nil {
+		t.Fatal("Load:", err)
+	}
+
+	// address from the file, timeout from env, retries from flags
+	if cfg.Address != ":9000" {
+		t.Errorf("Expected address from the file but got %q", cfg.Address)
+	}
+
+	if cfg.Timeout != 20*time.Second {
+		t.Errorf("Expected timeout from the environment but got %v", cfg.Timeout)
+	}
+
+	if cfg.Store.Retries != 9 || !cfg.Store.Enabled {
+		t.Errorf("Expected retries and enabled from flags but got %+v", cfg.Store)
+	}
+
+	if cfg.Store.Kind != "chaos" || cfg.Store.ErrorRate != 0.5 {
+		t.Errorf("Expected the rest of store from the file but got %+v", cfg.Store)
+	}
+
+	if cfg.Token != "shh" {
+		t.Errorf("Expected the token from the environment but got %q", cfg.Token)
+	}
+}
+
+func TestDefaultsStayWithNothingElse(t *testing.T) {
+	cfg := defaults()
+
+	err := Loader{Name: "test", EnvPrefix: "TEST", LookupEnv: env(nil)}.Load(cfg, nil)
+
+	if err != nil {
+		t.Fatal("Load:", err)
+	}
+
+	if *cfg != *defaults() {
+		t.Errorf("Expected defaults %+v but got %+v", *defaults(), *cfg)
+	}
+}
+
+func TestJSONFilesWork(t *testing.T) {
+	file := writeFile(t, "config.json", `{"address": ":9001", "store": {"kind": "chaos"}}`)
+	cfg := defaults()
+
+	err := Loader{Name: "test", EnvPrefix: "TEST", LookupEnv: env(nil)}.Load(cfg, []string{"-config", file})
+
+	if err != nil {
+		t.Fatal("Load:", err)
+	}
+
+	if cfg.Address != ":9001" || cfg.Store.Kind != "chaos" || cfg.Store.Retries != 3 {
+		t.Errorf("Unexpected config %+v", *cfg)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		env      map[string]string
+		args     []string
+		contains string
+	}{
+		{"UnknownFileKey", "adress: typo\n", nil, nil, "adress"},
+		{"BadEnv", "", map[string]string{"TEST_TIMEOUT": "soon"}, nil, "TEST_TIMEOUT"},
+		{"BadFlag", "", nil, []string{"-store.retries", "lots"}, "store.retries"},
+		{"SecretsHaveNoFlag", "", nil, []string{"-token", "shh"}, "token"},
+		{"Invalid", "", nil, []string{"-store.retries", "0"}, "at least 1"},
+		{"StrayArgs", "", nil, []string{"extra"}, "unexpected"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := test.args
+
+			if test.file != "" {
+				args = append([]string{"-config", writeFile(t, "config.yaml", test.file)}, args...)
+			}
+
+			var output bytes.Buffer
+
+			err := Loader{Name: "test", EnvPrefix: "TEST", LookupEnv: env(test.env), Output: &output}.Load(defaults(), args)
+
+			if err == nil || !strings.Contains(err.Error(), test.contains) {
+				t.Errorf("Expected an error containing %q but got %v", test.contains, err)
+			}
+		})
+	}
+}
+
+func TestPrintConfigRedactsSecrets(t *testing.T) {
+	var output bytes.Buffer
+
+	cfg := defaults()
+
+	err := Loader{
+		Name:      "test",
+		EnvPrefix: "TEST",
+		LookupEnv: env(map[string]string{"TEST_TOKEN": "shh"}),
+		Output:    &output,
+	}.Load(cfg, []string{"-print-config"})
+
+	if !errors.Is(err, ErrPrinted) {
+		t.Fatalf("Expected %v but got %v", ErrPrinted, err)
+	}
+
+	printed := output.String()
+
+	for _, expected := range []string{`address: :8080`, `timeout: 5s`, `token: '[redacted]'`, `retries: 3`} {
+		if !strings.Contains(printed, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, printed)
+		}
+	}
+
+	if strings.Contains(printed, "shh") {
+		t.Errorf("Expected the secret to be redacted but got:\n%s", printed)
+	}
+
+	if cfg.Token != "shh" {
+		t.Error("Expected printing to leave the real config alone")
+	}
+
+	// What we print can be read straight back in
+	reloaded := defaults()
+
+	err = Loader{Name: "test", EnvPrefix: "TEST", LookupEnv: env(nil)}.Load(reloaded, []string{"-config", writeFile(t, "printed.yaml", printed)})
+
+	if err != nil {
+		t.Fatal("Expected printed config to load but got:", err)
+	}
+}
+
+func TestHelpListsEnvironmentVariables(t *testing.T) {
+	var output bytes.Buffer
+
+	err := Loader{Name: "test", EnvPrefix: "TEST", LookupEnv: env(nil), Output: &output}.Load(defaults(), []string{"-h"})
+
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("Expected %v but got %v", flag.ErrHelp, err)
+	}
+
+	for _, expected := range []string{"-store.error-rate", "TEST_STORE_ERROR_RATE", "Where to listen", "(default :8080)"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected %q in:\n%s", expected, output.String())
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Package decorate has the pieces generated decorators are built from.
+//
+// A decorator wraps something that satisfies a local interface and is
+// itself the same interface, so the code using it can't tell the
+// difference.  That's the whole trick: logging, metrics and retries get
+// added in main, and the handlers and leaderboard never have to know.
+//
+// Generate a decorator with cmd/localdecorate, then hand it middleware:
+//
+//	store := newUserStoreDecorator(db.New(),
+//		decorate.Logging(logger),
+//		stats.Middleware(),
+//		decorate.Retry(3, 50*time.Millisecond, nil),
+//	)
+//
+// The first middleware is the outermost, so that reads as
+// logging(metrics(retry(db.New()))).
+package decorate
+
+import (
+	"context"
+	"time"
+)
+
+// Call describes one method call going through a decorator
+type Call struct {
+	// Interface is the interface the decorator was generated for
+	Interface string
+
+	// Method is the method being called
+	Method string
+
+	// Args are the arguments, minus the context
+	Args []interface{}
+
+	// Results point at where the results go, minus the error.  They're
+	// filled in by the time next returns, and middleware that answers
+	// without calling next at all can fill them in itself.
+	Results []interface{}
+}
+
+// Invoke carries on with the call, either to the next middleware or to the
+// wrapped value itself.  It can be called more than once, which is how
+// retries work.
+type Invoke func(ctx context.Context) error
+
+// Middleware runs around every call.  It must call next to get anything
+// done, and should return what next returns unless it has good reason not
+// to.
+type Middleware func(ctx context.Context, call Call, next Invoke) error
+
+// Chain turns several middleware into one, with the first one outermost
+func Chain(middleware ...Middleware) Middleware {
+	return func(ctx context.Context, call Call, next Invoke) error {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			next = wrap(middleware[i], call, next)
+		}
+
+		return next(ctx)
+	}
+}
+
+func wrap(middleware Middleware, call Call, next Invoke) Invoke {
+	return func(ctx context.Context) error {
+		return middleware(ctx, call, next)
+	}
+}
+
+// Hooks is the simple way to write middleware, for when you only want to
+// watch calls go by rather than change them.  Any of them can be nil.
+type Hooks struct {
+	// Before runs before the call, and can add to the context it's made
+	// with
+	Before func(ctx context.Context, call Call) context.Context
+
+	// After runs after every call, failed or not
+	After func(ctx context.Context, call Call, elapsed time.Duration, err error)
+
+	// OnError runs when the call fails, before After.  Whatever it returns
+	// is the error the caller sees, so return err to leave it alone.
+	OnError func(ctx context.Context, call Call, err error) error
+}
+
+// Middleware runs the hooks around each call
+func (h Hooks) Middleware() Middleware {
+	return func(ctx context.Context, call Call, next Invoke) error {
+		if h.Before != nil {
+			ctx = h.Before(ctx, call)
+		}
+
+		start := time.Now()
+		err := next(ctx)
+		elapsed := time.Since(start)
+
+		if err != nil && h.OnError != nil {
+			err = h.OnError(ctx, call, err)
+		}
+
+		if h.After != nil {
+			h.After(ctx, call, elapsed, err)
+		}
+
+		return err
+	}
+}
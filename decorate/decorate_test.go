@@ -0,0 +1,204 @@
+package decorate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testCall = Call{Interface: "Thing", Method: "Do", Args: []interface{}{1}}
+
+func TestChainRunsFirstMiddlewareOutermost(t *testing.T) {
+	var order []string
+
+	record := func(name string) Middleware {
+		return func(ctx context.Context, call Call, next Invoke) error {
+			order = append(order, name+" before")
+			err := next(ctx)
+			order = append(order, name+" after")
+
+			return err
+		}
+	}
+
+	err := Chain(record("a"), record("b"))(context.Background(), testCall, func(ctx context.Context) error {
+		order = append(order, "call")
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	expected := "a before,b before,call,b after,a after"
+
+	if strings.Join(order, ",") != expected {
+		t.Errorf("Expected %s but got %s", expected, strings.Join(order, ","))
+	}
+}
+
+func TestChainWithNothingJustCalls(t *testing.T) {
+	called := false
+
+	_ = Chain()(context.Background(), testCall, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+
+	if !called {
+		t.Error("Expected the call to happen")
+	}
+}
+
+type ctxKey struct{}
+
+func TestHooksRunInOrder(t *testing.T) {
+	failure := errors.New("oh no")
+	replaced := errors.New("replaced")
+
+	var sawValue interface{}
+	var afterErr error
+
+	hooks := Hooks{
+		Before: func(ctx context.Context, call Call) context.Context {
+			return context.WithValue(ctx, ctxKey{}, "from before")
+		},
+		OnError: func(ctx context.Context, call Call, err error) error {
+			if err != failure {
+				t.Errorf("Expected OnError to see the failure but got %v", err)
+			}
+
+			return replaced
+		},
+		After: func(ctx context.Context, call Call, elapsed time.Duration, err error) {
+			afterErr = err
+		},
+	}
+
+	err := hooks.Middleware()(context.Background(), testCall, func(ctx context.Context) error {
+		sawValue = ctx.Value(ctxKey{})
+		return failure
+	})
+
+	if sawValue != "from before" {
+		t.Errorf("Expected the call to get Before's context but saw %v", sawValue)
+	}
+
+	if err != replaced || afterErr != replaced {
+		t.Errorf("Expected OnError's error everywhere after it, got %v and %v", err, afterErr)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	failure := errors.New("flaky")
+	permanent := errors.New("permanent")
+
+	tests := []struct {
+		name          string
+		errs          []error
+		retryable     func(error) bool
+		expectedCalls int
+		expectedErr   error
+	}{
+		{"SucceedsFirstTime", []error{nil}, nil, 1, nil},
+		{"SucceedsEventually", []error{failure, failure, nil}, nil, 3, nil},
+		{"GivesUp", []error{failure, failure, failure, nil}, nil, 3, failure},
+		{"ContextErrorsAreFinal", []error{context.DeadlineExceeded, nil}, nil, 1, context.DeadlineExceeded},
+		{"NotRetryable", []error{permanent, nil}, func(err error) bool { return err != permanent }, 1, permanent},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+
+			err := Retry(3, time.Millisecond, test.retryable)(context.Background(), testCall, func(ctx context.Context) error {
+				err := test.errs[calls]
+				calls++
+
+				return err
+			})
+
+			if calls != test.expectedCalls {
+				t.Errorf("Expected %d calls but got %d", test.expectedCalls, calls)
+			}
+
+			if !errors.Is(err, test.expectedErr) {
+				t.Errorf("Expected error %v but got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWaitingWhenContextEnds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	failure := errors.New("flaky")
+	calls := 0
+
+	err := Retry(3, time.Hour, nil)(ctx, testCall, func(ctx context.Context) error {
+		calls++
+		cancel()
+
+		return failure
+	})
+
+	if calls != 1 || err != failure {
+		t.Errorf("Expected one call returning the last failure but got %d calls and %v", calls, err)
+	}
+}
+
+func TestStatsCountsCallsAndErrors(t *testing.T) {
+	stats := NewStats()
+	middleware := stats.Middleware()
+
+	for _, err := range []error{nil, errors.New("oh no"), nil} {
+		_ = middleware(context.Background(), testCall, func(ctx context.Context) error { return err })
+	}
+
+	_ = middleware(context.Background(), Call{Interface: "Another", Method: "Thing"}, func(ctx context.Context) error { return nil })
+
+	snapshot := stats.Snapshot()
+
+	if len(snapshot) != 2 {
+		t.Fatalf("Expected 2 methods but got %+v", snapshot)
+	}
+
+	if snapshot[0].Interface != "Another" || snapshot[0].Calls != 1 {
+		t.Errorf("Expected Another.Thing first with 1 call but got %+v", snapshot[0])
+	}
+
+	if snapshot[1].Calls != 3 || snapshot[1].Errors != 1 {
+		t.Errorf("Expected Thing.Do with 3 calls and 1 error but got %+v", snapshot[1])
+	}
+}
+
+// Logs whatever it finds under ctxKey, like a request ID handler would
+type ctxKeyLogHandler struct {
+	slog.Handler
+}
+
+func (h ctxKeyLogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if value, ok := ctx.Value(ctxKey{}).(string); ok {
+		record.AddAttrs(slog.String("from_context", value))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func TestStructuredLoggingUsesCallContext(t *testing.T) {
+	var out bytes.Buffer
+
+	middleware := StructuredLogging(slog.New(ctxKeyLogHandler{slog.NewTextHandler(&out, nil)}))
+	ctx := context.WithValue(context.Background(), ctxKey{}, "abc-123")
+
+	_ = middleware(ctx, testCall, func(ctx context.Context) error { return errors.New("oh no") })
+
+	for _, expected := range []string{"level=ERROR", "interface=Thing", "method=Do", `error="oh no"`, "from_context=abc-123"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %s in %q", expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package decorate
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Logging logs every call with how long it took, and the error if it failed
+func Logging(logger *log.Logger) Middleware {
+	return Hooks{
+		After: func(ctx context.Context, call Call, elapsed time.Duration, err error) {
+			if err != nil {
+				logger.Printf("%s.%s%v failed after %v: %v", call.Interface, call.Method, call.Args, elapsed, err)
+				return
+			}
+
+			logger.Printf("%s.%s%v took %v", call.Interface, call.Method, call.Args, elapsed)
+		},
+	}.Middleware()
+}
+
+// StructuredLogging logs every call as a structured record, with the
+// context the call was made with.  Whatever the logger's handler pulls out
+// of that context, like a request ID, ends up on the record too.
+func StructuredLogging(logger *slog.Logger) Middleware {
+	return Hooks{
+		After: func(ctx context.Context, call Call, elapsed time.Duration, err error) {
+			attrs := []slog.Attr{
+				slog.String("interface", call.Interface),
+				slog.String("method", call.Method),
+				slog.Any("args", call.Args),
+				slog.Duration("elapsed", elapsed),
+			}
+
+			if err != nil {
+				logger.LogAttrs(ctx, slog.LevelError, "Call failed", append(attrs, slog.Any("error", err))...)
+				return
+			}
+
+			logger.LogAttrs(ctx, slog.LevelInfo, "Call succeeded", attrs...)
+		},
+	}.Middleware()
+}
+
+// Retry tries failed calls again, up to attempts times in total, waiting a
+// little longer each time.  Context errors are never retried since there's
+// nobody waiting for the answer.  retryable can narrow it down further,
+// nil means everything else is worth another go.
+//
+// Only retry things that are safe to do twice.
+func Retry(attempts int, backoff time.Duration, retryable func(error) bool) Middleware {
+	return func(ctx context.Context, call Call, next Invoke) error {
+		var err error
+
+		for attempt := 1; ; attempt++ {
+			err = next(ctx)
+
+			if err == nil || attempt >= attempts || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return err
+			}
+
+			if retryable != nil && !retryable(err) {
+				return err
+			}
+
+			timer := time.NewTimer(backoff * time.Duration(attempt))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// MethodStats is what Stats knows about one method
+type MethodStats struct {
+	Interface string
+	Method    string
+	Calls     int
+	Errors    int
+	Total     time.Duration
+}
+
+// Stats counts calls, errors and time spent per method.  Put its
+// middleware outside any retries to see what the caller saw, or inside to
+// count every attempt.
+type Stats struct {
+	mu      sync.Mutex
+	methods map[string]*MethodStats
+}
+
+// NewStats returns empty Stats ready to count
+func NewStats() *Stats {
+	return &Stats{
+		methods: make(map[string]*MethodStats),
+	}
+}
+
+// Middleware records every call that goes through it
+func (s *Stats) Middleware() Middleware {
+	return Hooks{
+		After: func(ctx context.Context, call Call, elapsed time.Duration, err error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			key := call.Interface + "." + call.Method
+			stats, ok := s.methods[key]
+
+			if !ok {
+				stats = &MethodStats{Interface: call.Interface, Method: call.Method}
+				s.methods[key] = stats
+			}
+
+			stats.Calls++
+			stats.Total += elapsed
+
+			if err != nil {
+				stats.Errors++
+			}
+		},
+	}.Middleware()
+}
+
+// Snapshot returns a copy of the stats so far, sorted by interface and
+// method
+func (s *Stats) Snapshot() []MethodStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make([]MethodStats, 0, len(s.methods))
+
+	for _, stats := range s.methods {
+		snapshot = append(snapshot, *stats)
+	}
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].Interface != snapshot[j].Interface {
+			return snapshot[i].Interface < snapshot[j].Interface
+		}
+
+		return snapshot[i].Method < snapshot[j].Method
+	})
+
+	return snapshot
+}
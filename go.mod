@@ -1,3 +1,13 @@
 module github.com/Evertras/go-interface-examples
 
-go 1.13
+go 1.22.0
+
+require (
+	golang.org/x/tools v0.29.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/mod v0.22.0 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+)
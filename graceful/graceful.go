@@ -0,0 +1,73 @@
+// Package graceful runs an http.Server until it's told to stop, then gives
+// requests already in flight a chance to finish before returning.
+//
+//	ctx, stop := graceful.SignalContext(context.Background())
+//	defer stop()
+//
+//	err := graceful.ListenAndServe(ctx, &http.Server{Addr: ":8080", Handler: mux}, graceful.DefaultTimeout)
+package graceful
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultTimeout is how long requests in flight get to finish, for servers
+// that don't have a reason to pick something else
+const DefaultTimeout = 10 * time.Second
+
+// SignalContext is done on Ctrl+C locally, or SIGTERM from whatever is
+// running us in production
+func SignalContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+}
+
+// ListenAndServe listens on server.Addr, then serves like Serve
+func ListenAndServe(ctx context.Context, server *http.Server, timeout time.Duration) error {
+	listener, err := net.Listen("tcp", server.Addr)
+
+	if err != nil {
+		return err
+	}
+
+	return Serve(ctx, server, listener, timeout)
+}
+
+// Serve runs server on listener until ctx is done, then stops taking new
+// connections and gives requests already in flight until timeout to finish
+//
+// Taking a listener rather than an address lets tests use whatever port is
+// free.  If requests are still going when time runs out, their connections
+// are closed and we return context.DeadlineExceeded.
+func Serve(ctx context.Context, server *http.Server, listener net.Listener, timeout time.Duration) error {
+	failed := make(chan error, 1)
+
+	go func() {
+		failed <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-failed:
+		return err
+
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := server.Shutdown(shutdownCtx)
+
+	if err != nil {
+		// Whatever didn't finish in time gets cut off rather than left
+		// hanging around after we've returned
+		server.Close()
+	}
+
+	return err
+}
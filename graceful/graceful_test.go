@@ -0,0 +1,195 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// client doesn't keep connections around, so nothing it did lingers to
+// slow down a shutdown
+var client = &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+// start serves handler on a free port until the returned cancel is called,
+// and reports what Serve returned on the channel
+func start(t *testing.T, handler http.Handler, timeout time.Duration) (string, context.CancelFunc, chan error) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal("net.Listen:", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+
+	go func() {
+		stopped <- Serve(ctx, &http.Server{Handler: handler}, listener, timeout)
+	}()
+
+	return "http://" + listener.Addr().String(), cancel, stopped
+}
+
+func waitForStop(t *testing.T, stopped chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-stopped:
+		return err
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server didn't stop")
+		return nil
+	}
+}
+
+func TestServeStopsCleanly(t *testing.T) {
+	url, cancel, stopped := start(t, http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte("ok"))
+	}), DefaultTimeout)
+
+	res, err := client.Get(url)
+
+	if err != nil {
+		t.Fatal("GET:", err)
+	}
+
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	cancel()
+
+	if err := waitForStop(t, stopped); err != nil {
+		t.Error("Expected a clean shutdown but got:", err)
+	}
+
+	if _, err := client.Get(url); err == nil {
+		t.Error("Expected the server to be gone after shutdown")
+	}
+}
+
+func TestServeDrainsRequestsInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	url, cancel, stopped := start(t, http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+		res.Write([]byte("finished"))
+	}), DefaultTimeout)
+
+	body := make(chan string, 1)
+
+	go func() {
+		res, err := client.Get(url)
+
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+
+		defer res.Body.Close()
+
+		contents, _ := io.ReadAll(res.Body)
+		body <- string(contents)
+	}()
+
+	<-started
+
+	// SIGTERM, as far as Serve can tell
+	cancel()
+
+	// New connections are turned away as soon as shutdown starts.  Keep
+	// trying briefly, since shutdown happens on another goroutine.
+	deadline := time.Now().Add(time.Second)
+
+	for {
+		conn, err := net.DialTimeout("tcp", strings.TrimPrefix(url, "http://"), 100*time.Millisecond)
+
+		if err != nil {
+			break
+		}
+
+		// Shutdown waits a while on connections that never send anything,
+		// so don't leave this one open
+		conn.Close()
+
+		if time.Now().After(deadline) {
+			t.Fatal("Expected new connections to be refused once shutdown started")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	close(release)
+
+	if got := <-body; got != "finished" {
+		t.Errorf("Expected the request in flight to finish but got %q", got)
+	}
+
+	if err := waitForStop(t, stopped); err != nil {
+		t.Error("Expected a clean shutdown but got:", err)
+	}
+}
+
+func TestServeClosesWhatDoesntFinishInTime(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	url, cancel, stopped := start(t, http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+	}), 50*time.Millisecond)
+
+	failed := make(chan error, 1)
+
+	go func() {
+		res, err := client.Get(url)
+
+		if err == nil {
+			res.Body.Close()
+		}
+
+		failed <- err
+	}()
+
+	<-started
+	cancel()
+
+	if err := waitForStop(t, stopped); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v but got %v", context.DeadlineExceeded, err)
+	}
+
+	select {
+	case err := <-failed:
+		if err == nil {
+			t.Error("Expected the stuck request to be cut off")
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the stuck request's connection to be closed")
+	}
+}
+
+func TestServeReturnsListenerErrors(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal("net.Listen:", err)
+	}
+
+	listener.Close()
+
+	err = Serve(context.Background(), &http.Server{}, listener, DefaultTimeout)
+
+	if err == nil {
+		t.Error("Expected an error from a closed listener")
+	}
+}
@@ -0,0 +1,192 @@
+// Package idempotency makes retried requests safe, by answering a repeat of
+// a request with the response the first one got instead of doing it again.
+//
+// Clients opt in by sending an Idempotency-Key header, any unique value
+// they like, with a POST, PUT or DELETE.  If the request times out they send
+// it again with the same key.  Whether or not the first one made it, the
+// request only happens once:
+//
+//   - The first request with a key runs as usual and its response is kept
+//     for the TTL.
+//   - A repeat with the same body gets that response back, with an
+//     Idempotent-Replayed header, without running again.
+//   - The same key with a different body is a client bug, and gets 422.
+//   - A repeat that arrives while the first is still running gets 409, since
+//     we don't know the answer yet.
+//
+// Keys belong to whoever sent them, so two users picking the same key
+// don't see each other's responses.  That's only as good as the principal:
+// if a client can claim to be anyone, it can read anyone's responses.
+//
+//	cache := idempotency.New(24*time.Hour, principal)
+//	handler := cache.Middleware(next)
+//
+// Responses are only kept in memory, so this only covers retries that land
+// on the same server.
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Header is where clients send their key
+const Header = "Idempotency-Key"
+
+// ReplayedHeader is set on responses that were replayed rather than served
+const ReplayedHeader = "Idempotent-Replayed"
+
+// maxKeyLength stops anyone using us to store arbitrary data
+const maxKeyLength = 255
+
+// maxBodyBytes is as much of a request body as we'll read to hash it
+const maxBodyBytes = 1 << 20
+
+// response is everything we need to send a response again
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// entry is what we know about one key
+type entry struct {
+	requestHash [sha256.Size]byte
+	expires     time.Time
+
+	// nil while the first request is still running
+	response *response
+}
+
+// cacheKey keeps one principal's keys apart from another's
+type cacheKey struct {
+	principal string
+	key       string
+}
+
+// Cache remembers responses by key until they expire
+type Cache struct {
+	ttl       time.Duration
+	principal func(req *http.Request) string
+
+	// now is swapped out by tests
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[cacheKey]*entry
+	lastSweep time.Time
+}
+
+// New returns a cache that keeps responses for ttl
+//
+// principal says who sent a request, so keys can be kept apart.  It can be
+// nil if everyone shares one set of keys.
+func New(ttl time.Duration, principal func(req *http.Request) string) *Cache {
+	return &Cache{
+		ttl:       ttl,
+		principal: principal,
+		now:       time.Now,
+		entries:   make(map[cacheKey]*entry),
+	}
+}
+
+// hashRequest covers everything that makes two requests the same request
+func hashRequest(req *http.Request, body []byte) [sha256.Size]byte {
+	hash := sha256.New()
+
+	io.WriteString(hash, req.Method)
+	hash.Write([]byte{0})
+	io.WriteString(hash, req.URL.RequestURI())
+	hash.Write([]byte{0})
+	hash.Write(body)
+
+	var sum [sha256.Size]byte
+	copy(sum[:], hash.Sum(nil))
+
+	return sum
+}
+
+// begin claims key for a request, or says why it can't
+//
+// If the key is new, it's claimed and ok is true.  Otherwise existing is
+// what's already there, for the caller to replay or refuse.
+func (c *Cache) begin(key cacheKey, requestHash [sha256.Size]byte) (existing *entry, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	c.sweep(now)
+
+	// A request that's still running keeps its key however long it takes,
+	// or a slow one could end up running twice
+	if existing, found := c.entries[key]; found && (existing.response == nil || now.Before(existing.expires)) {
+		// Copy it out while we hold the lock, since finish writes to it
+		copied := *existing
+
+		return &copied, false
+	}
+
+	c.entries[key] = &entry{
+		requestHash: requestHash,
+		expires:     now.Add(c.ttl),
+	}
+
+	return nil, true
+}
+
+// finish keeps the response for key, or forgets key entirely if response
+// is nil so the request can be tried again
+func (c *Cache) finish(key cacheKey, response *response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if response == nil {
+		delete(c.entries, key)
+		return
+	}
+
+	if e, ok := c.entries[key]; ok {
+		e.response = response
+		e.expires = c.now().Add(c.ttl)
+	}
+}
+
+// sweep forgets expired entries, at most once per TTL so a busy cache
+// isn't forever walking the map
+func (c *Cache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+
+	c.lastSweep = now
+
+	for key, e := range c.entries {
+		// Never forget a request that's still running
+		if e.response != nil && !now.Before(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// readBody reads the whole request body so it can be hashed, and puts it
+// back for the handler
+func readBody(res http.ResponseWriter, req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(res, req.Body, maxBodyBytes))
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
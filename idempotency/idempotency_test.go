@@ -0,0 +1,258 @@
+package idempotency
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingHandler awards points and says how many times it's done so
+type countingHandler struct {
+	calls   int
+	status  int
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *countingHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	h.calls++
+
+	if h.started != nil {
+		close(h.started)
+		<-h.release
+	}
+
+	status := h.status
+
+	if status == 0 {
+		status = http.StatusCreated
+	}
+
+	res.Header().Set("X-Award", strconv.Itoa(h.calls))
+	res.WriteHeader(status)
+	res.Write([]byte("awarded " + strconv.Itoa(h.calls)))
+}
+
+func newTestCache() (*Cache, *time.Time) {
+	now := time.Date(2020, 7, 18, 12, 0, 0, 0, time.UTC)
+
+	cache := New(time.Hour, func(req *http.Request) string {
+		return req.Header.Get("x-user-id")
+	})
+
+	cache.now = func() time.Time { return now }
+
+	return cache, &now
+}
+
+func send(handler http.Handler, method string, userID string, key string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/award", strings.NewReader(body))
+	req.Header.Set("x-user-id", userID)
+
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	return res
+}
+
+func TestDuplicatesAreReplayed(t *testing.T) {
+	cache, _ := newTestCache()
+	next := &countingHandler{}
+	handler := cache.Middleware(next)
+
+	first := send(handler, "POST", "evertras", "abc", `{"points": 10}`)
+	second := send(handler, "POST", "evertras", "abc", `{"points": 10}`)
+
+	if next.calls != 1 {
+		t.Fatalf("Expected the handler to run once but it ran %d times", next.calls)
+	}
+
+	if second.Code != first.Code || second.Body.String() != first.Body.String() || second.Header().Get("X-Award") != "1" {
+		t.Errorf("Expected the first response replayed but got %d %q with headers %v", second.Code, second.Body.String(), second.Header())
+	}
+
+	if first.Header().Get(ReplayedHeader) != "" || second.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("Expected only the replay to say so but got %q then %q",
+			first.Header().Get(ReplayedHeader), second.Header().Get(ReplayedHeader))
+	}
+}
+
+func TestReusedKeyWithDifferentRequestIsRefused(t *testing.T) {
+	cache, _ := newTestCache()
+	next := &countingHandler{}
+	handler := cache.Middleware(next)
+
+	send(handler, "POST", "evertras", "abc", `{"points": 10}`)
+
+	if res := send(handler, "POST", "evertras", "abc", `{"points": 1000}`); res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a different body but got %d", res.Code)
+	}
+
+	if res := send(handler, "DELETE", "evertras", "abc", `{"points": 10}`); res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a different method but got %d", res.Code)
+	}
+
+	if next.calls != 1 {
+		t.Errorf("Expected the handler to run once but it ran %d times", next.calls)
+	}
+}
+
+func TestKeysBelongToTheirPrincipal(t *testing.T) {
+	cache, _ := newTestCache()
+	next := &countingHandler{}
+	handler := cache.Middleware(next)
+
+	send(handler, "DELETE", "evertras", "abc", "")
+	res := send(handler, "DELETE", "ty", "abc", "")
+
+	if next.calls != 2 || res.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("Expected another user's key to be their own but handler ran %d times", next.calls)
+	}
+}
+
+func TestKeysExpire(t *testing.T) {
+	cache, now := newTestCache()
+	next := &countingHandler{}
+	handler := cache.Middleware(next)
+
+	send(handler, "PUT", "evertras", "abc", "TY")
+
+	*now = now.Add(2 * time.Hour)
+
+	send(handler, "PUT", "evertras", "abc", "TY")
+
+	if next.calls != 2 {
+		t.Errorf("Expected an expired key to run again but handler ran %d times", next.calls)
+	}
+
+	if len(cache.entries) != 1 {
+		t.Errorf("Expected the expired entry to be swept but have %d", len(cache.entries))
+	}
+}
+
+func TestServerErrorsCanBeRetried(t *testing.T) {
+	cache, _ := newTestCache()
+	next := &countingHandler{status: http.StatusInternalServerError}
+	handler := cache.Middleware(next)
+
+	send(handler, "POST", "evertras", "abc", "")
+
+	next.status = http.StatusCreated
+
+	if res := send(handler, "POST", "evertras", "abc", ""); res.Code != http.StatusCreated || next.calls != 2 {
+		t.Errorf("Expected the retry to run and succeed but got %d after %d calls", res.Code, next.calls)
+	}
+}
+
+func TestUnprotectedRequestsGoStraightThrough(t *testing.T) {
+	cache, _ := newTestCache()
+	next := &countingHandler{}
+	handler := cache.Middleware(next)
+
+	send(handler, "POST", "evertras", "", "")
+	send(handler, "POST", "evertras", "", "")
+	send(handler, "GET", "evertras", "abc", "")
+	send(handler, "GET", "evertras", "abc", "")
+
+	if next.calls != 4 {
+		t.Errorf("Expected every request to run but handler ran %d times", next.calls)
+	}
+
+	if res := send(handler, "POST", "evertras", strings.Repeat("k", maxKeyLength+1), ""); res.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an overly long key but got %d", res.Code)
+	}
+}
+
+func TestDuplicateWhileInProgressConflicts(t *testing.T) {
+	cache, _ := newTestCache()
+	next := &countingHandler{started: make(chan struct{}), release: make(chan struct{})}
+	handler := cache.Middleware(next)
+
+	done := make(chan *httptest.ResponseRecorder)
+
+	go func() {
+		done <- send(handler, "POST", "evertras", "abc", "")
+	}()
+
+	<-next.started
+
+	res := send(handler, "POST", "evertras", "abc", "")
+
+	if res.Code != http.StatusConflict || res.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 409 with Retry-After while the first is running but got %d", res.Code)
+	}
+
+	close(next.release)
+
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Errorf("Expected the first request to finish but got %d", first.Code)
+	}
+}
+
+func TestSlowRequestsKeepTheirKeyPastTheTTL(t *testing.T) {
+	cache, now := newTestCache()
+	next := &countingHandler{started: make(chan struct{}), release: make(chan struct{})}
+	handler := cache.Middleware(next)
+
+	done := make(chan *httptest.ResponseRecorder)
+
+	go func() {
+		done <- send(handler, "POST", "evertras", "abc", "")
+	}()
+
+	<-next.started
+
+	*now = now.Add(2 * time.Hour)
+
+	res := send(handler, "POST", "evertras", "abc", "")
+
+	if res.Code != http.StatusConflict {
+		t.Errorf("Expected 409 while the first is still running but got %d", res.Code)
+	}
+
+	close(next.release)
+	<-done
+
+	if next.calls != 1 {
+		t.Errorf("Expected the handler to run once but it ran %d times", next.calls)
+	}
+}
+
+// brokenBody fails partway through, like a client that hung up mid-upload
+type brokenBody struct{}
+
+func (brokenBody) Read(p []byte) (int, error) {
+	return 0, errors.New("connection reset by peer")
+}
+
+func TestUnreadableBodiesAreRefused(t *testing.T) {
+	cache, _ := newTestCache()
+	next := &countingHandler{}
+	handler := cache.Middleware(next)
+
+	if res := send(handler, "POST", "evertras", "abc", strings.Repeat("x", maxBodyBytes+1)); res.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for an overly large body but got %d", res.Code)
+	}
+
+	req := httptest.NewRequest("POST", "/award", brokenBody{})
+	req.Header.Set(Header, "def")
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a body that couldn't be read but got %d", res.Code)
+	}
+
+	if next.calls != 0 {
+		t.Errorf("Expected the handler not to run but it ran %d times", next.calls)
+	}
+}
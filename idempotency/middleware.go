@@ -0,0 +1,173 @@
+package idempotency
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// recorder passes a response through to the client while keeping a copy
+//
+// The handler gets its own header map, so we only keep headers the handler
+// set.  Whatever middleware further out set, like a request ID, belongs to
+// this request and not to the ones that replay it.
+type recorder struct {
+	http.ResponseWriter
+
+	header      http.Header
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+
+	r.wroteHeader = true
+	r.status = status
+
+	for name, values := range r.header {
+		r.ResponseWriter.Header()[name] = values
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(body []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	r.body.Write(body)
+
+	return r.ResponseWriter.Write(body)
+}
+
+func (r *recorder) response() *response {
+	return &response{
+		status: r.status,
+		header: r.header.Clone(),
+		body:   bytes.Clone(r.body.Bytes()),
+	}
+}
+
+// replay sends a kept response again
+func replay(res http.ResponseWriter, kept *response) {
+	for name, values := range kept.header {
+		res.Header()[name] = values
+	}
+
+	res.Header().Set(ReplayedHeader, "true")
+	res.WriteHeader(kept.status)
+	res.Write(kept.body)
+}
+
+// applies is true for methods that change things, which are the only ones
+// worth protecting.  Everything else is safe to repeat already.
+func applies(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// Middleware honours Idempotency-Key on POST, PUT and DELETE requests.
+// Requests without one go straight through.
+//
+// Server errors aren't kept, so a request that failed on our end can be
+// retried with the same key and actually run again.
+func (c *Cache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		idempotencyKey := req.Header.Get(Header)
+
+		if idempotencyKey == "" || !applies(req.Method) {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		if len(idempotencyKey) > maxKeyLength {
+			http.Error(res, Header+" can be at most "+strconv.Itoa(maxKeyLength)+" characters", http.StatusBadRequest)
+			return
+		}
+
+		body, err := readBody(res, req)
+
+		var tooLarge *http.MaxBytesError
+
+		if errors.As(err, &tooLarge) {
+			http.Error(res, "request body too large to make idempotent", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		// Anything else means the client went away or sent something broken
+		// partway through, and we can't tell what they meant to send
+		if err != nil {
+			http.Error(res, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+
+		key := cacheKey{key: idempotencyKey}
+
+		if c.principal != nil {
+			key.principal = c.principal(req)
+		}
+
+		requestHash := hashRequest(req, body)
+
+		existing, ok := c.begin(key, requestHash)
+
+		switch {
+		case ok:
+			// First time we've seen it, carry on
+
+		case existing.requestHash != requestHash:
+			http.Error(res, Header+" was already used for a different request", http.StatusUnprocessableEntity)
+			return
+
+		case existing.response == nil:
+			res.Header().Set("Retry-After", "1")
+			http.Error(res, "a request with this "+Header+" is still in progress", http.StatusConflict)
+			return
+
+		default:
+			replay(res, existing.response)
+			return
+		}
+
+		recorder := &recorder{ResponseWriter: res, header: make(http.Header)}
+
+		// Whatever happens, don't leave the key claimed forever
+		kept := false
+
+		defer func() {
+			if !kept {
+				c.finish(key, nil)
+			}
+		}()
+
+		next.ServeHTTP(recorder, req)
+
+		// A handler that never wrote anything still has headers to send
+		if !recorder.wroteHeader {
+			recorder.WriteHeader(http.StatusOK)
+		}
+
+		response := recorder.response()
+
+		if response.status >= 500 {
+			return
+		}
+
+		c.finish(key, response)
+		kept = true
+	})
+}
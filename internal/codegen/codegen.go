@@ -0,0 +1,71 @@
+// Package codegen has the little helpers the tools in cmd and analysis
+// share, for turning interfaces into Go files and telling the standard
+// library from everything else.
+package codegen
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// GroupImports puts the standard library first, then everything else, with
+// an empty entry between them for a template to leave a blank line
+func GroupImports(imports map[string]bool) []string {
+	var standard, other []string
+
+	for path := range imports {
+		if IsStandard(path) {
+			standard = append(standard, path)
+		} else {
+			other = append(other, path)
+		}
+	}
+
+	sort.Strings(standard)
+	sort.Strings(other)
+
+	if len(other) == 0 {
+		return standard
+	}
+
+	return append(append(standard, ""), other...)
+}
+
+// IsStandard is true for standard library import paths, which never have
+// a dot in their first element the way a domain does
+func IsStandard(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+// LowerFirst turns GetUserScore into getUserScore
+func LowerFirst(s string) string {
+	runes := []rune(s)
+	runes[0] = unicode.ToLower(runes[0])
+
+	return string(runes)
+}
+
+// UpperFirst turns userStoreDecorator into UserStoreDecorator
+func UpperFirst(s string) string {
+	runes := []rune(s)
+	runes[0] = unicode.ToUpper(runes[0])
+
+	return string(runes)
+}
+
+// SnakeCase turns UserDataStore into user_data_store, for file names
+func SnakeCase(name string) string {
+	var out strings.Builder
+	runes := []rune(name)
+
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			out.WriteRune('_')
+		}
+
+		out.WriteRune(unicode.ToLower(r))
+	}
+
+	return out.String()
+}
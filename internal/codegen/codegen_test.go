@@ -0,0 +1,63 @@
+package codegen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupImports(t *testing.T) {
+	got := GroupImports(map[string]bool{
+		"sync": true,
+		"github.com/Evertras/go-interface-examples/decorate": true,
+		"context":                        true,
+		"golang.org/x/tools/go/packages": true,
+	})
+
+	expected := []string{
+		"context",
+		"sync",
+		"",
+		"github.com/Evertras/go-interface-examples/decorate",
+		"golang.org/x/tools/go/packages",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q but got %q", expected, got)
+	}
+
+	if got := GroupImports(map[string]bool{"context": true}); !reflect.DeepEqual(got, []string{"context"}) {
+		t.Errorf("Expected no blank line without other imports but got %q", got)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"UserDataStore":         "user_data_store",
+		"TopUserGetter":         "top_user_getter",
+		"HTTPClient":            "http_client",
+		"Notifier":              "notifier",
+		"GSLDataStoreUser":      "gsl_data_store_user",
+		"CurrentChampionGetter": "current_champion_getter",
+	}
+
+	for input, expected := range tests {
+		if actual := SnakeCase(input); actual != expected {
+			t.Errorf("SnakeCase(%q): expected %q but got %q", input, expected, actual)
+		}
+	}
+}
+
+func TestIsStandard(t *testing.T) {
+	tests := map[string]bool{
+		"context":                        true,
+		"net/http":                       true,
+		"golang.org/x/tools/go/packages": false,
+		"github.com/Evertras/go-interface-examples/decorate": false,
+	}
+
+	for path, expected := range tests {
+		if actual := IsStandard(path); actual != expected {
+			t.Errorf("IsStandard(%q): expected %v but got %v", path, expected, actual)
+		}
+	}
+}
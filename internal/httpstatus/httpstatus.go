@@ -0,0 +1,49 @@
+// Package httpstatus finds out which status a handler sent, for the
+// middleware that counts, traces and logs requests.
+package httpstatus
+
+import "net/http"
+
+// Recorder remembers the status a handler wrote and passes everything
+// through untouched
+type Recorder struct {
+	http.ResponseWriter
+
+	status int
+}
+
+// NewRecorder wraps res
+func NewRecorder(res http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: res}
+}
+
+func (r *Recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *Recorder) Write(body []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	return r.ResponseWriter.Write(body)
+}
+
+// Unwrap lets http.ResponseController reach the real ResponseWriter
+func (r *Recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Status is what the handler sent.  A handler that wrote nothing at all
+// still goes out as a 200.
+func (r *Recorder) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+
+	return r.status
+}
@@ -0,0 +1,54 @@
+package httpstatus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecorderKeepsTheFirstStatus(t *testing.T) {
+	cases := map[string]struct {
+		handler  http.HandlerFunc
+		expected int
+	}{
+		"nothing written": {
+			handler:  func(res http.ResponseWriter, req *http.Request) {},
+			expected: http.StatusOK,
+		},
+		"body only": {
+			handler: func(res http.ResponseWriter, req *http.Request) {
+				res.Write([]byte("TY"))
+			},
+			expected: http.StatusOK,
+		},
+		"status then body": {
+			handler: func(res http.ResponseWriter, req *http.Request) {
+				res.WriteHeader(http.StatusNotFound)
+				res.Write([]byte("who?"))
+			},
+			expected: http.StatusNotFound,
+		},
+		"status twice": {
+			handler: func(res http.ResponseWriter, req *http.Request) {
+				res.WriteHeader(http.StatusServiceUnavailable)
+				res.WriteHeader(http.StatusOK)
+			},
+			expected: http.StatusServiceUnavailable,
+		},
+	}
+
+	for name, c := range cases {
+		res := httptest.NewRecorder()
+		recorder := NewRecorder(res)
+
+		c.handler(recorder, httptest.NewRequest("GET", "/", nil))
+
+		if recorder.Status() != c.expected {
+			t.Errorf("%s: expected status %d but got %d", name, c.expected, recorder.Status())
+		}
+
+		if res.Code != c.expected {
+			t.Errorf("%s: expected %d to reach the client but got %d", name, c.expected, res.Code)
+		}
+	}
+}
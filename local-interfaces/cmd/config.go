@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/graceful"
+	"github.com/Evertras/go-interface-examples/ratelimit"
+)
+
+// Store kinds the leaderboard knows how to build
+const (
+	storeMemory = "memory"
+	storeChaos  = "chaos"
+)
+
+// leaderboardConfig is everything about the leaderboard server that changes
+// between machines, loaded by the config package from a file, LEADERBOARD_*
+// environment variables and flags
+//
+// Like the GSL server's config, only main ever sees this.
+type leaderboardConfig struct {
+	Address           string        `yaml:"address" help:"Where the leaderboard server listens"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" help:"How long a client gets to send request headers"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" help:"How long a request gets from headers read to response written"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" help:"How long to keep an idle connection open"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" help:"How long requests in flight get to finish on SIGTERM"`
+	IdempotencyTTL    time.Duration `yaml:"idempotency_ttl" help:"How long responses are kept to replay for retries with the same Idempotency-Key"`
+
+	Store struct {
+		Kind         string        `yaml:"kind" help:"Data store to use, memory or chaos"`
+		Retries      int           `yaml:"retries" help:"Attempts per store call, including the first"`
+		RetryBackoff time.Duration `yaml:"retry_backoff" help:"Wait after the first failed store call, doubling after each"`
+
+		// Only used by the chaos store, which is the memory store with
+		// faults injected
+		Chaos struct {
+			Seed      int64         `yaml:"seed" help:"Makes chaos repeatable"`
+			ErrorRate float64       `yaml:"error_rate" help:"Chance from 0 to 1 that a store call fails"`
+			Latency   time.Duration `yaml:"latency" help:"Added to every store call"`
+		} `yaml:"chaos"`
+	} `yaml:"store"`
+
+	Notifications struct {
+		Enabled bool   `yaml:"enabled" help:"Send top score notifications at all"`
+		Channel string `yaml:"channel" help:"Which channel notifications go out on, as labelled in metrics"`
+	} `yaml:"notifications"`
+
+	// How fast each client can call each route, by IP
+	RateLimits struct {
+		Score  ratelimit.Limit `yaml:"score"`
+		User   ratelimit.Limit `yaml:"user"`
+		Award  ratelimit.Limit `yaml:"award"`
+		Notify ratelimit.Limit `yaml:"notify"`
+	} `yaml:"rate_limits"`
+
+	Tracing struct {
+		File      string `yaml:"file" help:"File to write OTLP JSON spans to"`
+		Collector string `yaml:"collector" help:"OTLP/HTTP URL to send spans to, like http://localhost:4318/v1/traces"`
+	} `yaml:"tracing"`
+}
+
+// defaultLeaderboardConfig is what the server ran with back when all of
+// this was hard-coded in main
+func defaultLeaderboardConfig() *leaderboardConfig {
+	cfg := &leaderboardConfig{
+		Address:           ":8080",
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       time.Minute,
+		ShutdownTimeout:   graceful.DefaultTimeout,
+		IdempotencyTTL:    24 * time.Hour,
+	}
+
+	cfg.Store.Kind = storeMemory
+	cfg.Store.Retries = 3
+	cfg.Store.RetryBackoff = 50 * time.Millisecond
+
+	cfg.RateLimits.Score = ratelimit.Limit{Requests: 20, Per: time.Second}
+	cfg.RateLimits.User = ratelimit.Limit{Requests: 5, Per: time.Minute}
+	cfg.RateLimits.Award = ratelimit.Limit{Requests: 10, Per: time.Second}
+	cfg.RateLimits.Notify = ratelimit.Limit{Requests: 1, Per: 10 * time.Second}
+
+	cfg.Notifications.Enabled = true
+	cfg.Notifications.Channel = "push"
+
+	return cfg
+}
+
+// Validate catches mistakes before we start, rather than on the first
+// request that trips over them
+func (c *leaderboardConfig) Validate() error {
+	var problems []error
+
+	require := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Errorf(format, args...))
+		}
+	}
+
+	require(c.Address != "", "address is required")
+	require(c.ReadHeaderTimeout > 0, "read_header_timeout must be positive")
+	require(c.WriteTimeout > 0, "write_timeout must be positive")
+	require(c.IdleTimeout >= 0, "idle_timeout can't be negative")
+	require(c.ShutdownTimeout > 0, "shutdown_timeout must be positive")
+	require(c.IdempotencyTTL > 0, "idempotency_ttl must be positive")
+	require(c.Store.Kind == storeMemory || c.Store.Kind == storeChaos,
+		"store.kind %q must be %s or %s", c.Store.Kind, storeMemory, storeChaos)
+	require(c.Store.Retries >= 1, "store.retries must be at least 1")
+	require(c.Store.RetryBackoff >= 0, "store.retry_backoff can't be negative")
+	require(c.Store.Chaos.ErrorRate >= 0 && c.Store.Chaos.ErrorRate <= 1, "store.chaos.error_rate must be from 0 to 1")
+	require(c.Store.Chaos.Latency >= 0, "store.chaos.latency can't be negative")
+	require(!c.Notifications.Enabled || c.Notifications.Channel != "", "notifications.channel is required when notifications are enabled")
+	require(c.Tracing.File == "" || c.Tracing.Collector == "", "tracing.file and tracing.collector can't both be set")
+
+	for name, limit := range c.routeRateLimits() {
+		err := limit.Validate()
+
+		require(err == nil, "rate_limits for %s: %v", name, err)
+	}
+
+	if c.Tracing.Collector != "" {
+		collector, err := url.Parse(c.Tracing.Collector)
+
+		require(err == nil && (collector.Scheme == "http" || collector.Scheme == "https") && collector.Host != "",
+			"tracing.collector %q must be an http or https URL", c.Tracing.Collector)
+	}
+
+	return errors.Join(problems...)
+}
+
+// routeRateLimits is the rate limits keyed by the route they apply to
+func (c *leaderboardConfig) routeRateLimits() map[string]ratelimit.Limit {
+	return map[string]ratelimit.Limit{
+		"/score":              c.RateLimits.Score,
+		"/user":               c.RateLimits.User,
+		"/award":              c.RateLimits.Award,
+		"/leaderboard/notify": c.RateLimits.Notify,
+	}
+}
+
+// mutedNotifier stands in for the notifier when notifications are off, so
+// the leaderboard doesn't need to know
+type mutedNotifier struct{}
+
+func (mutedNotifier) NotifyTopScore(ctx context.Context, id string, score int) error {
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Evertras/go-interface-examples/chaos"
+	"github.com/Evertras/go-interface-examples/config"
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+)
+
+func loadLeaderboardConfig(env map[string]string, args ...string) (*leaderboardConfig, error) {
+	cfg := defaultLeaderboardConfig()
+
+	err := config.Loader{
+		Name:      "leaderboard",
+		EnvPrefix: "LEADERBOARD",
+		LookupEnv: func(key string) (string, bool) {
+			value, ok := env[key]
+			return value, ok
+		},
+	}.Load(cfg, args)
+
+	return cfg, err
+}
+
+func TestDefaultLeaderboardConfigIsValid(t *testing.T) {
+	err := defaultLeaderboardConfig().Validate()
+
+	if err != nil {
+		t.Error("Expected the defaults to be valid but got:", err)
+	}
+}
+
+func TestLeaderboardConfigPicksTheStore(t *testing.T) {
+	cfg, err := loadLeaderboardConfig(map[string]string{"LEADERBOARD_STORE_KIND": "chaos"}, "-store.chaos.error-rate", "0.25")
+
+	if err != nil {
+		t.Fatal("Load:", err)
+	}
+
+	if _, ok := newDataStore(cfg).(*chaos.Db); !ok {
+		t.Errorf("Expected a chaos store but got %T", newDataStore(cfg))
+	}
+
+	if _, ok := newDataStore(defaultLeaderboardConfig()).(*db.Db); !ok {
+		t.Errorf("Expected the memory store by default but got %T", newDataStore(defaultLeaderboardConfig()))
+	}
+}
+
+func TestLeaderboardConfigValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		contains string
+	}{
+		{"UnknownStore", nil, []string{"-store.kind", "postgres"}, "store.kind"},
+		{"NoRetries", map[string]string{"LEADERBOARD_STORE_RETRIES": "0"}, nil, "store.retries"},
+		{"ErrorRateOverOne", nil, []string{"-store.chaos.error-rate", "1.5"}, "store.chaos.error_rate"},
+		{"NoChannel", nil, []string{"-notifications.channel", ""}, "notifications.channel"},
+		{"TwoTraceDestinations", nil, []string{"-tracing.file", "spans.jsonl", "-tracing.collector", "http://localhost:4318/v1/traces"}, "can't both be set"},
+		{"CollectorNotAURL", nil, []string{"-tracing.collector", "localhost:4318"}, "tracing.collector"},
+		{"RateLimitWithoutPer", nil, []string{"-rate-limits.score.per", "0s"}, "rate_limits for /score"},
+		{"NoWriteTimeout", nil, []string{"-write-timeout", "0s"}, "write_timeout"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadLeaderboardConfig(test.env, test.args...)
+
+			if err == nil || !strings.Contains(err.Error(), test.contains) {
+				t.Errorf("Expected an error about %s but got %v", test.contains, err)
+			}
+		})
+	}
+}
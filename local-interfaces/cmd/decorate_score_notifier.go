@@ -0,0 +1,41 @@
+// Code generated by localdecorate; DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+
+	"github.com/Evertras/go-interface-examples/decorate"
+)
+
+// scoreNotifierDecorator runs every scoreNotifier call through middleware, and
+// satisfies scoreNotifier itself so nobody using it can tell
+type scoreNotifierDecorator struct {
+	next       scoreNotifier
+	middleware decorate.Middleware
+}
+
+// newScoreNotifierDecorator wraps next so every call goes through the middleware,
+// the first one outermost
+func newScoreNotifierDecorator(next scoreNotifier, middleware ...decorate.Middleware) *scoreNotifierDecorator {
+	return &scoreNotifierDecorator{
+		next:       next,
+		middleware: decorate.Chain(middleware...),
+	}
+}
+
+// NotifyTopScore calls through to the wrapped scoreNotifier
+func (d *scoreNotifierDecorator) NotifyTopScore(ctx context.Context, id string, score int) error {
+	call := decorate.Call{
+		Interface: "scoreNotifier",
+		Method:    "NotifyTopScore",
+		Args:      []interface{}{id, score},
+		Results:   []interface{}{},
+	}
+
+	err := d.middleware(ctx, call, func(ctx context.Context) error {
+		return d.next.NotifyTopScore(ctx, id, score)
+	})
+
+	return err
+}
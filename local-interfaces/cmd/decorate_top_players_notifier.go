@@ -0,0 +1,41 @@
+// Code generated by localdecorate; DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+
+	"github.com/Evertras/go-interface-examples/decorate"
+)
+
+// topPlayersNotifierDecorator runs every topPlayersNotifier call through middleware, and
+// satisfies topPlayersNotifier itself so nobody using it can tell
+type topPlayersNotifierDecorator struct {
+	next       topPlayersNotifier
+	middleware decorate.Middleware
+}
+
+// newTopPlayersNotifierDecorator wraps next so every call goes through the middleware,
+// the first one outermost
+func newTopPlayersNotifierDecorator(next topPlayersNotifier, middleware ...decorate.Middleware) *topPlayersNotifierDecorator {
+	return &topPlayersNotifierDecorator{
+		next:       next,
+		middleware: decorate.Chain(middleware...),
+	}
+}
+
+// NotifyTopPlayers calls through to the wrapped topPlayersNotifier
+func (d *topPlayersNotifierDecorator) NotifyTopPlayers(ctx context.Context, top int) error {
+	call := decorate.Call{
+		Interface: "topPlayersNotifier",
+		Method:    "NotifyTopPlayers",
+		Args:      []interface{}{top},
+		Results:   []interface{}{},
+	}
+
+	err := d.middleware(ctx, call, func(ctx context.Context) error {
+		return d.next.NotifyTopPlayers(ctx, top)
+	})
+
+	return err
+}
@@ -0,0 +1,118 @@
+// Code generated by localdecorate; DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+
+	"github.com/Evertras/go-interface-examples/decorate"
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+)
+
+// userStoreDecorator runs every userStore call through middleware, and
+// satisfies userStore itself so nobody using it can tell
+type userStoreDecorator struct {
+	next       userStore
+	middleware decorate.Middleware
+}
+
+// newUserStoreDecorator wraps next so every call goes through the middleware,
+// the first one outermost
+func newUserStoreDecorator(next userStore, middleware ...decorate.Middleware) *userStoreDecorator {
+	return &userStoreDecorator{
+		next:       next,
+		middleware: decorate.Chain(middleware...),
+	}
+}
+
+// AwardPoints calls through to the wrapped userStore
+func (d *userStoreDecorator) AwardPoints(ctx context.Context, ids []string, score int) error {
+	call := decorate.Call{
+		Interface: "userStore",
+		Method:    "AwardPoints",
+		Args:      []interface{}{ids, score},
+		Results:   []interface{}{},
+	}
+
+	err := d.middleware(ctx, call, func(ctx context.Context) error {
+		return d.next.AwardPoints(ctx, ids, score)
+	})
+
+	return err
+}
+
+// CreateUser calls through to the wrapped userStore
+func (d *userStoreDecorator) CreateUser(ctx context.Context, id string) error {
+	call := decorate.Call{
+		Interface: "userStore",
+		Method:    "CreateUser",
+		Args:      []interface{}{id},
+		Results:   []interface{}{},
+	}
+
+	err := d.middleware(ctx, call, func(ctx context.Context) error {
+		return d.next.CreateUser(ctx, id)
+	})
+
+	return err
+}
+
+// DeleteUser calls through to the wrapped userStore
+func (d *userStoreDecorator) DeleteUser(ctx context.Context, id string) error {
+	call := decorate.Call{
+		Interface: "userStore",
+		Method:    "DeleteUser",
+		Args:      []interface{}{id},
+		Results:   []interface{}{},
+	}
+
+	err := d.middleware(ctx, call, func(ctx context.Context) error {
+		return d.next.DeleteUser(ctx, id)
+	})
+
+	return err
+}
+
+// GetTopUsers calls through to the wrapped userStore
+func (d *userStoreDecorator) GetTopUsers(ctx context.Context, count int) ([]*db.User, error) {
+	var result0 []*db.User
+
+	call := decorate.Call{
+		Interface: "userStore",
+		Method:    "GetTopUsers",
+		Args:      []interface{}{count},
+		Results:   []interface{}{&result0},
+	}
+
+	err := d.middleware(ctx, call, func(ctx context.Context) error {
+		var err error
+
+		result0, err = d.next.GetTopUsers(ctx, count)
+
+		return err
+	})
+
+	return result0, err
+}
+
+// GetUserScore calls through to the wrapped userStore
+func (d *userStoreDecorator) GetUserScore(ctx context.Context, id string) (int, error) {
+	var result0 int
+
+	call := decorate.Call{
+		Interface: "userStore",
+		Method:    "GetUserScore",
+		Args:      []interface{}{id},
+		Results:   []interface{}{&result0},
+	}
+
+	err := d.middleware(ctx, call, func(ctx context.Context) error {
+		var err error
+
+		result0, err = d.next.GetUserScore(ctx, id)
+
+		return err
+	})
+
+	return result0, err
+}
@@ -2,27 +2,212 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
 
+	"github.com/Evertras/go-interface-examples/chaos"
+	"github.com/Evertras/go-interface-examples/config"
+	"github.com/Evertras/go-interface-examples/decorate"
+	"github.com/Evertras/go-interface-examples/graceful"
+	"github.com/Evertras/go-interface-examples/idempotency"
 	"github.com/Evertras/go-interface-examples/local-interfaces/db"
-	"github.com/Evertras/go-interface-examples/local-interfaces/handlers"
 	"github.com/Evertras/go-interface-examples/local-interfaces/leaderboard"
 	"github.com/Evertras/go-interface-examples/local-interfaces/notifications"
+	"github.com/Evertras/go-interface-examples/metrics"
+	"github.com/Evertras/go-interface-examples/requestid"
+	"github.com/Evertras/go-interface-examples/tracing"
 )
 
+//go:generate go run ../../cmd/localdecorate -type userStore
+//go:generate go run ../../cmd/localdecorate -type scoreNotifier
+//go:generate go run ../../cmd/localdecorate -type topPlayersNotifier
+
+// userStore is everything main hands the database to: the handlers and
+// the leaderboard.  Main is a consumer too, so it gets a local interface
+// like everyone else, and that's what the decorator wraps.
+type userStore interface {
+	GetUserScore(ctx context.Context, id string) (int, error)
+	CreateUser(ctx context.Context, id string) error
+	DeleteUser(ctx context.Context, id string) error
+	GetTopUsers(ctx context.Context, count int) ([]*db.User, error)
+	AwardPoints(ctx context.Context, ids []string, score int) error
+}
+
+// scoreNotifier is the part of the notifier the leaderboard uses
+type scoreNotifier interface {
+	NotifyTopScore(ctx context.Context, id string, score int) error
+}
+
+// topPlayersNotifier is the part of the leaderboard the handlers use
+type topPlayersNotifier interface {
+	NotifyTopPlayers(ctx context.Context, top int) error
+}
+
+// newTraceExporter picks where spans go.  With nowhere to send them we
+// still trace, so traceparent headers keep working for whoever calls us.
+func newTraceExporter(file string, collector string) (tracing.Exporter, error) {
+	switch {
+	case collector != "":
+		return tracing.NewHTTPExporter(collector, &http.Client{Timeout: 5 * time.Second}), nil
+
+	case file != "":
+		out, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return tracing.NewWriterExporter(out), nil
+
+	default:
+		return tracing.NewWriterExporter(io.Discard), nil
+	}
+}
+
+// newDataStore builds the store the config asks for
+func newDataStore(cfg *leaderboardConfig) chaos.UserStore {
+	store := db.New()
+
+	if cfg.Store.Kind != storeChaos {
+		return store
+	}
+
+	return chaos.NewDb(store, chaos.New(chaos.Config{
+		Seed:      cfg.Store.Chaos.Seed,
+		Latency:   cfg.Store.Chaos.Latency,
+		ErrorRate: cfg.Store.Chaos.ErrorRate,
+	}))
+}
+
 func main() {
-	database := db.New()
-	notifier := notifications.New()
+	// JSON logs, and anything logged while serving a request says which
+	// request it was
+	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(os.Stderr, nil)))
+
+	cfg := defaultLeaderboardConfig()
+
+	err := config.Loader{Name: "leaderboard", EnvPrefix: "LEADERBOARD"}.Load(cfg, os.Args[1:])
+
+	if errors.Is(err, config.ErrPrinted) || errors.Is(err, flag.ErrHelp) {
+		return
+	}
+
+	if err != nil {
+		logger.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	traceExporter, err := newTraceExporter(cfg.Tracing.File, cfg.Tracing.Collector)
+
+	if err != nil {
+		logger.Error("Failed to set up trace exporter", "error", err)
+		os.Exit(1)
+	}
+
+	tracer := tracing.NewTracer("leaderboard", traceExporter, logger)
+
+	// Everything we measure ends up here, and gets served on /metrics
+	registry := metrics.NewRegistry()
+	notificationMetrics := metrics.NewNotificationMetrics(registry)
+
+	// Users that don't exist won't start existing if we ask again, and
+	// ones that do exist won't stop
+	retryable := func(err error) bool {
+		return !errors.Is(err, db.ErrUserNotFound) &&
+			!errors.Is(err, db.ErrUserExists) &&
+			!errors.Is(err, db.ErrInvalidID)
+	}
+
+	// Awarding points twice isn't safe, since a call that timed out might
+	// still have gone through.  Clients retry those with an Idempotency-Key
+	// instead.
+	retry := decorate.Retry(cfg.Store.Retries, cfg.Store.RetryBackoff, retryable)
+	retrySafeCalls := func(ctx context.Context, call decorate.Call, next decorate.Invoke) error {
+		if call.Method == "AwardPoints" {
+			return next(ctx)
+		}
+
+		return retry(ctx, call, next)
+	}
+
+	// tracing(logging(metrics(retry(db.New())))), and it's still just a
+	// userStore.  Retries happen inside the span, so one slow call with
+	// three attempts shows up as one slow span.
+	database := newUserStoreDecorator(newDataStore(cfg),
+		tracer.Middleware(tracing.KindClient),
+		decorate.StructuredLogging(logger),
+		metrics.NewCallMetrics(registry, "store").Middleware(),
+		retrySafeCalls,
+	)
+
+	var sender scoreNotifier = notifications.New(logger)
+
+	if !cfg.Notifications.Enabled {
+		sender = mutedNotifier{}
+	}
+
+	// Notifications aren't safe to send twice, so no retries here
+	notifier := newScoreNotifierDecorator(sender,
+		tracer.Middleware(tracing.KindClient),
+		decorate.StructuredLogging(logger),
+		decorate.Hooks{
+			After: func(ctx context.Context, call decorate.Call, elapsed time.Duration, err error) {
+				notificationMetrics.ObserveNotification(cfg.Notifications.Channel, err)
+			},
+		}.Middleware(),
+	)
 
 	// Our database and notifier match the local interfaces in leaderboard,
-	// so we can use them fine
-	leaderboard := leaderboard.New(database, notifier)
+	// so we can use them fine.  The decorators do too, since they match
+	// exactly the same methods.  The leaderboard gets a span of its own,
+	// so the store and notifier spans nest under it.
+	leaderboard := newTopPlayersNotifierDecorator(leaderboard.New(database, notifier),
+		tracer.Middleware(tracing.KindInternal),
+	)
+
+	// Similarly, our handlers expect a certain interface which is also
+	// fulfilled by our database, so the server can have it too
+	deps := serverDependencies{
+		userDataStore:      database,
+		userCreator:        database,
+		pointsAwarder:      database,
+		topPlayersNotifier: leaderboard,
+		logger:             logger,
+		tracer:             tracer,
+		requestObserver:    metrics.NewHTTPMetrics(registry),
+		metricsHandler:     registry,
+		rateLimits:         cfg.routeRateLimits(),
+		deduplicator:       idempotency.New(cfg.IdempotencyTTL, requestScope),
+	}
+
+	server := &http.Server{
+		Addr:              cfg.Address,
+		Handler:           newServerHandler(deps),
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	// Drain what's in flight before exiting, then send whatever spans
+	// those requests left behind
+	ctx, stop := graceful.SignalContext(context.Background())
+	defer stop()
+
+	logger.Info("Leaderboard server listening", "address", cfg.Address, "store", cfg.Store.Kind)
 
-	leaderboard.NotifyTopPlayers(context.Background(), 3)
+	err = graceful.ListenAndServe(ctx, server, cfg.ShutdownTimeout)
 
-	// Similarly, our handlers expect a certain interface which is also fulfilled
-	// by our database, so we could create an HTTP server here
-	handlers.DeleteUserHandler(database)
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	tracer.Flush(flushCtx)
+	cancelFlush()
 
-	// Don't actually do anything useful, we're just showing how the interfaces
-	// properly match!
+	if err != nil {
+		logger.Error("Leaderboard server stopped", "error", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/internal/httpstatus"
+	"github.com/Evertras/go-interface-examples/local-interfaces/handlers"
+	"github.com/Evertras/go-interface-examples/ratelimit"
+	"github.com/Evertras/go-interface-examples/requestid"
+)
+
+// requestObserver finds out about every request once it's been served
+//
+// Main hands a metrics registry in here, but the server only needs to know
+// that someone wants to hear about requests.
+type requestObserver interface {
+	ObserveRequest(route string, method string, status int, elapsed time.Duration)
+}
+
+// routeTracer starts a span for every request to a route
+type routeTracer interface {
+	HTTPMiddleware(route string, next http.Handler) http.Handler
+}
+
+// requestDeduplicator makes sure a retried request only happens once
+type requestDeduplicator interface {
+	Middleware(next http.Handler) http.Handler
+}
+
+// serverDependencies is everything the leaderboard server needs
+type serverDependencies struct {
+	userDataStore      handlers.UserDataStore
+	userCreator        handlers.UserCreator
+	pointsAwarder      handlers.PointsAwarder
+	topPlayersNotifier handlers.TopPlayersNotifier
+	logger             *slog.Logger
+
+	// Optional: who hears about requests, what serves /metrics, and who
+	// traces them
+	requestObserver requestObserver
+	metricsHandler  http.Handler
+	tracer          routeTracer
+
+	// Optional: how fast each client can call each route, keyed by route
+	rateLimits map[string]ratelimit.Limit
+
+	// Optional: who replays responses to requests with an Idempotency-Key
+	deduplicator requestDeduplicator
+}
+
+// userPrincipal is who a request says it's from.  Nothing checks it, so
+// anyone can claim to be anyone, and it must never decide whose limits or
+// responses a request gets on its own.
+func userPrincipal(req *http.Request) string {
+	return req.Header.Get("x-user-id")
+}
+
+// requestScope is whose Idempotency-Key a request is using.  The address
+// comes first, so making up an x-user-id only ever reaches keys sent from
+// the same place.
+func requestScope(req *http.Request) string {
+	return ratelimit.ClientKey(nil)(req) + " user:" + userPrincipal(req)
+}
+
+// newServerHandler wires the handlers up to routes
+//
+// The handlers already take local interfaces, so all we do here is decide
+// which URL gets which handler.  Every request gets an X-Request-ID on the
+// way in, which rides along in the context to the store and the logs.
+func newServerHandler(deps serverDependencies) http.Handler {
+	mux := http.NewServeMux()
+
+	// Everyone is limited by address.  Going by x-user-id would let a
+	// client get a fresh bucket by making up a new one.
+	clientKey := ratelimit.ClientKey(nil)
+
+	handle := func(method string, route string, handler http.Handler) {
+		// Replays still count against the rate limit, so retrying in a
+		// tight loop doesn't get around it
+		if deps.deduplicator != nil {
+			handler = deps.deduplicator.Middleware(handler)
+		}
+
+		if limit, ok := deps.rateLimits[route]; ok {
+			handler = ratelimit.NewLimiter(limit).Middleware(clientKey, handler)
+		}
+
+		handler = instrumentRoute(route, deps.requestObserver, handler)
+
+		if deps.tracer != nil {
+			handler = deps.tracer.HTTPMiddleware(route, handler)
+		}
+
+		mux.Handle(method+" "+route, handler)
+	}
+
+	handle(http.MethodGet, "/score", handlers.GetUserScoreHandler(deps.userDataStore, deps.logger))
+	handle(http.MethodPost, "/user", handlers.CreateUserHandler(deps.userCreator, deps.logger))
+	handle(http.MethodDelete, "/user", handlers.DeleteUserHandler(deps.userDataStore, deps.logger))
+	handle(http.MethodPost, "/award", handlers.AwardPointsHandler(deps.pointsAwarder, deps.logger))
+	handle(http.MethodPost, "/leaderboard/notify", handlers.NotifyTopPlayersHandler(deps.topPlayersNotifier, deps.logger))
+
+	if deps.metricsHandler != nil {
+		mux.Handle("GET /metrics", deps.metricsHandler)
+	}
+
+	return requestid.Middleware(mux)
+}
+
+// instrumentRoute tells the observer about every request to the route
+func instrumentRoute(route string, observer requestObserver, next http.Handler) http.Handler {
+	if observer == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		recorder := httpstatus.NewRecorder(res)
+		start := time.Now()
+
+		next.ServeHTTP(recorder, req)
+
+		observer.ObserveRequest(route, req.Method, recorder.Status(), time.Since(start))
+	})
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/idempotency"
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+	"github.com/Evertras/go-interface-examples/local-interfaces/leaderboard"
+	"github.com/Evertras/go-interface-examples/local-interfaces/notifications"
+	"github.com/Evertras/go-interface-examples/metrics"
+	"github.com/Evertras/go-interface-examples/ratelimit"
+	"github.com/Evertras/go-interface-examples/requestid"
+	"github.com/Evertras/go-interface-examples/tracing"
+)
+
+type mockUserDataStore struct {
+	pendingScore int
+	pendingError error
+
+	lastRequestID string
+}
+
+func (s *mockUserDataStore) GetUserScore(ctx context.Context, id string) (int, error) {
+	s.lastRequestID = requestid.FromContext(ctx)
+
+	return s.pendingScore, s.pendingError
+}
+
+func (s *mockUserDataStore) DeleteUser(ctx context.Context, id string) error {
+	return s.pendingError
+}
+
+func TestMetricsCountRequestsByRouteAndStatus(t *testing.T) {
+	registry := metrics.NewRegistry()
+	store := &mockUserDataStore{pendingScore: 9000}
+
+	server := newServerHandler(serverDependencies{
+		userDataStore:   store,
+		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		requestObserver: metrics.NewHTTPMetrics(registry),
+		metricsHandler:  registry,
+	})
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/score", nil))
+
+	store.pendingError = db.ErrUserNotFound
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/user", strings.NewReader("nobody")))
+
+	res := httptest.NewRecorder()
+	server.ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+
+	if res.Code != 200 {
+		t.Fatalf("Expected status %d but got %d", 200, res.Code)
+	}
+
+	for _, expected := range []string{
+		`http_requests_total{route="/score",method="GET",status="200"} 1`,
+		`http_requests_total{route="/user",method="DELETE",status="404"} 1`,
+	} {
+		if !strings.Contains(res.Body.String(), expected) {
+			t.Errorf("Expected %q in metrics but got:\n%s", expected, res.Body.String())
+		}
+	}
+}
+
+func TestRequestIDReachesTheStore(t *testing.T) {
+	store := &mockUserDataStore{}
+
+	server := newServerHandler(serverDependencies{
+		userDataStore: store,
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	req := httptest.NewRequest("GET", "/score", nil)
+	req.Header.Set(requestid.Header, "abc-123")
+	res := httptest.NewRecorder()
+
+	server.ServeHTTP(res, req)
+
+	if store.lastRequestID != "abc-123" {
+		t.Errorf("Expected the store to see request ID %q but got %q", "abc-123", store.lastRequestID)
+	}
+
+	if res.Header().Get(requestid.Header) != "abc-123" {
+		t.Errorf("Expected request ID %q in the response but got %q", "abc-123", res.Header().Get(requestid.Header))
+	}
+}
+
+func TestRateLimitsArePerRouteAndPerClient(t *testing.T) {
+	server := newServerHandler(serverDependencies{
+		userDataStore: &mockUserDataStore{},
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		rateLimits: map[string]ratelimit.Limit{
+			"/score": {Requests: 1, Per: time.Minute},
+		},
+	})
+
+	send := func(method string, target string, remoteAddr string, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("x-user-id", userID)
+		res := httptest.NewRecorder()
+
+		server.ServeHTTP(res, req)
+
+		return res
+	}
+
+	if res := send("GET", "/score", "10.0.0.1:1234", "evertras"); res.Code != http.StatusOK {
+		t.Fatalf("Expected the first request through but got %d", res.Code)
+	}
+
+	res := send("GET", "/score", "10.0.0.1:1234", "evertras")
+
+	if res.Code != http.StatusTooManyRequests || res.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected 429 with Retry-After 60 but got %d and %q", res.Code, res.Header().Get("Retry-After"))
+	}
+
+	// Anyone can send any x-user-id, so a new one doesn't get a new limit
+	if res := send("GET", "/score", "10.0.0.1:5678", "ty"); res.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected a made up user ID to share the address's limit but got %d", res.Code)
+	}
+
+	if res := send("GET", "/score", "10.0.0.2:1234", "evertras"); res.Code != http.StatusOK {
+		t.Errorf("Expected another address to have its own limit but got %d", res.Code)
+	}
+
+	// /user has no limit configured at all
+	for i := 0; i < 5; i++ {
+		if res := send("DELETE", "/user", "10.0.0.1:1234", "evertras"); res.Code != http.StatusOK || res.Header().Get(ratelimit.HeaderLimit) != "" {
+			t.Fatalf("Expected /user to be unlimited but got %d with headers %v", res.Code, res.Header())
+		}
+	}
+}
+
+func TestUsersCanBeCreatedScoredAndDeleted(t *testing.T) {
+	database := db.New()
+
+	server := newServerHandler(serverDependencies{
+		userDataStore: database,
+		userCreator:   database,
+		pointsAwarder: database,
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	send := func(method string, target string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("x-user-id", "evertras")
+		res := httptest.NewRecorder()
+
+		server.ServeHTTP(res, req)
+
+		return res
+	}
+
+	steps := []struct {
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"GET", "/score", "", http.StatusNotFound},
+		{"POST", "/user", "evertras", http.StatusCreated},
+		{"POST", "/user", "evertras", http.StatusConflict},
+		{"POST", "/award", `{"user_ids": ["evertras"], "points": 10}`, http.StatusNoContent},
+		{"POST", "/award", `{"user_ids": ["evertras", "nobody"], "points": 10}`, http.StatusNotFound},
+		{"GET", "/score", "", http.StatusOK},
+		{"DELETE", "/user", "evertras", http.StatusOK},
+		{"DELETE", "/user", "evertras", http.StatusNotFound},
+	}
+
+	for _, step := range steps {
+		if res := send(step.method, step.target, step.body); res.Code != step.status {
+			t.Fatalf("%s %s %q: expected %d but got %d", step.method, step.target, step.body, step.status, res.Code)
+		}
+	}
+}
+
+func TestRetriedAwardsOnlyHappenOnce(t *testing.T) {
+	database := db.New()
+	_ = database.CreateUser(context.Background(), "evertras")
+
+	server := newServerHandler(serverDependencies{
+		userDataStore: database,
+		pointsAwarder: database,
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		deduplicator:  idempotency.New(time.Hour, requestScope),
+	})
+
+	award := func(key string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/award", strings.NewReader(body))
+		req.Header.Set("x-user-id", "admin")
+		req.Header.Set(idempotency.Header, key)
+		res := httptest.NewRecorder()
+
+		server.ServeHTTP(res, req)
+
+		return res
+	}
+
+	for i := 0; i < 3; i++ {
+		if res := award("award-1", `{"user_ids": ["evertras"], "points": 10}`); res.Code != http.StatusNoContent {
+			t.Fatalf("Attempt %d: expected 204 but got %d", i, res.Code)
+		}
+	}
+
+	if res := award("award-1", `{"user_ids": ["evertras"], "points": 100}`); res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a reused key but got %d", res.Code)
+	}
+
+	score, _ := database.GetUserScore(context.Background(), "evertras")
+
+	if score != 10 {
+		t.Errorf("Expected points to be awarded once for a score of 10 but got %d", score)
+	}
+}
+
+func TestIdempotencyKeysAreScopedToTheAddress(t *testing.T) {
+	database := db.New()
+	_ = database.CreateUser(context.Background(), "evertras")
+
+	server := newServerHandler(serverDependencies{
+		pointsAwarder: database,
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		deduplicator:  idempotency.New(time.Hour, requestScope),
+	})
+
+	// Claiming to be the same user from somewhere else doesn't get
+	// someone else's response replayed
+	for _, remoteAddr := range []string{"10.0.0.1:1234", "10.0.0.2:1234"} {
+		req := httptest.NewRequest("POST", "/award", strings.NewReader(`{"user_ids": ["evertras"], "points": 10}`))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("x-user-id", "admin")
+		req.Header.Set(idempotency.Header, "award-1")
+		res := httptest.NewRecorder()
+
+		server.ServeHTTP(res, req)
+
+		if res.Header().Get(idempotency.ReplayedHeader) != "" {
+			t.Errorf("Expected a request from %s to run rather than be replayed", remoteAddr)
+		}
+	}
+
+	score, _ := database.GetUserScore(context.Background(), "evertras")
+
+	if score != 20 {
+		t.Errorf("Expected both awards to happen for a score of 20 but got %d", score)
+	}
+}
+
+func TestNotifyTopPlayersTraceNestsLeaderboardStoreAndNotifier(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := tracing.NewTracer("leaderboard", exporter, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	database := db.New()
+	_ = database.CreateUser(context.Background(), "evertras")
+
+	store := newUserStoreDecorator(database, tracer.Middleware(tracing.KindClient))
+	notifier := newScoreNotifierDecorator(notifications.New(slog.New(slog.NewTextHandler(io.Discard, nil))), tracer.Middleware(tracing.KindClient))
+
+	server := newServerHandler(serverDependencies{
+		topPlayersNotifier: newTopPlayersNotifierDecorator(leaderboard.New(store, notifier), tracer.Middleware(tracing.KindInternal)),
+		logger:             slog.New(slog.NewTextHandler(io.Discard, nil)),
+		tracer:             tracer,
+	})
+
+	res := httptest.NewRecorder()
+	server.ServeHTTP(res, httptest.NewRequest("POST", "/leaderboard/notify", nil))
+
+	if res.Code != 204 {
+		t.Fatalf("Expected status %d but got %d", 204, res.Code)
+	}
+
+	tracer.Flush(context.Background())
+
+	parents := map[string]tracing.SpanID{}
+	ids := map[string]tracing.SpanID{}
+
+	for _, span := range exporter.spans {
+		parents[span.Name] = span.Parent
+		ids[span.Name] = span.SpanContext.SpanID
+	}
+
+	expected := map[string]string{
+		"POST /leaderboard/notify":            "",
+		"topPlayersNotifier.NotifyTopPlayers": "POST /leaderboard/notify",
+		"userStore.GetTopUsers":               "topPlayersNotifier.NotifyTopPlayers",
+		"scoreNotifier.NotifyTopScore":        "topPlayersNotifier.NotifyTopPlayers",
+	}
+
+	if len(parents) != len(expected) {
+		t.Fatalf("Expected spans %v but got %v", expected, parents)
+	}
+
+	for name, parent := range expected {
+		if parents[name] != ids[parent] {
+			t.Errorf("Expected %s to be a child of %q", name, parent)
+		}
+	}
+}
+
+// recordingExporter keeps every span it's given
+type recordingExporter struct {
+	spans []tracing.SpanData
+}
+
+func (e *recordingExporter) ExportSpans(ctx context.Context, service string, spans []tracing.SpanData) error {
+	e.spans = append(e.spans, spans...)
+
+	return nil
+}
@@ -3,11 +3,31 @@ package db
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"sync"
 )
 
-// Db does some IO with a database, mocked here for simplicity
+var (
+	// ErrUserNotFound means there's no user with that ID
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrUserExists means a user with that ID was already created
+	ErrUserExists = errors.New("user already exists")
+
+	// ErrInvalidID means the ID can't be used for a user, like an empty one
+	ErrInvalidID = errors.New("invalid user ID")
+)
+
+// Db does some IO with a database, kept in memory here for simplicity
+//
+// It's still pretend, but it's honest pretend: it behaves the way a real
+// database would, so the dbtest conformance suite can hold it to the same
+// rules as anything we'd swap in later.
 type Db struct {
 	// Would normally have all sorts of connection info here
+	mu     sync.RWMutex
+	scores map[string]int
 }
 
 // New returns a new Db ready to do Db things.
@@ -15,7 +35,9 @@ type Db struct {
 // Notice we don't provide an interface here!  We only provide the concrete
 // implementation.  Accept interfaces, return implementations.
 func New() *Db {
-	return &Db{}
+	return &Db{
+		scores: make(map[string]int),
+	}
 }
 
 // User represents a user as it's stored in the database
@@ -31,10 +53,22 @@ type User struct {
 // package.  This is often unavoidable for any non-trivial returns, but
 // it's a tradeoff to be aware of.
 func (d *Db) GetUser(ctx context.Context, id string) (*User, error) {
-	// Just fake a result for funsies
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	score, ok := d.scores[id]
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUserNotFound, id)
+	}
+
 	return &User{
 		ID:    id,
-		Score: 7,
+		Score: score,
 	}, nil
 }
 
@@ -44,35 +78,123 @@ func (d *Db) GetUser(ctx context.Context, id string) (*User, error) {
 // which means any interfaces that want to implement this do -not- need to
 // tie themselves to this package.  This is great when you only need single
 // fields at a time, but that won't always be the case.
+//
+// This used to make up a score of 7 for anyone at all.  Now a user has to
+// be created first, and asking about one who wasn't is ErrUserNotFound,
+// because that's what the handler mocks have always assumed and what any
+// real database would say.  The server answers 404 for them until someone
+// creates them with POST /user.
 func (d *Db) GetUserScore(ctx context.Context, id string) (int, error) {
-	// Just fake a result for funsies
-	return 7, nil
+	user, err := d.GetUser(ctx, id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return user.Score, nil
 }
 
 // CreateUser creates a user starting with a score of 0
 func (d *Db) CreateUser(ctx context.Context, id string) error {
-	// Just pretend we did the thing, it's fine
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if id == "" {
+		return ErrInvalidID
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.scores[id]; ok {
+		return fmt.Errorf("%w: %q", ErrUserExists, id)
+	}
+
+	d.scores[id] = 0
+
 	return nil
 }
 
 // DeleteUser deletes a user with the given ID
 func (d *Db) DeleteUser(ctx context.Context, id string) error {
-	// Just pretend we did the thing, it's fine
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.scores[id]; !ok {
+		return fmt.Errorf("%w: %q", ErrUserNotFound, id)
+	}
+
+	delete(d.scores, id)
+
 	return nil
 }
 
 // GetTopUsers returns the top X users ranked by score
 //
+// Users with the same score are ordered by ID so the result is always the
+// same for the same data.
+//
 // Note that it makes sense here to return full user information, or at least
 // some struct that contains user IDs and scores combined.  So we're tying
 // interfaces to this package.  Tradeoffs.
 func (d *Db) GetTopUsers(ctx context.Context, count int) ([]*User, error) {
-	// I'm too lazy to build a slice, just error out if we actually try to run this
-	return nil, errors.New("Not implemented, Evertras is lazy")
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if count < 0 {
+		return nil, fmt.Errorf("count must not be negative, got %d", count)
+	}
+
+	d.mu.RLock()
+	users := make([]*User, 0, len(d.scores))
+
+	for id, score := range d.scores {
+		users = append(users, &User{ID: id, Score: score})
+	}
+	d.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Score != users[j].Score {
+			return users[i].Score > users[j].Score
+		}
+
+		return users[i].ID < users[j].ID
+	})
+
+	if count < len(users) {
+		users = users[:count]
+	}
+
+	return users, nil
 }
 
 // AwardPoints gives points to all the users in the ids array
+//
+// Either everyone gets their points or nobody does.  If any of the users
+// don't exist, nothing changes.  An ID listed twice gets the points twice.
 func (d *Db) AwardPoints(ctx context.Context, ids []string, score int) error {
-	// Just pretend we did the thing, it's fine
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, id := range ids {
+		if _, ok := d.scores[id]; !ok {
+			return fmt.Errorf("%w: %q", ErrUserNotFound, id)
+		}
+	}
+
+	for _, id := range ids {
+		d.scores[id] += score
+	}
+
 	return nil
 }
@@ -0,0 +1,15 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+	"github.com/Evertras/go-interface-examples/local-interfaces/db/dbtest"
+)
+
+// Every backend runs the same suite.  Add new ones here.
+func TestDbConforms(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T) *db.Db {
+		return db.New()
+	})
+}
@@ -0,0 +1,332 @@
+// Package dbtest checks that a user store really behaves the way db.Db
+// promises to.
+//
+// The handlers and leaderboard tests use tiny mocks, and those mocks make
+// assumptions: that a missing user is an error you can spot with
+// errors.Is, that top users come back best first, that awarding points
+// doesn't lose any when two requests land at once.  This suite is where
+// those assumptions get checked against anything that claims to be a db.
+//
+// Run it from a test next to the implementation:
+//
+//	func TestDbConforms(t *testing.T) {
+//		dbtest.Run(t, func(t *testing.T) *db.Db {
+//			return db.New()
+//		})
+//	}
+package dbtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+)
+
+// userStore is everything a db backend has to do.  This is the one place a
+// big interface makes sense: we're checking the whole thing, not using it.
+//
+// It stays unexported so nobody is tempted to depend on it.  Code that uses
+// a store should still ask for only what it needs, like handlers does.
+type userStore interface {
+	GetUser(ctx context.Context, id string) (*db.User, error)
+	GetUserScore(ctx context.Context, id string) (int, error)
+	CreateUser(ctx context.Context, id string) error
+	DeleteUser(ctx context.Context, id string) error
+	GetTopUsers(ctx context.Context, count int) ([]*db.User, error)
+	AwardPoints(ctx context.Context, ids []string, score int) error
+}
+
+// concurrentAwards is how many goroutines award points at once.  A store
+// that loses an update ends up short of the expected total even without
+// -race.
+const concurrentAwards = 100
+
+// Run checks the store against every part of the contract as subtests.
+// newStore returns a fresh, empty store each time, and should use t for
+// any cleanup.
+func Run[S userStore](t *testing.T, newStore func(t *testing.T) S) {
+	ctx := context.Background()
+
+	t.Run("CreatedUsersStartAtZero", func(t *testing.T) {
+		store := newStore(t)
+
+		mustCreate(t, store, "evertras")
+
+		user, err := store.GetUser(ctx, "evertras")
+
+		if err != nil {
+			t.Fatal("GetUser:", err)
+		}
+
+		if user.ID != "evertras" || user.Score != 0 {
+			t.Errorf("Expected evertras with score 0 but got %+v", user)
+		}
+
+		assertScore(t, store, "evertras", 0)
+	})
+
+	t.Run("CreatingTwiceFails", func(t *testing.T) {
+		store := newStore(t)
+
+		mustCreate(t, store, "evertras")
+
+		err := store.CreateUser(ctx, "evertras")
+
+		if !errors.Is(err, db.ErrUserExists) {
+			t.Errorf("Expected db.ErrUserExists but got %v", err)
+		}
+	})
+
+	t.Run("CreatingEmptyIDFails", func(t *testing.T) {
+		store := newStore(t)
+
+		err := store.CreateUser(ctx, "")
+
+		if !errors.Is(err, db.ErrInvalidID) {
+			t.Errorf("Expected db.ErrInvalidID but got %v", err)
+		}
+	})
+
+	t.Run("MissingUsersAreNotFound", func(t *testing.T) {
+		store := newStore(t)
+
+		_, err := store.GetUser(ctx, "nobody")
+
+		if !errors.Is(err, db.ErrUserNotFound) {
+			t.Errorf("GetUser: expected db.ErrUserNotFound but got %v", err)
+		}
+
+		_, err = store.GetUserScore(ctx, "nobody")
+
+		if !errors.Is(err, db.ErrUserNotFound) {
+			t.Errorf("GetUserScore: expected db.ErrUserNotFound but got %v", err)
+		}
+
+		err = store.DeleteUser(ctx, "nobody")
+
+		if !errors.Is(err, db.ErrUserNotFound) {
+			t.Errorf("DeleteUser: expected db.ErrUserNotFound but got %v", err)
+		}
+	})
+
+	t.Run("DeletedUsersAreGone", func(t *testing.T) {
+		store := newStore(t)
+
+		mustCreate(t, store, "evertras")
+
+		err := store.DeleteUser(ctx, "evertras")
+
+		if err != nil {
+			t.Fatal("DeleteUser:", err)
+		}
+
+		_, err = store.GetUser(ctx, "evertras")
+
+		if !errors.Is(err, db.ErrUserNotFound) {
+			t.Errorf("Expected db.ErrUserNotFound after delete but got %v", err)
+		}
+
+		// And the ID is free to use again, starting from scratch
+		mustCreate(t, store, "evertras")
+		assertScore(t, store, "evertras", 0)
+	})
+
+	t.Run("AwardPointsAddsToEveryone", func(t *testing.T) {
+		store := newStore(t)
+
+		mustCreate(t, store, "a")
+		mustCreate(t, store, "b")
+		mustCreate(t, store, "c")
+
+		mustAward(t, store, []string{"a", "b"}, 5)
+		mustAward(t, store, []string{"a"}, 2)
+		mustAward(t, store, []string{"c", "c"}, 1)
+
+		assertScore(t, store, "a", 7)
+		assertScore(t, store, "b", 5)
+		assertScore(t, store, "c", 2)
+	})
+
+	t.Run("AwardPointsIsAllOrNothing", func(t *testing.T) {
+		store := newStore(t)
+
+		mustCreate(t, store, "a")
+
+		err := store.AwardPoints(ctx, []string{"a", "nobody"}, 5)
+
+		if !errors.Is(err, db.ErrUserNotFound) {
+			t.Errorf("Expected db.ErrUserNotFound but got %v", err)
+		}
+
+		assertScore(t, store, "a", 0)
+	})
+
+	t.Run("TopUsersAreBestFirstWithTiesByID", func(t *testing.T) {
+		store := newStore(t)
+
+		for _, id := range []string{"d", "b", "a", "c", "e"} {
+			mustCreate(t, store, id)
+		}
+
+		mustAward(t, store, []string{"c"}, 10)
+		mustAward(t, store, []string{"d", "b", "e"}, 4)
+
+		assertTopUsers(t, store, 4, []db.User{{ID: "c", Score: 10}, {ID: "b", Score: 4}, {ID: "d", Score: 4}, {ID: "e", Score: 4}})
+	})
+
+	t.Run("TopUsersHandlesCounts", func(t *testing.T) {
+		store := newStore(t)
+
+		assertTopUsers(t, store, 3, []db.User{})
+
+		mustCreate(t, store, "a")
+		mustCreate(t, store, "b")
+
+		assertTopUsers(t, store, 10, []db.User{{ID: "a"}, {ID: "b"}})
+		assertTopUsers(t, store, 0, []db.User{})
+
+		_, err := store.GetTopUsers(ctx, -1)
+
+		if err == nil {
+			t.Error("Expected an error for a negative count")
+		}
+	})
+
+	t.Run("ReturnedUsersAreCopies", func(t *testing.T) {
+		store := newStore(t)
+
+		mustCreate(t, store, "a")
+
+		user, err := store.GetUser(ctx, "a")
+
+		if err != nil {
+			t.Fatal("GetUser:", err)
+		}
+
+		user.Score = 9000
+
+		top, err := store.GetTopUsers(ctx, 1)
+
+		if err != nil {
+			t.Fatal("GetTopUsers:", err)
+		}
+
+		top[0].Score = 9000
+
+		assertScore(t, store, "a", 0)
+	})
+
+	t.Run("ConcurrentAwardsAreNotLost", func(t *testing.T) {
+		store := newStore(t)
+
+		mustCreate(t, store, "a")
+		mustCreate(t, store, "b")
+
+		var wg sync.WaitGroup
+		errs := make(chan error, concurrentAwards)
+
+		for i := 0; i < concurrentAwards; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				if err := store.AwardPoints(ctx, []string{"a", "b"}, 1); err != nil {
+					errs <- err
+				}
+			}()
+		}
+
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			t.Errorf("AwardPoints failed: %v", err)
+		}
+
+		assertScore(t, store, "a", concurrentAwards)
+		assertScore(t, store, "b", concurrentAwards)
+	})
+
+	t.Run("CanceledContextIsAnError", func(t *testing.T) {
+		store := newStore(t)
+
+		mustCreate(t, store, "a")
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		calls := map[string]error{
+			"CreateUser":  store.CreateUser(canceled, "b"),
+			"DeleteUser":  store.DeleteUser(canceled, "a"),
+			"AwardPoints": store.AwardPoints(canceled, []string{"a"}, 1),
+		}
+
+		_, calls["GetUser"] = store.GetUser(canceled, "a")
+		_, calls["GetUserScore"] = store.GetUserScore(canceled, "a")
+		_, calls["GetTopUsers"] = store.GetTopUsers(canceled, 1)
+
+		for name, err := range calls {
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("%s: expected context.Canceled but got %v", name, err)
+			}
+		}
+
+		// Nothing should have happened
+		assertScore(t, store, "a", 0)
+	})
+}
+
+func mustCreate(t *testing.T, store userStore, id string) {
+	t.Helper()
+
+	if err := store.CreateUser(context.Background(), id); err != nil {
+		t.Fatalf("CreateUser(%q): %v", id, err)
+	}
+}
+
+func mustAward(t *testing.T, store userStore, ids []string, score int) {
+	t.Helper()
+
+	if err := store.AwardPoints(context.Background(), ids, score); err != nil {
+		t.Fatalf("AwardPoints(%v, %d): %v", ids, score, err)
+	}
+}
+
+func assertScore(t *testing.T, store userStore, id string, expected int) {
+	t.Helper()
+
+	score, err := store.GetUserScore(context.Background(), id)
+
+	if err != nil {
+		t.Fatalf("GetUserScore(%q): %v", id, err)
+	}
+
+	if score != expected {
+		t.Errorf("Expected %q to have score %d but got %d", id, expected, score)
+	}
+}
+
+func assertTopUsers(t *testing.T, store userStore, count int, expected []db.User) {
+	t.Helper()
+
+	users, err := store.GetTopUsers(context.Background(), count)
+
+	if err != nil {
+		t.Fatalf("GetTopUsers(%d): %v", count, err)
+	}
+
+	got := make([]db.User, len(users))
+
+	for i, user := range users {
+		got[i] = *user
+	}
+
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("GetTopUsers(%d): expected %v but got %v", count, expected, got)
+	}
+}
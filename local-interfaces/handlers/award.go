@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+)
+
+// maxAwardBytes is plenty for a few hundred user IDs
+const maxAwardBytes = 64 * 1024
+
+// PointsAwarder can give points to users
+type PointsAwarder interface {
+	AwardPoints(ctx context.Context, ids []string, score int) error
+}
+
+// awardRequest is what a client sends to award points
+type awardRequest struct {
+	UserIDs []string `json:"user_ids"`
+	Points  int      `json:"points"`
+}
+
+// AwardPointsHandler creates an HTTP handler that gives points to every
+// user listed in the request body, like {"user_ids": ["a", "b"], "points": 10}
+//
+// Awarding isn't safe to do twice, so clients that retry should send an
+// Idempotency-Key.
+func AwardPointsHandler(pointsAwarder PointsAwarder, logger *slog.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		var award awardRequest
+
+		decoder := json.NewDecoder(http.MaxBytesReader(res, req.Body, maxAwardBytes))
+		decoder.DisallowUnknownFields()
+
+		err := decoder.Decode(&award)
+
+		if err != nil || len(award.UserIDs) == 0 || award.Points < 1 {
+			http.Error(res, "expected user_ids and a positive number of points", http.StatusBadRequest)
+			return
+		}
+
+		err = pointsAwarder.AwardPoints(req.Context(), award.UserIDs, award.Points)
+
+		// Nobody gets points if anyone listed doesn't exist
+		if errors.Is(err, db.ErrUserNotFound) {
+			http.Error(res, "no such user", http.StatusNotFound)
+			return
+		}
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "pointsAwarder.AwardPoints failed", "user_ids", award.UserIDs, "points", award.Points, "error", err)
+			res.WriteHeader(500)
+			return
+		}
+
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
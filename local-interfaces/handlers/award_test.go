@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+)
+
+type mockPointsAwarder struct {
+	pendingError error
+
+	awardedIDs    []string
+	awardedPoints int
+}
+
+func (a *mockPointsAwarder) AwardPoints(ctx context.Context, ids []string, score int) error {
+	a.awardedIDs = ids
+	a.awardedPoints = score
+
+	return a.pendingError
+}
+
+func TestAwardPointsHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		pendingError error
+		status       int
+		awarded      bool
+	}{
+		{"Awarded", `{"user_ids": ["a", "b"], "points": 10}`, nil, 204, true},
+		{"NotJSON", `ten points to everyone`, nil, 400, false},
+		{"NoUsers", `{"user_ids": [], "points": 10}`, nil, 400, false},
+		{"NoPoints", `{"user_ids": ["a"], "points": 0}`, nil, 400, false},
+		{"UnknownField", `{"user_ids": ["a"], "points": 10, "bonus": true}`, nil, 400, false},
+		{"StoreFails", `{"user_ids": ["a"], "points": 10}`, errors.New("oh no"), 500, true},
+		{"MissingUser", `{"user_ids": ["a"], "points": 10}`, fmt.Errorf("%w: %q", db.ErrUserNotFound, "a"), 404, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			awarder := &mockPointsAwarder{pendingError: test.pendingError}
+			res := httptest.NewRecorder()
+
+			AwardPointsHandler(awarder, discardLogger)(res, httptest.NewRequest("POST", "/award", strings.NewReader(test.body)))
+
+			if res.Code != test.status {
+				t.Errorf("Expected status %d but got %d", test.status, res.Code)
+			}
+
+			if test.awarded != (awarder.awardedIDs != nil) {
+				t.Errorf("Expected awarded to be %v but got %v", test.awarded, awarder.awardedIDs)
+			}
+
+			if test.awarded && awarder.awardedPoints != 10 {
+				t.Errorf("Expected 10 points but got %d", awarder.awardedPoints)
+			}
+		})
+	}
+}
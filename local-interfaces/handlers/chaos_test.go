@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/chaos"
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+)
+
+func TestGetUserScoreHandlerWhenTheStoreHangs(t *testing.T) {
+	database := db.New()
+	_ = database.CreateUser(context.Background(), "evertras")
+
+	store := chaos.NewDb(database, chaos.New(chaos.Config{DeadlineRate: 1}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	req := httptest.NewRequest("GET", "/idk", nil).WithContext(ctx)
+	req.Header.Set("x-user-id", "evertras")
+	res := httptest.NewRecorder()
+
+	GetUserScoreHandler(store, discardLogger)(res, req)
+
+	if res.Code != 500 {
+		t.Errorf("Expected 500 but got %d", res.Code)
+	}
+}
+
+func TestDeleteUserHandlerWithFlakyStore(t *testing.T) {
+	// Seeded, so whichever calls fail, they fail the same way every run
+	store := chaos.NewDb(db.New(), chaos.New(chaos.Config{Seed: 3, ErrorRate: 0.5}))
+	failed := 0
+
+	for i := 0; i < 20; i++ {
+		_ = store.CreateUser(context.Background(), "evertras")
+
+		req := httptest.NewRequest("DELETE", "/user/idk", bytes.NewBufferString("evertras"))
+		res := httptest.NewRecorder()
+
+		DeleteUserHandler(store, discardLogger)(res, req)
+
+		// A create that failed leaves nobody to delete, which is a 404
+		switch res.Code {
+		case 200, 404:
+		case 500:
+			failed++
+		default:
+			t.Fatalf("Expected 200, 404 or 500 but got %d", res.Code)
+		}
+	}
+
+	if failed == 0 || failed == 20 {
+		t.Errorf("Expected some deletes to fail but %d of 20 did", failed)
+	}
+}
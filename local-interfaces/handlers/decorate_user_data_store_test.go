@@ -0,0 +1,63 @@
+// Code generated by localdecorate; DO NOT EDIT.
+
+package handlers
+
+import (
+	"context"
+
+	"github.com/Evertras/go-interface-examples/decorate"
+)
+
+// recordedUserDataStore runs every UserDataStore call through middleware, and
+// satisfies UserDataStore itself so nobody using it can tell
+type recordedUserDataStore struct {
+	next       UserDataStore
+	middleware decorate.Middleware
+}
+
+// newRecordedUserDataStore wraps next so every call goes through the middleware,
+// the first one outermost
+func newRecordedUserDataStore(next UserDataStore, middleware ...decorate.Middleware) *recordedUserDataStore {
+	return &recordedUserDataStore{
+		next:       next,
+		middleware: decorate.Chain(middleware...),
+	}
+}
+
+// DeleteUser calls through to the wrapped UserDataStore
+func (d *recordedUserDataStore) DeleteUser(ctx context.Context, id string) error {
+	call := decorate.Call{
+		Interface: "UserDataStore",
+		Method:    "DeleteUser",
+		Args:      []interface{}{id},
+		Results:   []interface{}{},
+	}
+
+	err := d.middleware(ctx, call, func(ctx context.Context) error {
+		return d.next.DeleteUser(ctx, id)
+	})
+
+	return err
+}
+
+// GetUserScore calls through to the wrapped UserDataStore
+func (d *recordedUserDataStore) GetUserScore(ctx context.Context, id string) (int, error) {
+	var result0 int
+
+	call := decorate.Call{
+		Interface: "UserDataStore",
+		Method:    "GetUserScore",
+		Args:      []interface{}{id},
+		Results:   []interface{}{&result0},
+	}
+
+	err := d.middleware(ctx, call, func(ctx context.Context) error {
+		var err error
+
+		result0, err = d.next.GetUserScore(ctx, id)
+
+		return err
+	})
+
+	return result0, err
+}
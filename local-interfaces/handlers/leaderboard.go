@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// defaultTop is how many players get notified if the request doesn't say
+const defaultTop = 3
+
+// maxTop stops one request from notifying the whole player base
+const maxTop = 100
+
+// TopPlayersNotifier can tell the top players how well they're doing
+//
+// This is all the handler needs from the leaderboard.  Notice it doesn't
+// know or care that a leaderboard talks to a database and a notifier.
+type TopPlayersNotifier interface {
+	NotifyTopPlayers(ctx context.Context, top int) error
+}
+
+// NotifyTopPlayersHandler creates an HTTP handler that notifies the top
+// players, as many as the top query parameter asks for
+func NotifyTopPlayersHandler(topPlayersNotifier TopPlayersNotifier, logger *slog.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		top := defaultTop
+
+		if raw := req.URL.Query().Get("top"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+
+			if err != nil || parsed < 1 || parsed > maxTop {
+				http.Error(res, "top must be a number from 1 to "+strconv.Itoa(maxTop), http.StatusBadRequest)
+				return
+			}
+
+			top = parsed
+		}
+
+		err := topPlayersNotifier.NotifyTopPlayers(req.Context(), top)
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "topPlayersNotifier.NotifyTopPlayers failed", "top", top, "error", err)
+			res.WriteHeader(500)
+			return
+		}
+
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
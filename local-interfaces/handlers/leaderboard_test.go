@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+type mockTopPlayersNotifier struct {
+	pendingError error
+	tops         []int
+}
+
+func (n *mockTopPlayersNotifier) NotifyTopPlayers(ctx context.Context, top int) error {
+	n.tops = append(n.tops, top)
+
+	return n.pendingError
+}
+
+func TestNotifyTopPlayersHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		pendingError error
+		status       int
+		top          int
+	}{
+		{"Default", "/leaderboard/notify", nil, 204, defaultTop},
+		{"Chosen", "/leaderboard/notify?top=10", nil, 204, 10},
+		{"NotANumber", "/leaderboard/notify?top=lots", nil, 400, 0},
+		{"TooMany", "/leaderboard/notify?top=1000", nil, 400, 0},
+		{"LeaderboardFails", "/leaderboard/notify", errors.New("oh no"), 500, defaultTop},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notifier := &mockTopPlayersNotifier{pendingError: test.pendingError}
+			res := httptest.NewRecorder()
+
+			NotifyTopPlayersHandler(notifier, discardLogger)(res, httptest.NewRequest("POST", test.url, nil))
+
+			if res.Code != test.status {
+				t.Errorf("Expected status %d but got %d", test.status, res.Code)
+			}
+
+			if test.top == 0 {
+				if len(notifier.tops) != 0 {
+					t.Errorf("Expected no notifications but got %v", notifier.tops)
+				}
+
+				return
+			}
+
+			if len(notifier.tops) != 1 || notifier.tops[0] != test.top {
+				t.Errorf("Expected to notify the top %d but got %v", test.top, notifier.tops)
+			}
+		})
+	}
+}
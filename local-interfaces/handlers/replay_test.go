@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+	"github.com/Evertras/go-interface-examples/replay"
+)
+
+// The mock says whatever we tell it to.  These tests use what a real db.Db
+// actually said instead, recorded in testdata.  Run them with
+// -replay.record to ask the real thing again.
+
+func replayedUserDataStore(t *testing.T) UserDataStore {
+	t.Helper()
+
+	// Only used when recording, replaying never touches it
+	database := db.New()
+	ctx := context.Background()
+
+	if err := database.CreateUser(ctx, "evertras"); err != nil {
+		t.Fatal("CreateUser:", err)
+	}
+
+	if err := database.AwardPoints(ctx, []string{"evertras"}, 9000); err != nil {
+		t.Fatal("AwardPoints:", err)
+	}
+
+	return newRecordedUserDataStore(database,
+		replay.Golden(t, "testdata/"+t.Name()+".golden.json", replay.Sentinels(db.ErrUserNotFound)),
+	)
+}
+
+func TestRecordedGetUserScore(t *testing.T) {
+	req := httptest.NewRequest("GET", "/idk", nil)
+	req.Header.Set("x-user-id", "evertras")
+	res := httptest.NewRecorder()
+
+	GetUserScoreHandler(replayedUserDataStore(t), discardLogger)(res, req)
+
+	if res.Code != 200 || res.Body.String() != "9000" {
+		t.Errorf("Expected 200 with 9000 but got %d with %q", res.Code, res.Body.String())
+	}
+}
+
+func TestRecordedGetUserScoreForMissingUser(t *testing.T) {
+	req := httptest.NewRequest("GET", "/idk", nil)
+	req.Header.Set("x-user-id", "nobody")
+	res := httptest.NewRecorder()
+
+	GetUserScoreHandler(replayedUserDataStore(t), discardLogger)(res, req)
+
+	if res.Code != 404 {
+		t.Errorf("Expected 404 but got %d", res.Code)
+	}
+}
+
+func TestRecordedDeleteUser(t *testing.T) {
+	req := httptest.NewRequest("DELETE", "/user/idk", bytes.NewBufferString("evertras"))
+	res := httptest.NewRecorder()
+
+	DeleteUserHandler(replayedUserDataStore(t), discardLogger)(res, req)
+
+	if res.Code != 200 {
+		t.Errorf("Expected 200 but got %d", res.Code)
+	}
+}
@@ -2,11 +2,17 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
 )
 
+//go:generate go run ../../cmd/localdecorate -type UserDataStore -name recordedUserDataStore -o decorate_user_data_store_test.go
+
 // UserDataStore can access and modify user data
 //
 // This is a little more broad and we're assuming the same type
@@ -19,15 +25,32 @@ type UserDataStore interface {
 	DeleteUser(ctx context.Context, id string) error
 }
 
+// UserCreator can create new users
+//
+// Creating is kept apart from UserDataStore since only one handler needs it,
+// and nothing else should have to implement it just to be a UserDataStore.
+type UserCreator interface {
+	CreateUser(ctx context.Context, id string) error
+}
+
 // GetUserScoreHandler creates an HTTP handler that can get a user's score
-func GetUserScoreHandler(userDataStore UserDataStore) http.HandlerFunc {
+//
+// The logger is a dependency like any other.  Log with the request's
+// context so whatever the logger does with it, like adding a request ID,
+// still happens.
+func GetUserScoreHandler(userDataStore UserDataStore, logger *slog.Logger) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
 		id := req.Header.Get("x-user-id")
 
 		score, err := userDataStore.GetUserScore(req.Context(), id)
 
+		if errors.Is(err, db.ErrUserNotFound) {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+
 		if err != nil {
-			fmt.Println("userDataStore.GetUserScore: ", err)
+			logger.ErrorContext(req.Context(), "userDataStore.GetUserScore failed", "user_id", id, "error", err)
 			res.WriteHeader(500)
 			return
 		}
@@ -37,13 +60,13 @@ func GetUserScoreHandler(userDataStore UserDataStore) http.HandlerFunc {
 }
 
 // DeleteUserHandler creates an HTTP handler that deletes a user from the store
-func DeleteUserHandler(userDataStore UserDataStore) http.HandlerFunc {
+func DeleteUserHandler(userDataStore UserDataStore, logger *slog.Logger) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
 		// Totally trust the client, this is fine (it's not, don't do this)
 		body, err := io.ReadAll(req.Body)
 
 		if err != nil {
-			fmt.Println("io.ReadAll(req.Body): ", err)
+			logger.ErrorContext(req.Context(), "io.ReadAll(req.Body) failed", "error", err)
 			res.WriteHeader(500)
 			return
 		}
@@ -52,10 +75,48 @@ func DeleteUserHandler(userDataStore UserDataStore) http.HandlerFunc {
 
 		err = userDataStore.DeleteUser(req.Context(), id)
 
+		if errors.Is(err, db.ErrUserNotFound) {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+
 		if err != nil {
-			fmt.Println("userDataStore.GetUserScore: ", err)
+			logger.ErrorContext(req.Context(), "userDataStore.DeleteUser failed", "user_id", id, "error", err)
 			res.WriteHeader(500)
 			return
 		}
 	}
 }
+
+// CreateUserHandler creates an HTTP handler that creates a user with the ID
+// in the body, starting with a score of 0
+func CreateUserHandler(userCreator UserCreator, logger *slog.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "io.ReadAll(req.Body) failed", "error", err)
+			res.WriteHeader(500)
+			return
+		}
+
+		id := string(body)
+
+		err = userCreator.CreateUser(req.Context(), id)
+
+		switch {
+		case errors.Is(err, db.ErrInvalidID):
+			http.Error(res, "expected a user ID in the body", http.StatusBadRequest)
+
+		case errors.Is(err, db.ErrUserExists):
+			res.WriteHeader(http.StatusConflict)
+
+		case err != nil:
+			logger.ErrorContext(req.Context(), "userCreator.CreateUser failed", "user_id", id, "error", err)
+			res.WriteHeader(500)
+
+		default:
+			res.WriteHeader(http.StatusCreated)
+		}
+	}
+}
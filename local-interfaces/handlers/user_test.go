@@ -3,16 +3,27 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+	"github.com/Evertras/go-interface-examples/requestid"
 )
 
+// Most tests don't care what gets logged
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
 type mockUserDataStore struct {
 	pendingError error
 	pendingScore int
 
 	deletedUsers []string
+	createdUsers []string
 }
 
 func (m *mockUserDataStore) GetUserScore(ctx context.Context, id string) (int, error) {
@@ -29,6 +40,16 @@ func (m *mockUserDataStore) DeleteUser(ctx context.Context, id string) error {
 	return nil
 }
 
+func (m *mockUserDataStore) CreateUser(ctx context.Context, id string) error {
+	if m.pendingError != nil {
+		return m.pendingError
+	}
+
+	m.createdUsers = append(m.createdUsers, id)
+
+	return nil
+}
+
 func TestGetUserScoreHandlerReturnsScore(t *testing.T) {
 	req := httptest.NewRequest("GET", "/idk", nil)
 	res := httptest.NewRecorder()
@@ -37,7 +58,7 @@ func TestGetUserScoreHandlerReturnsScore(t *testing.T) {
 		pendingScore: 3,
 	}
 
-	handler := GetUserScoreHandler(userDataStore)
+	handler := GetUserScoreHandler(userDataStore, discardLogger)
 
 	handler(res, req)
 
@@ -53,6 +74,40 @@ func TestGetUserScoreHandlerReturnsScore(t *testing.T) {
 	}
 }
 
+func TestGetUserScoreHandlerFailsWhenStoreFails(t *testing.T) {
+	req := httptest.NewRequest("GET", "/idk", nil)
+	res := httptest.NewRecorder()
+
+	userDataStore := &mockUserDataStore{
+		pendingError: errors.New("oh no"),
+	}
+
+	handler := GetUserScoreHandler(userDataStore, discardLogger)
+
+	handler(res, req)
+
+	if res.Code != 500 {
+		t.Errorf("Expected HTTP response 500 but got %d", res.Code)
+	}
+}
+
+func TestGetUserScoreHandlerReturns404ForMissingUser(t *testing.T) {
+	req := httptest.NewRequest("GET", "/idk", nil)
+	res := httptest.NewRecorder()
+
+	userDataStore := &mockUserDataStore{
+		pendingError: fmt.Errorf("%w: %q", db.ErrUserNotFound, "nobody"),
+	}
+
+	handler := GetUserScoreHandler(userDataStore, discardLogger)
+
+	handler(res, req)
+
+	if res.Code != 404 {
+		t.Errorf("Expected HTTP response 404 but got %d", res.Code)
+	}
+}
+
 func TestDeleteUserDeletesUserIDFromBody(t *testing.T) {
 	id := "fakeusersomething"
 	req := httptest.NewRequest("DELETE", "/user/idk", bytes.NewBufferString(id))
@@ -62,7 +117,7 @@ func TestDeleteUserDeletesUserIDFromBody(t *testing.T) {
 		pendingScore: 3,
 	}
 
-	handler := DeleteUserHandler(userDataStore)
+	handler := DeleteUserHandler(userDataStore, discardLogger)
 
 	handler(res, req)
 
@@ -78,3 +133,90 @@ func TestDeleteUserDeletesUserIDFromBody(t *testing.T) {
 		t.Errorf("Expected to delete id %q but deleted %q", id, userDataStore.deletedUsers[0])
 	}
 }
+
+func TestDeleteUserFailureIsLoggedWithRequestID(t *testing.T) {
+	var out bytes.Buffer
+
+	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(&out, nil)))
+
+	req := httptest.NewRequest("DELETE", "/user/idk", bytes.NewBufferString("evertras"))
+	req = req.WithContext(requestid.NewContext(req.Context(), "abc-123"))
+	res := httptest.NewRecorder()
+
+	userDataStore := &mockUserDataStore{
+		pendingError: errors.New("oh no"),
+	}
+
+	DeleteUserHandler(userDataStore, logger)(res, req)
+
+	var logged map[string]interface{}
+
+	err := json.Unmarshal(out.Bytes(), &logged)
+
+	if err != nil {
+		t.Fatalf("Expected one JSON log line but got %q: %v", out.String(), err)
+	}
+
+	expected := map[string]interface{}{
+		"level":      "ERROR",
+		"msg":        "userDataStore.DeleteUser failed",
+		"user_id":    "evertras",
+		"error":      "oh no",
+		"request_id": "abc-123",
+	}
+
+	for key, value := range expected {
+		if logged[key] != value {
+			t.Errorf("Expected %s to be %q but got %q", key, value, logged[key])
+		}
+	}
+}
+
+func TestDeleteUserReturns404ForMissingUser(t *testing.T) {
+	req := httptest.NewRequest("DELETE", "/user/idk", bytes.NewBufferString("nobody"))
+	res := httptest.NewRecorder()
+
+	userDataStore := &mockUserDataStore{
+		pendingError: fmt.Errorf("%w: %q", db.ErrUserNotFound, "nobody"),
+	}
+
+	DeleteUserHandler(userDataStore, discardLogger)(res, req)
+
+	if res.Code != 404 {
+		t.Errorf("Expected HTTP response 404 but got %d", res.Code)
+	}
+}
+
+func TestCreateUserHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		pendingError error
+		status       int
+	}{
+		{"Created", nil, 201},
+		{"AlreadyExists", fmt.Errorf("%w: %q", db.ErrUserExists, "evertras"), 409},
+		{"InvalidID", db.ErrInvalidID, 400},
+		{"StoreFails", errors.New("oh no"), 500},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/user", bytes.NewBufferString("evertras"))
+			res := httptest.NewRecorder()
+
+			userDataStore := &mockUserDataStore{
+				pendingError: test.pendingError,
+			}
+
+			CreateUserHandler(userDataStore, discardLogger)(res, req)
+
+			if res.Code != test.status {
+				t.Errorf("Expected HTTP response %d but got %d", test.status, res.Code)
+			}
+
+			if test.status == 201 && (len(userDataStore.createdUsers) != 1 || userDataStore.createdUsers[0] != "evertras") {
+				t.Errorf("Expected to create %q but created %v", "evertras", userDataStore.createdUsers)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/chaos"
+	"github.com/Evertras/go-interface-examples/local-interfaces/db"
+	"github.com/Evertras/go-interface-examples/local-interfaces/notifications"
+)
+
+// The mocks above fail on every call or none.  These use the real db and
+// notifier with chaos in between, so things go wrong partway through.
+
+func seededDb(t *testing.T) *db.Db {
+	t.Helper()
+
+	ctx := context.Background()
+	database := db.New()
+
+	for i, id := range []string{"a", "b", "c", "d"} {
+		if err := database.CreateUser(ctx, id); err != nil {
+			t.Fatal("CreateUser:", err)
+		}
+
+		if err := database.AwardPoints(ctx, []string{id}, 10*(i+1)); err != nil {
+			t.Fatal("AwardPoints:", err)
+		}
+	}
+
+	return database
+}
+
+func TestNotifyTopPlayersStopsWhenANotificationFailsPartway(t *testing.T) {
+	injector := chaos.NewScripted(&chaos.Scenario{
+		Steps: []chaos.Step{
+			{Method: "NotifyTopScore", Call: 2, Fault: chaos.FaultError},
+		},
+	})
+
+	leaderboard := New(chaos.NewDb(seededDb(t), injector), chaos.NewNotifier(notifications.New(slog.New(slog.NewTextHandler(io.Discard, nil))), injector))
+
+	err := leaderboard.NotifyTopPlayers(context.Background(), 3)
+
+	if !errors.Is(err, chaos.ErrInjected) {
+		t.Errorf("Expected the injected failure but got %v", err)
+	}
+
+	if calls := injector.Calls("NotifyTopScore"); calls != 2 {
+		t.Errorf("Expected to stop after the second notification but saw %d", calls)
+	}
+}
+
+func TestNotifyTopPlayersGivesUpWhenTheDbHangs(t *testing.T) {
+	injector := chaos.New(chaos.Config{DeadlineRate: 1, Methods: []string{"GetTopUsers"}})
+
+	leaderboard := New(chaos.NewDb(seededDb(t), injector), chaos.NewNotifier(notifications.New(slog.New(slog.NewTextHandler(io.Discard, nil))), injector))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := leaderboard.NotifyTopPlayers(ctx, 3)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded but got %v", err)
+	}
+
+	if calls := injector.Calls("NotifyTopScore"); calls != 0 {
+		t.Errorf("Expected no notifications but saw %d", calls)
+	}
+}
+
+// Whatever happens, nobody gets told twice and nothing panics
+func TestNotifyTopPlayersUnderRandomChaos(t *testing.T) {
+	for seed := int64(0); seed < 50; seed++ {
+		injector := chaos.New(chaos.Config{Seed: seed, ErrorRate: 0.2})
+
+		leaderboard := New(chaos.NewDb(seededDb(t), injector), chaos.NewNotifier(notifications.New(slog.New(slog.NewTextHandler(io.Discard, nil))), injector))
+
+		err := leaderboard.NotifyTopPlayers(context.Background(), 3)
+
+		if err != nil && !errors.Is(err, chaos.ErrInjected) {
+			t.Errorf("Seed %d: expected an injected failure or nothing but got %v", seed, err)
+		}
+
+		if calls := injector.Calls("NotifyTopScore"); calls > 3 {
+			t.Errorf("Seed %d: expected at most 3 notifications but saw %d", seed, calls)
+		}
+	}
+}
@@ -2,7 +2,7 @@ package notifications
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
 )
 
 // Notifier sends notifications to the user
@@ -10,18 +10,24 @@ import (
 // Actual implementation doesn't matter here, we're just
 // including this to have something other than a database
 // as an example.
-type Notifier struct{}
+type Notifier struct {
+	logger *slog.Logger
+}
 
 // New returns a new Notifier ready to send notifications
 //
 // Doesn't actually do anything, but real code will do stuff here...
-func New() *Notifier {
-	return &Notifier{}
+// It logs with the caller's context, so if that came from a request the
+// log line can say which one.
+func New(logger *slog.Logger) *Notifier {
+	return &Notifier{
+		logger: logger,
+	}
 }
 
 // NotifyTopScore sends a notification to a user about their top score
 func (n *Notifier) NotifyTopScore(ctx context.Context, id string, score int) error {
-	fmt.Printf("Sending notification to ID %q about their high score of %d\n", id, score)
+	n.logger.InfoContext(ctx, "Sending top score notification", "user_id", id, "score", score)
 
 	// Don't actually do anything useful...
 
@@ -30,7 +36,7 @@ func (n *Notifier) NotifyTopScore(ctx context.Context, id string, score int) err
 
 // NotifyPasswordUpdate notifies a user that their password has been updated
 func (n *Notifier) NotifyPasswordUpdate(ctx context.Context, id string) error {
-	fmt.Printf("Sending notification to ID %q about their password being updated\n", id)
+	n.logger.InfoContext(ctx, "Sending password update notification", "user_id", id)
 
 	// Don't actually do anything useful...
 
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/decorate"
+)
+
+// HTTPMetrics counts requests and how long they took, by route, method and
+// status
+type HTTPMetrics struct {
+	requests *Counter
+	duration *Histogram
+}
+
+// NewHTTPMetrics registers the HTTP request metrics
+func NewHTTPMetrics(registry *Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: registry.NewCounter("http_requests_total", "HTTP requests served.", "route", "method", "status"),
+		duration: registry.NewHistogram("http_request_duration_seconds", "How long HTTP requests took to serve.", nil, "route", "method", "status"),
+	}
+}
+
+// knownMethods are the methods that get their own label value
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// ObserveRequest records one finished request.  Route should be the
+// pattern it matched rather than the raw path, or every player and
+// tournament ID gets its own series.
+//
+// Clients can send any method they make up, so anything that isn't a
+// standard method is counted as "other" for the same reason.
+func (m *HTTPMetrics) ObserveRequest(route string, method string, status int, elapsed time.Duration) {
+	if !knownMethods[method] {
+		method = "other"
+	}
+
+	code := strconv.Itoa(status)
+
+	m.requests.Inc(route, method, code)
+	m.duration.Observe(elapsed.Seconds(), route, method, code)
+}
+
+// CallMetrics counts calls to a dependency and how long they took
+type CallMetrics struct {
+	calls    *Counter
+	errors   *Counter
+	duration *Histogram
+}
+
+// NewCallMetrics registers call metrics named after the subsystem, like
+// store_calls_total and store_call_duration_seconds
+func NewCallMetrics(registry *Registry, subsystem string) *CallMetrics {
+	return &CallMetrics{
+		calls:    registry.NewCounter(subsystem+"_calls_total", "Calls made to the "+subsystem+".", "interface", "method"),
+		errors:   registry.NewCounter(subsystem+"_call_errors_total", "Calls to the "+subsystem+" that failed.", "interface", "method"),
+		duration: registry.NewHistogram(subsystem+"_call_duration_seconds", "How long calls to the "+subsystem+" took.", nil, "interface", "method"),
+	}
+}
+
+// Middleware records every call through a generated decorator
+func (m *CallMetrics) Middleware() decorate.Middleware {
+	return decorate.Hooks{
+		After: func(ctx context.Context, call decorate.Call, elapsed time.Duration, err error) {
+			m.calls.Inc(call.Interface, call.Method)
+			m.duration.Observe(elapsed.Seconds(), call.Interface, call.Method)
+
+			if err != nil {
+				m.errors.Inc(call.Interface, call.Method)
+			}
+		},
+	}.Middleware()
+}
+
+// NotificationMetrics counts notifications sent and failed, by channel
+type NotificationMetrics struct {
+	sent   *Counter
+	failed *Counter
+}
+
+// NewNotificationMetrics registers the notification metrics
+func NewNotificationMetrics(registry *Registry) *NotificationMetrics {
+	return &NotificationMetrics{
+		sent:   registry.NewCounter("notifications_sent_total", "Notifications sent.", "channel"),
+		failed: registry.NewCounter("notifications_failed_total", "Notifications that couldn't be sent.", "channel"),
+	}
+}
+
+// ObserveNotification records how one notification went
+func (m *NotificationMetrics) ObserveNotification(channel string, err error) {
+	if err != nil {
+		m.failed.Inc(channel)
+		return
+	}
+
+	m.sent.Inc(channel)
+}
@@ -0,0 +1,329 @@
+// Package metrics is a small metrics registry that speaks the Prometheus
+// text format.
+//
+// It does counters, gauges and histograms with labels, which covers what
+// the servers here need without pulling in a client library.  A Registry
+// is an http.Handler, so serving it is one line:
+//
+//	registry := metrics.NewRegistry()
+//	mux.Handle("/metrics", registry)
+//
+// Nothing in the handlers or stores knows about this package.  They take
+// small local interfaces, and main hands them things from here that
+// happen to fit.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are histogram buckets in seconds, good for request and
+// call latencies
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var validName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+// Registry holds metrics and writes them out
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]*family
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: make(map[string]*family),
+	}
+}
+
+// family is one metric name, with a series per set of label values
+type family struct {
+	name       string
+	help       string
+	kind       metricType
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+
+	// value is the counter or gauge value, or the histogram's sum
+	value float64
+
+	// counts are per bucket, not cumulative, plus one more for +Inf
+	counts []uint64
+	count  uint64
+}
+
+func (r *Registry) register(name string, help string, kind metricType, buckets []float64, labelNames []string) *family {
+	if !validName.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+
+	for _, label := range labelNames {
+		if !validName.MatchString(label) || strings.HasPrefix(label, "__") || label == "le" {
+			panic(fmt.Sprintf("metrics: invalid label name %q for %s", label, name))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Registering the same thing twice is a programming mistake, and it's
+	// better to find out at startup than from a confusing scrape
+	if _, ok := r.metrics[name]; ok {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+
+	f := &family{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*series),
+	}
+
+	r.metrics[name] = f
+
+	return f
+}
+
+func (f *family) with(labelValues []string) *series {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metrics: %s has labels %v but got values %v", f.name, f.labelNames, labelValues))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	s, ok := f.series[key]
+
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+
+		if f.kind == typeHistogram {
+			s.counts = make([]uint64, len(f.buckets)+1)
+		}
+
+		f.series[key] = s
+	}
+
+	return s
+}
+
+// Counter only goes up
+type Counter struct {
+	family *family
+}
+
+// NewCounter registers a counter.  Names should end in _total.
+func (r *Registry) NewCounter(name string, help string, labelNames ...string) *Counter {
+	return &Counter{family: r.register(name, help, typeCounter, nil, labelNames)}
+}
+
+// Inc adds one to the series with these label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds to the series with these label values.  Counters can't go
+// down, so negative values panic.
+func (c *Counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		panic(fmt.Sprintf("metrics: counter %s can't go down", c.family.name))
+	}
+
+	c.family.mu.Lock()
+	defer c.family.mu.Unlock()
+
+	c.family.with(labelValues).value += value
+}
+
+// Gauge goes up and down
+type Gauge struct {
+	family *family
+}
+
+// NewGauge registers a gauge
+func (r *Registry) NewGauge(name string, help string, labelNames ...string) *Gauge {
+	return &Gauge{family: r.register(name, help, typeGauge, nil, labelNames)}
+}
+
+// Set sets the series with these label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.family.mu.Lock()
+	defer g.family.mu.Unlock()
+
+	g.family.with(labelValues).value = value
+}
+
+// Add adds to the series with these label values, negative to go down
+func (g *Gauge) Add(value float64, labelValues ...string) {
+	g.family.mu.Lock()
+	defer g.family.mu.Unlock()
+
+	g.family.with(labelValues).value += value
+}
+
+// Histogram counts observations into buckets
+type Histogram struct {
+	family *family
+}
+
+// NewHistogram registers a histogram.  Buckets are upper bounds and must
+// be in increasing order; nil means DefaultBuckets.
+func (r *Registry) NewHistogram(name string, help string, buckets []float64, labelNames ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			panic(fmt.Sprintf("metrics: buckets for %s must be increasing", name))
+		}
+	}
+
+	return &Histogram{family: r.register(name, help, typeHistogram, append([]float64(nil), buckets...), labelNames)}
+}
+
+// Observe records a value in the series with these label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.family.mu.Lock()
+	defer h.family.mu.Unlock()
+
+	s := h.family.with(labelValues)
+	bucket := sort.SearchFloat64s(h.family.buckets, value)
+
+	s.counts[bucket]++
+	s.count++
+	s.value += value
+}
+
+// WriteTo writes every metric in the Prometheus text format, sorted by
+// name and then labels so the output is stable
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := make([]*family, 0, len(r.metrics))
+
+	for _, f := range r.metrics {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+
+	var out strings.Builder
+
+	for _, f := range families {
+		f.write(&out)
+	}
+
+	n, err := io.WriteString(w, out.String())
+
+	return int64(n), err
+}
+
+// ServeHTTP serves the metrics for Prometheus to scrape
+func (r *Registry) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	_, _ = r.WriteTo(res)
+}
+
+func (f *family) write(out *strings.Builder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintf(out, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(out, "# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+
+		if f.kind != typeHistogram {
+			fmt.Fprintf(out, "%s%s %s\n", f.name, f.labels(s.labelValues, ""), formatFloat(s.value))
+			continue
+		}
+
+		var cumulative uint64
+
+		for i, upper := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(out, "%s_bucket%s %d\n", f.name, f.labels(s.labelValues, formatFloat(upper)), cumulative)
+		}
+
+		fmt.Fprintf(out, "%s_bucket%s %d\n", f.name, f.labels(s.labelValues, "+Inf"), s.count)
+		fmt.Fprintf(out, "%s_sum%s %s\n", f.name, f.labels(s.labelValues, ""), formatFloat(s.value))
+		fmt.Fprintf(out, "%s_count%s %d\n", f.name, f.labels(s.labelValues, ""), s.count)
+	}
+}
+
+// labels renders {a="1",b="2"}, with le on the end for histogram buckets
+func (f *family) labels(values []string, le string) string {
+	var pairs []string
+
+	for i, name := range f.labelNames {
+		pairs = append(pairs, name+`="`+escapeLabel(values[i])+`"`)
+	}
+
+	if le != "" {
+		pairs = append(pairs, `le="`+le+`"`)
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/decorate"
+)
+
+func scrape(t *testing.T, registry *Registry) string {
+	t.Helper()
+
+	res := httptest.NewRecorder()
+	registry.ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+
+	if contentType := res.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Expected the Prometheus content type but got %q", contentType)
+	}
+
+	return res.Body.String()
+}
+
+func TestExpositionFormat(t *testing.T) {
+	registry := NewRegistry()
+
+	counter := registry.NewCounter("things_total", "Things that happened.", "kind")
+	gauge := registry.NewGauge("queue_depth", "How much is waiting.")
+	histogram := registry.NewHistogram("wait_seconds", "How long things waited.", []float64{0.1, 1}, "queue")
+
+	counter.Inc("b")
+	counter.Add(2.5, "a")
+	counter.Inc("b")
+	gauge.Set(3)
+	gauge.Add(-1)
+	histogram.Observe(0.05, "main")
+	histogram.Observe(0.5, "main")
+	histogram.Observe(0.1, "main")
+	histogram.Observe(7, "main")
+
+	expected := `# HELP queue_depth How much is waiting.
+# TYPE queue_depth gauge
+queue_depth 2
+# HELP things_total Things that happened.
+# TYPE things_total counter
+things_total{kind="a"} 2.5
+things_total{kind="b"} 2
+# HELP wait_seconds How long things waited.
+# TYPE wait_seconds histogram
+wait_seconds_bucket{queue="main",le="0.1"} 2
+wait_seconds_bucket{queue="main",le="1"} 3
+wait_seconds_bucket{queue="main",le="+Inf"} 4
+wait_seconds_sum{queue="main"} 7.65
+wait_seconds_count{queue="main"} 4
+`
+
+	if got := scrape(t, registry); got != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, got)
+	}
+}
+
+func TestLabelValuesAreEscaped(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounter("escapes_total", "Help with a \\ backslash\nand a newline.", "value")
+
+	counter.Inc("a \"quoted\" \\ value\nover two lines")
+
+	got := scrape(t, registry)
+
+	for _, expected := range []string{
+		`# HELP escapes_total Help with a \\ backslash\nand a newline.`,
+		`escapes_total{value="a \"quoted\" \\ value\nover two lines"} 1`,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("Expected output to contain %s but got:\n%s", expected, got)
+		}
+	}
+}
+
+func TestMistakesPanic(t *testing.T) {
+	tests := map[string]func(registry *Registry){
+		"BadName":          func(r *Registry) { r.NewCounter("no-dashes", "") },
+		"BadLabel":         func(r *Registry) { r.NewCounter("ok_total", "", "le") },
+		"Twice":            func(r *Registry) { r.NewGauge("twice", ""); r.NewGauge("twice", "") },
+		"WrongLabelCount":  func(r *Registry) { r.NewCounter("ok_total", "", "a").Inc() },
+		"CounterGoingDown": func(r *Registry) { r.NewCounter("ok_total", "").Add(-1) },
+		"UnsortedBuckets":  func(r *Registry) { r.NewHistogram("ok_seconds", "", []float64{1, 0.5}) },
+	}
+
+	for name, mistake := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected a panic")
+				}
+			}()
+
+			mistake(NewRegistry())
+		})
+	}
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	registry := NewRegistry()
+	httpMetrics := NewHTTPMetrics(registry)
+
+	httpMetrics.ObserveRequest("/champion", "GET", 200, 3*time.Millisecond)
+	httpMetrics.ObserveRequest("/champion", "GET", 200, 30*time.Millisecond)
+	httpMetrics.ObserveRequest("/champion", "PUT", 401, time.Millisecond)
+	httpMetrics.ObserveRequest("/champion", "BREW", 405, time.Millisecond)
+	httpMetrics.ObserveRequest("/champion", "WHEN", 405, time.Millisecond)
+
+	got := scrape(t, registry)
+
+	for _, expected := range []string{
+		`http_requests_total{route="/champion",method="GET",status="200"} 2`,
+		`http_requests_total{route="/champion",method="PUT",status="401"} 1`,
+		`http_requests_total{route="/champion",method="other",status="405"} 2`,
+		`http_request_duration_seconds_bucket{route="/champion",method="GET",status="200",le="0.005"} 1`,
+		`http_request_duration_seconds_count{route="/champion",method="GET",status="200"} 2`,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("Expected output to contain %s but got:\n%s", expected, got)
+		}
+	}
+}
+
+func TestCallMetricsMiddleware(t *testing.T) {
+	registry := NewRegistry()
+	middleware := NewCallMetrics(registry, "store").Middleware()
+	call := decorate.Call{Interface: "UserDataStore", Method: "GetUserScore"}
+
+	for _, err := range []error{nil, errors.New("oh no"), nil} {
+		_ = middleware(context.Background(), call, func(ctx context.Context) error { return err })
+	}
+
+	got := scrape(t, registry)
+
+	for _, expected := range []string{
+		`store_calls_total{interface="UserDataStore",method="GetUserScore"} 3`,
+		`store_call_errors_total{interface="UserDataStore",method="GetUserScore"} 1`,
+		`store_call_duration_seconds_count{interface="UserDataStore",method="GetUserScore"} 3`,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("Expected output to contain %s but got:\n%s", expected, got)
+		}
+	}
+}
+
+func TestNotificationMetrics(t *testing.T) {
+	registry := NewRegistry()
+	notificationMetrics := NewNotificationMetrics(registry)
+
+	notificationMetrics.ObserveNotification("webhook", nil)
+	notificationMetrics.ObserveNotification("webhook", nil)
+	notificationMetrics.ObserveNotification("webhook", errors.New("410 Gone"))
+
+	got := scrape(t, registry)
+
+	for _, expected := range []string{
+		`notifications_sent_total{channel="webhook"} 2`,
+		`notifications_failed_total{channel="webhook"} 1`,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("Expected output to contain %s but got:\n%s", expected, got)
+		}
+	}
+}
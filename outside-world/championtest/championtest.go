@@ -0,0 +1,167 @@
+// Package championtest checks that a CurrentChampionGetter behaves the way
+// the GSL server expects.
+//
+// Every implementation gets the same treatment: what happens when the
+// champion is there, when it isn't, when it's blank, when it has a stray
+// newline on the end, when lots of people ask at once, and when nobody
+// wants the answer anymore.  Handlers are tested with mocks that assume all
+// of this, so this is where we check the real things actually do it.
+//
+// Use it from a test in the package that has the implementation:
+//
+//	func TestGSLDataStoreConforms(t *testing.T) {
+//		championtest.Run(t, func(t *testing.T, fixture championtest.Fixture) championtest.CurrentChampionGetter {
+//			// Write fixture.Contents to a temp file, unless fixture.Missing
+//			return NewGSLDataStore(filename)
+//		})
+//	}
+package championtest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// CurrentChampionGetter is the same shape as the GSL server's interface.
+// It's repeated here so this package doesn't have to import a main package.
+type CurrentChampionGetter interface {
+	GetCurrentChampion(ctx context.Context) (string, error)
+}
+
+// Fixture describes what the getter's source should hold
+type Fixture struct {
+	// Contents is exactly what the source should contain, whitespace and all
+	Contents string
+
+	// Missing means the source shouldn't exist at all, like a file that
+	// was never written or a server that returns 404
+	Missing bool
+}
+
+// NewGetter sets up a source as described by the fixture and returns a
+// getter that reads from it.  It should use t for cleanup and fail the test
+// itself if setup goes wrong.
+type NewGetter func(t *testing.T, fixture Fixture) CurrentChampionGetter
+
+// concurrentCallers is how many goroutines ask at once in the concurrency
+// check.  Getters mostly just read, so what this catches is shared state
+// like caches, and only when the tests run with -race.
+const concurrentCallers = 50
+
+// Run checks the getter against every part of the contract as subtests
+func Run(t *testing.T, newGetter NewGetter) {
+	t.Run("ReturnsChampion", func(t *testing.T) {
+		getter := newGetter(t, Fixture{Contents: "TY"})
+
+		assertChampion(t, getter, "TY")
+	})
+
+	t.Run("TrimsTrailingWhitespace", func(t *testing.T) {
+		getter := newGetter(t, Fixture{Contents: "TY\r\n \t\n"})
+
+		assertChampion(t, getter, "TY")
+	})
+
+	t.Run("ErrorsWhenSourceIsMissing", func(t *testing.T) {
+		getter := newGetter(t, Fixture{Missing: true})
+
+		assertError(t, getter)
+	})
+
+	t.Run("ErrorsWhenEmpty", func(t *testing.T) {
+		getter := newGetter(t, Fixture{Contents: ""})
+
+		assertError(t, getter)
+	})
+
+	t.Run("ErrorsWhenOnlyWhitespace", func(t *testing.T) {
+		getter := newGetter(t, Fixture{Contents: " \n"})
+
+		assertError(t, getter)
+	})
+
+	t.Run("IsSafeToCallConcurrently", func(t *testing.T) {
+		getter := newGetter(t, Fixture{Contents: "Maru"})
+
+		var wg sync.WaitGroup
+		errs := make(chan error, concurrentCallers)
+
+		for i := 0; i < concurrentCallers; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				champion, err := getter.GetCurrentChampion(context.Background())
+
+				if err == nil && champion != "Maru" {
+					err = errors.New("got " + champion)
+				}
+
+				if err != nil {
+					errs <- err
+				}
+			}()
+		}
+
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			t.Errorf("Concurrent call failed: %v", err)
+		}
+	})
+
+	t.Run("StopsWhenContextIsCanceled", func(t *testing.T) {
+		getter := newGetter(t, Fixture{Contents: "TY"})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := getter.GetCurrentChampion(ctx)
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected an error wrapping context.Canceled but got %v", err)
+		}
+	})
+
+	t.Run("StopsWhenDeadlinePasses", func(t *testing.T) {
+		getter := newGetter(t, Fixture{Contents: "TY"})
+
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		_, err := getter.GetCurrentChampion(ctx)
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected an error wrapping context.DeadlineExceeded but got %v", err)
+		}
+	})
+}
+
+func assertChampion(t *testing.T, getter CurrentChampionGetter, expected string) {
+	t.Helper()
+
+	champion, err := getter.GetCurrentChampion(context.Background())
+
+	if err != nil {
+		t.Fatalf("GetCurrentChampion returned an error: %v", err)
+	}
+
+	if champion != expected {
+		t.Errorf("Expected champion %q but got %q", expected, champion)
+	}
+}
+
+func assertError(t *testing.T, getter CurrentChampionGetter) {
+	t.Helper()
+
+	champion, err := getter.GetCurrentChampion(context.Background())
+
+	if err == nil {
+		t.Errorf("Expected an error but got champion %q", champion)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Evertras/go-interface-examples/outside-world/championtest"
+)
+
+// The data store here doesn't know about contexts yet, so it gets a thin
+// adapter to be checked like the ones in no-velociraptors
+
+func writeChampionFixture(t *testing.T, fixture championtest.Fixture) string {
+	t.Helper()
+
+	championFile := filepath.Join(t.TempDir(), "champion.txt")
+
+	if fixture.Missing {
+		return championFile
+	}
+
+	err := os.WriteFile(championFile, []byte(fixture.Contents), 0644)
+
+	if err != nil {
+		t.Fatal("os.WriteFile:", err)
+	}
+
+	return championFile
+}
+
+// contextDataStore checks the context before asking, since reading the file
+// can't be interrupted once it's started anyway
+type contextDataStore struct {
+	dataStore *GSLDataStore
+}
+
+func (s contextDataStore) GetCurrentChampion(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return s.dataStore.GetCurrentChampion()
+}
+
+func TestGSLDataStoreConforms(t *testing.T) {
+	championtest.Run(t, func(t *testing.T, fixture championtest.Fixture) championtest.CurrentChampionGetter {
+		return contextDataStore{NewGSLDataStore(writeChampionFixture(t, fixture))}
+	})
+}
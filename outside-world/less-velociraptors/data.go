@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 )
 
 // GSLDataStore knows how to get GSL data
@@ -25,5 +27,13 @@ func (s *GSLDataStore) GetCurrentChampion() (string, error) {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
 
-	return string(contents), nil
+	// Editors love adding a newline at the end, and it's never part
+	// of anyone's handle
+	champion := strings.TrimSpace(string(contents))
+
+	if champion == "" {
+		return "", errors.New("champion file is empty")
+	}
+
+	return champion, nil
 }
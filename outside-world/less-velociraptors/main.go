@@ -1,16 +1,22 @@
 package main
 
 import (
+	"context"
 	"log"
+
+	"github.com/Evertras/go-interface-examples/graceful"
 )
 
 func main() {
+	ctx, stop := graceful.SignalContext(context.Background())
+	defer stop()
+
 	log.Println("Running GSL server on :8080")
 
 	// Now we need to explicitly add our data store, but this is the
 	// perfect place to do some configuration!
 	dataStore := NewGSLDataStore("./champion.txt")
-	err := runServer(":8080", dataStore)
+	err := runServer(ctx, ":8080", dataStore)
 
 	if err != nil {
 		log.Fatal(err)
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net"
 	"net/http"
+
+	"github.com/Evertras/go-interface-examples/graceful"
 )
 
 // Creates a handler that writes the current champion to the client.
@@ -26,15 +30,58 @@ func gslCurrentChampionHandler(dataStore *GSLDataStore) http.HandlerFunc {
 	}
 }
 
-// Runs the server on the specified address with the given data store
+// The process is up and able to answer
+func healthzHandler(res http.ResponseWriter, req *http.Request) {
+	res.Write([]byte("ok"))
+}
+
+// Creates a handler that says whether we can actually serve champions
+//
+// Same trick as the champion handler: we're handed the data store, so we
+// don't need to know it's a file to ask whether it's working.
+func readyzHandler(dataStore *GSLDataStore) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		_, err := dataStore.GetCurrentChampion()
+
+		if err != nil {
+			log.Println("Not ready, failed to get current champion:", err)
+			res.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		res.Write([]byte("ok"))
+	}
+}
+
+// Runs the server on the specified address with the given data store,
+// until ctx is done
 //
 // Notice we added the data store here as a parameter.  To run our server,
 // we must have a data store.  We are NOT creating it here!  That's
 // someone else's problem.  Always make your dependencies explicit.
-func runServer(address string, dataStore *GSLDataStore) error {
+func runServer(ctx context.Context, address string, dataStore *GSLDataStore) error {
+	listener, err := net.Listen("tcp", address)
+
+	if err != nil {
+		return err
+	}
+
+	return serve(ctx, listener, dataStore)
+}
+
+// serve is runServer without picking the address
+func serve(ctx context.Context, listener net.Listener, dataStore *GSLDataStore) error {
+	return graceful.Serve(ctx, &http.Server{Handler: newServerHandler(dataStore)}, listener, graceful.DefaultTimeout)
+}
+
+// newServerHandler decides which URL gets which handler, and hands the data
+// store to the ones that need it
+func newServerHandler(dataStore *GSLDataStore) http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/champion", gslCurrentChampionHandler(dataStore))
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.HandleFunc("/readyz", readyzHandler(dataStore))
 
-	return http.ListenAndServe(address, mux)
+	return mux
 }
@@ -1,25 +1,20 @@
 package main
 
 import (
-	"os"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/Evertras/go-interface-examples/outside-world/championtest"
 )
 
 func TestGSLCurrentChampionIsTY(t *testing.T) {
-	// We still have to do this.  I hate my life.
-	contents, err := os.ReadFile("./champion.txt")
-
-	if err != nil {
-		t.Fatal("Failed to read file:", err)
-	}
-
-	expectedWorldChampion := string(contents)
+	// We still have to make a file.  I hate my life.
+	championFile := writeChampionFixture(t, championtest.Fixture{Contents: "TY"})
 
 	req := httptest.NewRequest("GET", "/champion", nil)
 	res := httptest.NewRecorder()
 
-	dataStore := NewGSLDataStore("champion.txt")
+	dataStore := NewGSLDataStore(championFile)
 
 	handler := gslCurrentChampionHandler(dataStore)
 
@@ -32,8 +27,8 @@ func TestGSLCurrentChampionIsTY(t *testing.T) {
 		t.Errorf("Expected code 200 but got %d", gotCode)
 	}
 
-	if expectedWorldChampion != gotWorldChampion {
-		t.Errorf("Expected world champion to be %q but got %q", expectedWorldChampion, gotWorldChampion)
+	if "TY" != gotWorldChampion {
+		t.Errorf("Expected world champion to be %q but got %q", "TY", gotWorldChampion)
 	}
 }
 
@@ -56,3 +51,33 @@ func TestGSLCurrentChampionReturns500WhenFileDoesntExist(t *testing.T) {
 		t.Errorf("Expected code 500 but got %d", gotCode)
 	}
 }
+
+// Starting and stopping is graceful's job, so what's left is whether
+// readiness follows the data store.  This still needs a real file, but at
+// least we get to pick which.
+func TestServerIsAliveButOnlyReadyWithItsFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		fixture      championtest.Fixture
+		readyzStatus int
+	}{
+		{"Ready", championtest.Fixture{Contents: "TY"}, 200},
+		{"FileMissing", championtest.Fixture{Missing: true}, 503},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := newServerHandler(NewGSLDataStore(writeChampionFixture(t, test.fixture)))
+
+			for path, expected := range map[string]int{"/healthz": 200, "/readyz": test.readyzStatus} {
+				res := httptest.NewRecorder()
+
+				handler.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+
+				if res.Code != expected {
+					t.Errorf("Expected GET %s to be %d but got %d", path, expected, res.Code)
+				}
+			}
+		})
+	}
+}
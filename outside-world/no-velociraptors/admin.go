@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CurrentChampionSetter can crown a new champion
+//
+// Just like CurrentChampionGetter, this is all the update handler gets to
+// know about.  It can't read history, it can't delete anything, it can only
+// set the champion.  If the champion changed since the caller last looked,
+// it returns ErrChampionChanged.
+type CurrentChampionSetter interface {
+	SetCurrentChampion(ctx context.Context, champion string, expectedETags []string) (ChampionUpdate, error)
+}
+
+// ChampionChangeAuditor keeps track of who changed the champion
+type ChampionChangeAuditor interface {
+	RecordChampionChange(ctx context.Context, entry ChampionAuditEntry) error
+}
+
+// maxChampionBytes is more than enough for any player's handle
+const maxChampionBytes = 256
+
+type adminContextKey struct{}
+
+// adminFromContext returns the name of the admin making the request, if any
+func adminFromContext(ctx context.Context) string {
+	admin, _ := ctx.Value(adminContextKey{}).(string)
+
+	return admin
+}
+
+// requireAdmin only lets requests through that carry a known bearer token
+//
+// adminTokens maps each token to the name of the admin who owns it, so we
+// know who to blame in the audit log.
+func requireAdmin(adminTokens map[string]string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		admin, ok := authenticateAdmin(adminTokens, req.Header.Get("Authorization"))
+
+		if !ok {
+			res.Header().Set("WWW-Authenticate", `Bearer realm="gsl"`)
+			res.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(req.Context(), adminContextKey{}, admin)
+
+		next.ServeHTTP(res, req.WithContext(ctx))
+	})
+}
+
+// adminPrincipal says which admin sent a request, or nobody if it doesn't
+// carry a known token
+func adminPrincipal(adminTokens map[string]string) func(req *http.Request) string {
+	return func(req *http.Request) string {
+		admin, _ := authenticateAdmin(adminTokens, req.Header.Get("Authorization"))
+
+		return admin
+	}
+}
+
+func authenticateAdmin(adminTokens map[string]string, authorization string) (string, bool) {
+	const prefix = "Bearer "
+
+	if len(authorization) <= len(prefix) || !strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	presented := []byte(authorization[len(prefix):])
+
+	// Check every token in constant time so response times don't hint at
+	// how close a guess was
+	admin := ""
+
+	for token, name := range adminTokens {
+		if subtle.ConstantTimeCompare([]byte(token), presented) == 1 {
+			admin = name
+		}
+	}
+
+	return admin, admin != ""
+}
+
+// parseAdminTokens reads admin tokens in the form "name:token,name:token"
+func parseAdminTokens(raw string) (map[string]string, error) {
+	tokens := make(map[string]string)
+
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, ":", 2)
+
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("admin token %q should look like name:token", pair)
+		}
+
+		tokens[parts[1]] = parts[0]
+	}
+
+	return tokens, nil
+}
+
+// Creates a handler that crowns a new champion
+//
+// The request body is the new champion's name.  Send the ETag you last saw
+// in If-Match and we'll refuse with a 412 if someone beat you to it.
+// Leaving If-Match out gets a 428, so nobody overwrites a champion they
+// never looked at by accident.  Send If-Match: * to crown someone no
+// matter who's there now.  A list of ETags works if any of them is the
+// current one.
+func gslUpdateChampionHandler(currentChampionSetter CurrentChampionSetter, championAuditor ChampionChangeAuditor, logger *slog.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxChampionBytes+1))
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "Failed to read request body", "error", err)
+			res.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		champion := strings.TrimSpace(string(body))
+
+		if champion == "" || len(champion) > maxChampionBytes || strings.ContainsAny(champion, "\r\n") {
+			res.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ifMatch := strings.TrimSpace(req.Header.Get("If-Match"))
+
+		if ifMatch == "" {
+			res.WriteHeader(http.StatusPreconditionRequired)
+			res.Write([]byte("send If-Match with the ETag you last saw, or * to overwrite anyway"))
+			return
+		}
+
+		expectedETags, ok := parseETagList(ifMatch)
+
+		if !ok {
+			http.Error(res, "If-Match should be * or a list of quoted ETags", http.StatusBadRequest)
+			return
+		}
+
+		ctx := req.Context()
+
+		// Whichever representation's ETag the client saw, they all refer to
+		// the same champion
+		for i, etag := range expectedETags {
+			expectedETags[i] = resourceETag(etag)
+		}
+
+		update, err := currentChampionSetter.SetCurrentChampion(ctx, champion, expectedETags)
+
+		if errors.Is(err, ErrChampionChanged) {
+			res.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "Failed to set current champion", "error", err)
+			writeDataStoreError(res, err)
+			return
+		}
+
+		err = championAuditor.RecordChampionChange(ctx, ChampionAuditEntry{
+			Time:     time.Now().UTC(),
+			Actor:    adminFromContext(ctx),
+			Previous: update.Previous,
+			Champion: update.Champion,
+		})
+
+		// The champion has already changed at this point and we can't take
+		// it back, so the best we can do is make a lot of noise about it
+		if err != nil {
+			logger.ErrorContext(ctx, "AUDIT FAILURE: champion changed but it wasn't recorded",
+				"admin", adminFromContext(ctx), "previous", update.Previous, "champion", update.Champion, "error", err)
+		}
+
+		res.Header().Set("ETag", update.ETag)
+		res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		res.Write([]byte(update.Champion))
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/idempotency"
+)
+
+type mockCurrentChampionSetter struct {
+	current      string
+	pendingError error
+
+	setChampions  []string
+	expectedETags [][]string
+}
+
+func (s *mockCurrentChampionSetter) SetCurrentChampion(ctx context.Context, champion string, expectedETags []string) (ChampionUpdate, error) {
+	if s.pendingError != nil {
+		return ChampionUpdate{}, s.pendingError
+	}
+
+	s.setChampions = append(s.setChampions, champion)
+	s.expectedETags = append(s.expectedETags, expectedETags)
+
+	update := ChampionUpdate{
+		Previous: s.current,
+		Champion: champion,
+		ETag:     `"` + champion + `"`,
+	}
+
+	s.current = champion
+
+	return update, nil
+}
+
+type mockChampionChangeAuditor struct {
+	pendingError error
+
+	recorded []ChampionAuditEntry
+}
+
+func (a *mockChampionChangeAuditor) RecordChampionChange(ctx context.Context, entry ChampionAuditEntry) error {
+	if a.pendingError != nil {
+		return a.pendingError
+	}
+
+	a.recorded = append(a.recorded, entry)
+
+	return nil
+}
+
+func serveAdminRequest(deps serverDependencies, token string, body string, ifMatch string) *httptest.ResponseRecorder {
+	config := serverConfig{
+		requestTimeout: defaultRequestTimeout,
+		adminTokens: map[string]string{
+			"secret-token": "evertras",
+		},
+	}
+
+	req := httptest.NewRequest("PUT", "/champion", bytes.NewBufferString(body))
+	res := httptest.NewRecorder()
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+
+	newServerHandler(config, deps).ServeHTTP(res, req)
+
+	return res
+}
+
+func TestUpdateChampionRequiresAdminToken(t *testing.T) {
+	setter := &mockCurrentChampionSetter{}
+	deps := serverDependencies{
+		logger:                testLogger,
+		currentChampionSetter: setter,
+		championAuditor:       &mockChampionChangeAuditor{},
+	}
+
+	for _, token := range []string{"", "wrong-token"} {
+		res := serveAdminRequest(deps, token, "Maru", "")
+
+		if res.Code != 401 {
+			t.Errorf("Expected code 401 with token %q but got %d", token, res.Code)
+		}
+	}
+
+	if len(setter.setChampions) != 0 {
+		t.Errorf("Expected no champions to be set but %d were", len(setter.setChampions))
+	}
+}
+
+func TestUpdateChampionSetsChampionAndRecordsWhoDidIt(t *testing.T) {
+	setter := &mockCurrentChampionSetter{current: "TY"}
+	auditor := &mockChampionChangeAuditor{}
+	deps := serverDependencies{
+		logger:                testLogger,
+		currentChampionSetter: setter,
+		championAuditor:       auditor,
+	}
+
+	res := serveAdminRequest(deps, "secret-token", "Maru\n", `"abc"`)
+
+	if res.Code != 200 {
+		t.Fatalf("Expected code 200 but got %d", res.Code)
+	}
+
+	if len(setter.setChampions) != 1 || setter.setChampions[0] != "Maru" {
+		t.Fatalf("Expected champion to be set to Maru but got %v", setter.setChampions)
+	}
+
+	if len(setter.expectedETags[0]) != 1 || setter.expectedETags[0][0] != `"abc"` {
+		t.Errorf("Expected If-Match to be passed through but got %q", setter.expectedETags[0])
+	}
+
+	if res.Header().Get("ETag") != `"Maru"` {
+		t.Errorf("Expected new ETag in response but got %q", res.Header().Get("ETag"))
+	}
+
+	if len(auditor.recorded) != 1 {
+		t.Fatalf("Expected %d audit entries but got %d", 1, len(auditor.recorded))
+	}
+
+	entry := auditor.recorded[0]
+
+	if entry.Actor != "evertras" || entry.Previous != "TY" || entry.Champion != "Maru" {
+		t.Errorf("Unexpected audit entry %+v", entry)
+	}
+}
+
+func TestRetriedUpdateChampionIsReplayed(t *testing.T) {
+	setter := &mockCurrentChampionSetter{current: "TY"}
+	auditor := &mockChampionChangeAuditor{}
+	adminTokens := map[string]string{"secret-token": "evertras"}
+
+	server := newServerHandler(serverConfig{
+		requestTimeout: defaultRequestTimeout,
+		adminTokens:    adminTokens,
+	}, serverDependencies{
+		logger:                testLogger,
+		currentChampionSetter: setter,
+		championAuditor:       auditor,
+		requestDeduplicator:   idempotency.New(time.Hour, adminPrincipal(adminTokens)),
+	})
+
+	update := func(champion string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/champion", bytes.NewBufferString(champion))
+		req.Header.Set("Authorization", "Bearer secret-token")
+		req.Header.Set("If-Match", "*")
+		req.Header.Set(idempotency.Header, "crown-maru")
+		res := httptest.NewRecorder()
+
+		server.ServeHTTP(res, req)
+
+		return res
+	}
+
+	first := update("Maru")
+	retried := update("Maru")
+
+	if retried.Code != 200 || retried.Header().Get("ETag") != first.Header().Get("ETag") || retried.Body.String() != "Maru" {
+		t.Errorf("Expected the first response replayed but got %d %q with ETag %q", retried.Code, retried.Body.String(), retried.Header().Get("ETag"))
+	}
+
+	if len(setter.setChampions) != 1 || len(auditor.recorded) != 1 {
+		t.Errorf("Expected one update and one audit entry but got %v and %d", setter.setChampions, len(auditor.recorded))
+	}
+
+	if res := update("Rogue"); res.Code != 422 {
+		t.Errorf("Expected 422 for the same key with another champion but got %d", res.Code)
+	}
+}
+
+func TestUpdateChampionReturns412WhenChampionChanged(t *testing.T) {
+	auditor := &mockChampionChangeAuditor{}
+	deps := serverDependencies{
+		logger:                testLogger,
+		currentChampionSetter: &mockCurrentChampionSetter{pendingError: ErrChampionChanged},
+		championAuditor:       auditor,
+	}
+
+	res := serveAdminRequest(deps, "secret-token", "Maru", `"stale"`)
+
+	if res.Code != 412 {
+		t.Errorf("Expected code 412 but got %d", res.Code)
+	}
+
+	if len(auditor.recorded) != 0 {
+		t.Errorf("Expected nothing to be audited but got %d entries", len(auditor.recorded))
+	}
+}
+
+func TestUpdateChampionReturns428WithoutIfMatch(t *testing.T) {
+	setter := &mockCurrentChampionSetter{current: "TY"}
+	deps := serverDependencies{
+		logger:                testLogger,
+		currentChampionSetter: setter,
+		championAuditor:       &mockChampionChangeAuditor{},
+	}
+
+	res := serveAdminRequest(deps, "secret-token", "Maru", "")
+
+	if res.Code != 428 {
+		t.Errorf("Expected code 428 but got %d", res.Code)
+	}
+
+	if len(setter.setChampions) != 0 {
+		t.Errorf("Expected the champion left alone but got %v", setter.setChampions)
+	}
+}
+
+func TestUpdateChampionPassesOnEveryTagInIfMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		ifMatch  string
+		expected []string
+	}{
+		{"Wildcard", "*", []string{"*"}},
+		{"List", `"abc", "def-json"`, []string{`"abc"`, `"def"`}},
+		{"CommaInsideTag", `"a,b"`, []string{`"a,b"`}},
+		{"Weak", `W/"abc"`, []string{`W/"abc"`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setter := &mockCurrentChampionSetter{current: "TY"}
+			deps := serverDependencies{
+				logger:                testLogger,
+				currentChampionSetter: setter,
+				championAuditor:       &mockChampionChangeAuditor{},
+			}
+
+			res := serveAdminRequest(deps, "secret-token", "Maru", test.ifMatch)
+
+			if res.Code != 200 {
+				t.Fatalf("Expected code 200 but got %d", res.Code)
+			}
+
+			if !reflect.DeepEqual(setter.expectedETags[0], test.expected) {
+				t.Errorf("Expected ETags %q but got %q", test.expected, setter.expectedETags[0])
+			}
+		})
+	}
+}
+
+func TestUpdateChampionRejectsMalformedIfMatch(t *testing.T) {
+	for _, ifMatch := range []string{"abc", `*, "abc"`, `"abc`, `"abc" "def"`, ","} {
+		setter := &mockCurrentChampionSetter{current: "TY"}
+		deps := serverDependencies{
+			logger:                testLogger,
+			currentChampionSetter: setter,
+			championAuditor:       &mockChampionChangeAuditor{},
+		}
+
+		res := serveAdminRequest(deps, "secret-token", "Maru", ifMatch)
+
+		if res.Code != 400 {
+			t.Errorf("Expected code 400 for If-Match %q but got %d", ifMatch, res.Code)
+		}
+
+		if len(setter.setChampions) != 0 {
+			t.Errorf("Expected the champion left alone for If-Match %q but got %v", ifMatch, setter.setChampions)
+		}
+	}
+}
+
+func TestUpdateChampionRejectsEmptyChampion(t *testing.T) {
+	deps := serverDependencies{
+		logger:                testLogger,
+		currentChampionSetter: &mockCurrentChampionSetter{},
+		championAuditor:       &mockChampionChangeAuditor{},
+	}
+
+	res := serveAdminRequest(deps, "secret-token", "  \n", "")
+
+	if res.Code != 400 {
+		t.Errorf("Expected code 400 but got %d", res.Code)
+	}
+}
+
+func TestChampionRejectsUnknownMethods(t *testing.T) {
+	req := httptest.NewRequest("DELETE", "/champion", nil)
+	res := httptest.NewRecorder()
+
+	newServerHandler(serverConfig{requestTimeout: defaultRequestTimeout}, serverDependencies{logger: testLogger}).ServeHTTP(res, req)
+
+	if res.Code != 405 {
+		t.Errorf("Expected code 405 but got %d", res.Code)
+	}
+
+	if res.Header().Get("Allow") != "GET, PUT" {
+		t.Errorf("Expected Allow header %q but got %q", "GET, PUT", res.Header().Get("Allow"))
+	}
+}
+
+func TestParseAdminTokens(t *testing.T) {
+	tokens, err := parseAdminTokens("evertras:abc, someone:def")
+
+	if err != nil {
+		t.Fatal("parseAdminTokens:", err)
+	}
+
+	if tokens["abc"] != "evertras" || tokens["def"] != "someone" {
+		t.Errorf("Unexpected tokens %v", tokens)
+	}
+
+	_, err = parseAdminTokens("no-token-here")
+
+	if err == nil {
+		t.Error("Expected an error for a malformed token but didn't get one")
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ChampionAuditEntry records who changed the champion and when
+type ChampionAuditEntry struct {
+	Time     time.Time `json:"time"`
+	Actor    string    `json:"actor"`
+	Previous string    `json:"previous"`
+	Champion string    `json:"champion"`
+}
+
+// FileAuditLog appends audit entries to a file, one JSON object per line
+//
+// We only ever append.  Nothing in here can edit or remove an entry once
+// it's written, which is the whole point of an audit log.
+type FileAuditLog struct {
+	filename string
+
+	mu sync.Mutex
+}
+
+// NewFileAuditLog returns a FileAuditLog that appends to the given file,
+// creating it if it doesn't exist yet
+func NewFileAuditLog(filename string) *FileAuditLog {
+	return &FileAuditLog{
+		filename: filename,
+	}
+}
+
+// RecordChampionChange appends an entry to the audit log
+func (l *FileAuditLog) RecordChampionChange(ctx context.Context, entry ChampionAuditEntry) error {
+	line, err := json.Marshal(entry)
+
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	_, err = f.Write(line)
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// GetChampionHistory returns up to limit audit entries, newest first.  A
+// limit of zero or less returns everything.
+func (l *FileAuditLog) GetChampionHistory(ctx context.Context, limit int) ([]ChampionAuditEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.filename)
+
+	if errors.Is(err, os.ErrNotExist) {
+		// Nobody's changed anything yet, that's fine
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	defer f.Close()
+
+	var entries []ChampionAuditEntry
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		var entry ChampionAuditEntry
+
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse audit log line %d: %w", len(entries)+1, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	// The file is oldest first, so flip it
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries, nil
+}
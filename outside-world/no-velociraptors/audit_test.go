@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileAuditLogAppendsEntries(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	auditLog := NewFileAuditLog(filename)
+
+	entries := []ChampionAuditEntry{
+		{
+			Time:     time.Date(2020, 7, 18, 0, 0, 0, 0, time.UTC),
+			Actor:    "evertras",
+			Previous: "Rogue",
+			Champion: "TY",
+		},
+		{
+			Time:     time.Date(2020, 10, 3, 0, 0, 0, 0, time.UTC),
+			Actor:    "someone",
+			Previous: "TY",
+			Champion: "Rogue",
+		},
+	}
+
+	for _, entry := range entries {
+		err := auditLog.RecordChampionChange(context.Background(), entry)
+
+		if err != nil {
+			t.Fatal("auditLog.RecordChampionChange:", err)
+		}
+	}
+
+	f, err := os.Open(filename)
+
+	if err != nil {
+		t.Fatal("os.Open:", err)
+	}
+
+	defer f.Close()
+
+	var got []ChampionAuditEntry
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		var entry ChampionAuditEntry
+
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+
+		if err != nil {
+			t.Fatal("json.Unmarshal:", err)
+		}
+
+		got = append(got, entry)
+	}
+
+	if len(got) != len(entries) {
+		t.Fatalf("Expected %d entries but found %d", len(entries), len(got))
+	}
+
+	for i := range entries {
+		if got[i] != entries[i] {
+			t.Errorf("Expected entry %d to be %+v but got %+v", i, entries[i], got[i])
+		}
+	}
+}
+
+func TestFileAuditLogHistoryIsNewestFirst(t *testing.T) {
+	auditLog := NewFileAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	ctx := context.Background()
+
+	history, err := auditLog.GetChampionHistory(ctx, 0)
+
+	if err != nil || len(history) != 0 {
+		t.Fatalf("Expected empty history for a missing log but got %v, %v", history, err)
+	}
+
+	for _, champion := range []string{"TY", "Rogue", "Maru"} {
+		err := auditLog.RecordChampionChange(ctx, ChampionAuditEntry{Champion: champion})
+
+		if err != nil {
+			t.Fatal("auditLog.RecordChampionChange:", err)
+		}
+	}
+
+	history, err = auditLog.GetChampionHistory(ctx, 2)
+
+	if err != nil {
+		t.Fatal("auditLog.GetChampionHistory:", err)
+	}
+
+	if len(history) != 2 || history[0].Champion != "Maru" || history[1].Champion != "Rogue" {
+		t.Errorf("Expected Maru then Rogue but got %+v", history)
+	}
+}
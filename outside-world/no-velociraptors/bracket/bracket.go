@@ -0,0 +1,535 @@
+// Package bracket models GSL style tournaments: who plays who, who won
+// each map, and who comes out the other end as champion.
+//
+// A tournament is just a list of matches.  Each match has two slots, and
+// each slot says where its player comes from: either a player seeded
+// straight in, or the winner or loser of some earlier match.  That's enough
+// to describe single elimination, double elimination and the dual
+// tournament groups the GSL uses, and to chain them all together.
+package bracket
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrMatchNotFound means there's no match with that ID in the tournament
+	ErrMatchNotFound = errors.New("match not found")
+
+	// ErrPlayersUndecided means we don't know who's playing the match yet
+	ErrPlayersUndecided = errors.New("players for this match aren't decided yet")
+
+	// ErrInvalidResult means the map results don't make sense for the match
+	ErrInvalidResult = errors.New("invalid match result")
+
+	// ErrResultLocked means later matches already depend on this result,
+	// so changing it would rewrite history
+	ErrResultLocked = errors.New("later matches already depend on this result")
+)
+
+// Format describes how a stage is played
+type Format string
+
+const (
+	// SingleElimination knocks you out after one loss
+	SingleElimination Format = "single-elimination"
+
+	// DoubleElimination knocks you out after two losses
+	DoubleElimination Format = "double-elimination"
+
+	// DualTournament is the four player GSL group: two opening matches, a
+	// winners' match, a losers' match, and a decider for second place
+	DualTournament Format = "dual-tournament"
+)
+
+// Source says where one of a match's players comes from.  Exactly one of
+// the fields should be set.
+type Source struct {
+	Player   string `json:"player,omitempty"`
+	WinnerOf string `json:"winnerOf,omitempty"`
+	LoserOf  string `json:"loserOf,omitempty"`
+}
+
+// Seed places a player directly into a match
+func Seed(player string) Source {
+	return Source{Player: player}
+}
+
+// WinnerOf sends the winner of another match into this one
+func WinnerOf(matchID string) Source {
+	return Source{WinnerOf: matchID}
+}
+
+// LoserOf sends the loser of another match into this one
+func LoserOf(matchID string) Source {
+	return Source{LoserOf: matchID}
+}
+
+// MapResult is who won a single map
+type MapResult struct {
+	Map    string `json:"map"`
+	Winner string `json:"winner"`
+}
+
+// Match is a best-of-N series between two players
+type Match struct {
+	ID     string      `json:"id"`
+	Round  string      `json:"round,omitempty"`
+	BestOf int         `json:"bestOf"`
+	Slots  [2]Source   `json:"slots"`
+	Maps   []MapResult `json:"maps,omitempty"`
+}
+
+// Stage is a group of matches played in one format, like "Group A" or
+// "Playoffs"
+type Stage struct {
+	Name    string  `json:"name"`
+	Format  Format  `json:"format"`
+	Matches []Match `json:"matches"`
+}
+
+// Tournament is a whole season, from the first group to the final
+type Tournament struct {
+	ID     string    `json:"id"`
+	Name   string    `json:"name"`
+	Date   time.Time `json:"date"`
+	Stages []Stage   `json:"stages"`
+
+	// Final is the ID of the match that decides the champion
+	Final string `json:"final"`
+
+	// index is where each match sits, built by Validate.  It's only ever
+	// replaced, never changed, so clones can share it.
+	index map[string]position
+}
+
+// position is where a match sits in a tournament
+type position struct {
+	stage int
+	match int
+}
+
+// resolved is a match with its players worked out from the matches
+// before it
+type resolved struct {
+	p1     string
+	p2     string
+	s1     int
+	s2     int
+	winner string
+	loser  string
+	done   bool
+}
+
+// Validate checks that the tournament hangs together.  Matches may only
+// take players from matches that come before them, which keeps the whole
+// thing free of cycles.
+//
+// It also remembers where every match is, so finding one later doesn't
+// mean looking through them all.  Like recording results, that makes it
+// something to do before sharing the tournament between goroutines.
+func (t *Tournament) Validate() error {
+	seen := make(map[string]bool)
+	index := make(map[string]position)
+
+	for i, stage := range t.Stages {
+		for j, match := range stage.Matches {
+			if match.ID == "" {
+				return fmt.Errorf("stage %q has a match without an ID", stage.Name)
+			}
+
+			if seen[match.ID] {
+				return fmt.Errorf("match %q appears more than once", match.ID)
+			}
+
+			if match.BestOf < 1 || match.BestOf%2 == 0 {
+				return fmt.Errorf("match %q must be best of an odd number, not %d", match.ID, match.BestOf)
+			}
+
+			for _, slot := range match.Slots {
+				err := validateSource(slot, seen)
+
+				if err != nil {
+					return fmt.Errorf("match %q: %w", match.ID, err)
+				}
+			}
+
+			seen[match.ID] = true
+			index[match.ID] = position{i, j}
+		}
+	}
+
+	if !seen[t.Final] {
+		return fmt.Errorf("final match %q doesn't exist", t.Final)
+	}
+
+	t.index = index
+	results := t.resolveAll()
+
+	for _, stage := range t.Stages {
+		for _, match := range stage.Matches {
+			if len(match.Maps) == 0 {
+				continue
+			}
+
+			r := results[match.ID]
+
+			err := checkResult(&match, r.p1, r.p2, match.Maps)
+
+			if err != nil {
+				return fmt.Errorf("match %q: %w", match.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateSource(source Source, earlier map[string]bool) error {
+	set := 0
+
+	for _, field := range []string{source.Player, source.WinnerOf, source.LoserOf} {
+		if field != "" {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return errors.New("each slot needs exactly one of player, winnerOf or loserOf")
+	}
+
+	for _, ref := range []string{source.WinnerOf, source.LoserOf} {
+		if ref != "" && !earlier[ref] {
+			return fmt.Errorf("slot refers to %q, which isn't an earlier match", ref)
+		}
+	}
+
+	return nil
+}
+
+// Match returns the match with the given ID
+func (t *Tournament) Match(id string) (*Match, error) {
+	pos, ok := t.find(id)
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrMatchNotFound, id)
+	}
+
+	return &t.Stages[pos.stage].Matches[pos.match], nil
+}
+
+// find looks the match up in the index, and falls back to looking through
+// every match if the tournament was never validated or has changed shape
+// since
+func (t *Tournament) find(id string) (position, bool) {
+	if pos, ok := t.index[id]; ok && pos.stage < len(t.Stages) {
+		matches := t.Stages[pos.stage].Matches
+
+		if pos.match < len(matches) && matches[pos.match].ID == id {
+			return pos, true
+		}
+	}
+
+	for i := range t.Stages {
+		for j := range t.Stages[i].Matches {
+			if t.Stages[i].Matches[j].ID == id {
+				return position{i, j}, true
+			}
+		}
+	}
+
+	return position{}, false
+}
+
+// resolveThrough works out every match in bracket order, stopping after
+// the one at last.  Slots only take players from earlier matches, so by
+// the time we get to a match everything it depends on is already known.
+func (t *Tournament) resolveThrough(last position) map[string]resolved {
+	results := make(map[string]resolved)
+
+	for i := 0; i <= last.stage && i < len(t.Stages); i++ {
+		for j, match := range t.Stages[i].Matches {
+			if i == last.stage && j > last.match {
+				break
+			}
+
+			results[match.ID] = resolveMatch(&match, results)
+		}
+	}
+
+	return results
+}
+
+// resolveAll works out every match in the tournament
+func (t *Tournament) resolveAll() map[string]resolved {
+	return t.resolveThrough(position{len(t.Stages), 0})
+}
+
+// resolveOne works out a single match and everything before it
+func (t *Tournament) resolveOne(id string) (resolved, bool) {
+	pos, ok := t.find(id)
+
+	if !ok {
+		return resolved{}, false
+	}
+
+	return t.resolveThrough(pos)[id], true
+}
+
+func resolveMatch(match *Match, earlier map[string]resolved) resolved {
+	r := resolved{
+		p1: resolveSource(match.Slots[0], earlier),
+		p2: resolveSource(match.Slots[1], earlier),
+	}
+
+	r.s1, r.s2 = score(match.Maps, r.p1, r.p2)
+
+	if r.p1 == "" || r.p2 == "" {
+		return r
+	}
+
+	needed := match.BestOf/2 + 1
+
+	switch {
+	case r.s1 >= needed:
+		r.winner, r.loser, r.done = r.p1, r.p2, true
+	case r.s2 >= needed:
+		r.winner, r.loser, r.done = r.p2, r.p1, true
+	}
+
+	return r
+}
+
+func resolveSource(source Source, earlier map[string]resolved) string {
+	switch {
+	case source.Player != "":
+		return source.Player
+
+	case source.WinnerOf != "":
+		return earlier[source.WinnerOf].winner
+
+	case source.LoserOf != "":
+		return earlier[source.LoserOf].loser
+	}
+
+	return ""
+}
+
+// Players returns who is playing in a match.  Either player is empty if
+// the match that decides them hasn't finished yet.
+func (t *Tournament) Players(id string) (string, string, error) {
+	r, ok := t.resolveOne(id)
+
+	if !ok {
+		return "", "", fmt.Errorf("%w: %q", ErrMatchNotFound, id)
+	}
+
+	return r.p1, r.p2, nil
+}
+
+// Score returns how many maps each player has won so far
+func (t *Tournament) Score(id string) (int, int, error) {
+	r, ok := t.resolveOne(id)
+
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: %q", ErrMatchNotFound, id)
+	}
+
+	return r.s1, r.s2, nil
+}
+
+func score(maps []MapResult, p1 string, p2 string) (int, int) {
+	s1, s2 := 0, 0
+
+	for _, result := range maps {
+		switch result.Winner {
+		case p1:
+			s1++
+		case p2:
+			s2++
+		}
+	}
+
+	return s1, s2
+}
+
+// Winner returns who won a match, if it's over
+func (t *Tournament) Winner(id string) (string, bool) {
+	r, _ := t.resolveOne(id)
+
+	return r.winner, r.done
+}
+
+// Loser returns who lost a match, if it's over
+func (t *Tournament) Loser(id string) (string, bool) {
+	r, _ := t.resolveOne(id)
+
+	return r.loser, r.done
+}
+
+// Champion returns the winner of the final, once there is one
+func (t *Tournament) Champion() (string, bool) {
+	return t.Winner(t.Final)
+}
+
+// Complete reports whether the tournament has a champion
+func (t *Tournament) Complete() bool {
+	_, ok := t.Champion()
+
+	return ok
+}
+
+// RecordResult sets the maps played in a match, replacing whatever was
+// there before.  The results can be partial while a match is still being
+// played, but they can't keep going after someone has already won.
+func (t *Tournament) RecordResult(id string, maps []MapResult) error {
+	match, err := t.Match(id)
+
+	if err != nil {
+		return err
+	}
+
+	r, _ := t.resolveOne(id)
+
+	err = checkResult(match, r.p1, r.p2, maps)
+
+	if err != nil {
+		return err
+	}
+
+	if t.hasDependentResults(id) {
+		return fmt.Errorf("%w: %q", ErrResultLocked, id)
+	}
+
+	match.Maps = append([]MapResult(nil), maps...)
+
+	return nil
+}
+
+func checkResult(match *Match, p1 string, p2 string, maps []MapResult) error {
+	if p1 == "" || p2 == "" {
+		return ErrPlayersUndecided
+	}
+
+	if len(maps) > match.BestOf {
+		return fmt.Errorf("%w: %d maps in a best of %d", ErrInvalidResult, len(maps), match.BestOf)
+	}
+
+	needed := match.BestOf/2 + 1
+	s1, s2 := 0, 0
+
+	for i, result := range maps {
+		if s1 >= needed || s2 >= needed {
+			return fmt.Errorf("%w: map %d was played after the match was already won", ErrInvalidResult, i+1)
+		}
+
+		switch result.Winner {
+		case p1:
+			s1++
+		case p2:
+			s2++
+		default:
+			return fmt.Errorf("%w: %q won map %d but isn't playing", ErrInvalidResult, result.Winner, i+1)
+		}
+	}
+
+	return nil
+}
+
+func (t *Tournament) hasDependentResults(id string) bool {
+	for _, stage := range t.Stages {
+		for _, match := range stage.Matches {
+			if len(match.Maps) == 0 {
+				continue
+			}
+
+			for _, slot := range match.Slots {
+				if slot.WinnerOf == id || slot.LoserOf == id {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// PlayOut finishes every match that isn't over yet, in bracket order.
+// nextMap is asked for each map still to be played, and has to be won by
+// one of the two players it's given.
+//
+// This is RecordResult for a whole tournament at once, working each match
+// out only once instead of for every map.
+func (t *Tournament) PlayOut(nextMap func(player1 string, player2 string) MapResult) error {
+	results := make(map[string]resolved)
+
+	for i := range t.Stages {
+		for j := range t.Stages[i].Matches {
+			match := &t.Stages[i].Matches[j]
+			r := resolveMatch(match, results)
+
+			if !r.done && (r.p1 == "" || r.p2 == "") {
+				return fmt.Errorf("match %q: %w", match.ID, ErrPlayersUndecided)
+			}
+
+			for !r.done {
+				maps := append(match.Maps, nextMap(r.p1, r.p2))
+
+				err := checkResult(match, r.p1, r.p2, maps)
+
+				if err != nil {
+					return fmt.Errorf("match %q: %w", match.ID, err)
+				}
+
+				match.Maps = maps
+				r = resolveMatch(match, results)
+			}
+
+			results[match.ID] = r
+		}
+	}
+
+	return nil
+}
+
+// Clone returns a deep copy, so results can be recorded on it without
+// touching the original
+func (t *Tournament) Clone() *Tournament {
+	clone := *t
+	clone.Stages = make([]Stage, len(t.Stages))
+
+	for i, stage := range t.Stages {
+		clone.Stages[i] = stage
+		clone.Stages[i].Matches = make([]Match, len(stage.Matches))
+
+		for j, match := range stage.Matches {
+			clone.Stages[i].Matches[j] = match
+			clone.Stages[i].Matches[j].Maps = append([]MapResult(nil), match.Maps...)
+		}
+	}
+
+	return &clone
+}
+
+// Entrants returns every player seeded straight into the tournament, in
+// the order they first appear
+func (t *Tournament) Entrants() []string {
+	var entrants []string
+
+	seen := make(map[string]bool)
+
+	for _, stage := range t.Stages {
+		for _, match := range stage.Matches {
+			for _, slot := range match.Slots {
+				if slot.Player != "" && !seen[slot.Player] {
+					seen[slot.Player] = true
+					entrants = append(entrants, slot.Player)
+				}
+			}
+		}
+	}
+
+	return entrants
+}
@@ -0,0 +1,360 @@
+package bracket
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Two dual tournament groups whose top two cross over into the semifinals,
+// then a final.  Same shape as a GSL Code S playoff, just smaller.
+func newTestTournament(t *testing.T) *Tournament {
+	t.Helper()
+
+	groupA, firstA, secondA := DualTournamentGroup("Group A", "a", [4]Source{Seed("TY"), Seed("Rogue"), Seed("Maru"), Seed("Dark")}, 3)
+	groupB, firstB, secondB := DualTournamentGroup("Group B", "b", [4]Source{Seed("Zest"), Seed("Solar"), Seed("Stats"), Seed("Trap")}, 3)
+
+	playoffs, champion, err := SingleEliminationBracket("Playoffs", "playoffs", []Source{firstA, secondB, firstB, secondA}, 5, 7)
+
+	if err != nil {
+		t.Fatal("SingleEliminationBracket:", err)
+	}
+
+	tournament := &Tournament{
+		ID:     "test",
+		Name:   "Test Season",
+		Date:   time.Date(2020, 7, 18, 0, 0, 0, 0, time.UTC),
+		Stages: []Stage{groupA, groupB, playoffs},
+		Final:  champion.WinnerOf,
+	}
+
+	err = tournament.Validate()
+
+	if err != nil {
+		t.Fatal("tournament.Validate:", err)
+	}
+
+	return tournament
+}
+
+func sweep(winner string, maps int) []MapResult {
+	results := make([]MapResult, maps)
+
+	for i := range results {
+		results[i] = MapResult{Map: "Pillars of Gold", Winner: winner}
+	}
+
+	return results
+}
+
+func mustRecord(t *testing.T, tournament *Tournament, id string, maps []MapResult) {
+	t.Helper()
+
+	err := tournament.RecordResult(id, maps)
+
+	if err != nil {
+		t.Fatalf("RecordResult(%q): %v", id, err)
+	}
+}
+
+func TestTournamentDerivesChampionFromResults(t *testing.T) {
+	tournament := newTestTournament(t)
+
+	mustRecord(t, tournament, "a-opening-1", sweep("TY", 2))
+	mustRecord(t, tournament, "a-opening-2", sweep("Maru", 2))
+	mustRecord(t, tournament, "a-winners", sweep("Maru", 2))
+	mustRecord(t, tournament, "a-losers", sweep("Rogue", 2))
+	mustRecord(t, tournament, "a-decider", []MapResult{{"Ice and Fire", "TY"}, {"Jagannatha", "Rogue"}, {"Oxide", "TY"}})
+
+	mustRecord(t, tournament, "b-opening-1", sweep("Zest", 2))
+	mustRecord(t, tournament, "b-opening-2", sweep("Stats", 2))
+	mustRecord(t, tournament, "b-winners", sweep("Zest", 2))
+	mustRecord(t, tournament, "b-losers", sweep("Solar", 2))
+	mustRecord(t, tournament, "b-decider", sweep("Solar", 2))
+
+	p1, p2, err := tournament.Players("playoffs-r1-m1")
+
+	if err != nil {
+		t.Fatal("tournament.Players:", err)
+	}
+
+	if p1 != "Maru" || p2 != "Solar" {
+		t.Errorf("Expected Maru vs Solar in the first semifinal but got %s vs %s", p1, p2)
+	}
+
+	if tournament.Complete() {
+		t.Error("Tournament shouldn't be complete before the final")
+	}
+
+	mustRecord(t, tournament, "playoffs-r1-m1", sweep("Maru", 3))
+	mustRecord(t, tournament, "playoffs-r1-m2", sweep("TY", 3))
+	mustRecord(t, tournament, "playoffs-r2-m1", []MapResult{
+		{"Ice and Fire", "Maru"},
+		{"Jagannatha", "TY"},
+		{"Oxide", "TY"},
+		{"Pillars of Gold", "TY"},
+		{"Submarine", "TY"},
+	})
+
+	champion, ok := tournament.Champion()
+
+	if !ok || champion != "TY" {
+		t.Errorf("Expected TY to be champion but got %q (ok=%v)", champion, ok)
+	}
+
+	s1, s2, err := tournament.Score("playoffs-r2-m1")
+
+	if err != nil {
+		t.Fatal("tournament.Score:", err)
+	}
+
+	if s1 != 1 || s2 != 4 {
+		t.Errorf("Expected final score 1-4 but got %d-%d", s1, s2)
+	}
+}
+
+func TestTournamentRejectsBadResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		matchID  string
+		maps     []MapResult
+		expected error
+	}{
+		{"UnknownMatch", "nope", sweep("TY", 2), ErrMatchNotFound},
+		{"PlayersUndecided", "a-winners", sweep("TY", 2), ErrPlayersUndecided},
+		{"WinnerNotPlaying", "a-opening-1", sweep("Maru", 2), ErrInvalidResult},
+		{"TooManyMaps", "a-opening-1", sweep("TY", 4), ErrInvalidResult},
+		{"PlayedAfterWinning", "a-opening-1", []MapResult{{"A", "TY"}, {"B", "TY"}, {"C", "Rogue"}}, ErrInvalidResult},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tournament := newTestTournament(t)
+
+			err := tournament.RecordResult(test.matchID, test.maps)
+
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v but got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestTournamentAllowsPartialResults(t *testing.T) {
+	tournament := newTestTournament(t)
+
+	mustRecord(t, tournament, "a-opening-1", sweep("TY", 1))
+
+	if _, ok := tournament.Winner("a-opening-1"); ok {
+		t.Error("One map shouldn't win a best of 3")
+	}
+
+	view := tournament.View()
+
+	if status := view.Stages[0].Matches[0].Status; status != StatusPlaying {
+		t.Errorf("Expected status %q but got %q", StatusPlaying, status)
+	}
+
+	if status := view.Stages[0].Matches[2].Status; status != StatusWaiting {
+		t.Errorf("Expected status %q but got %q", StatusWaiting, status)
+	}
+}
+
+func TestTournamentLocksResultsThatLaterMatchesDependOn(t *testing.T) {
+	tournament := newTestTournament(t)
+
+	mustRecord(t, tournament, "a-opening-1", sweep("TY", 2))
+	mustRecord(t, tournament, "a-opening-2", sweep("Maru", 2))
+
+	// Nothing has been played off the back of it yet, so fixing it is fine
+	mustRecord(t, tournament, "a-opening-1", sweep("Rogue", 2))
+
+	mustRecord(t, tournament, "a-winners", sweep("Maru", 1))
+
+	err := tournament.RecordResult("a-opening-1", sweep("TY", 2))
+
+	if !errors.Is(err, ErrResultLocked) {
+		t.Errorf("Expected ErrResultLocked but got %v", err)
+	}
+}
+
+func TestMatchesAreFoundAfterTheTournamentChangesShape(t *testing.T) {
+	tournament := newTestTournament(t)
+
+	mustRecord(t, tournament, "a-opening-1", sweep("TY", 2))
+
+	// Everything Validate remembered is now one stage off
+	showmatch := Stage{Name: "Showmatch", Matches: []Match{
+		{ID: "show", BestOf: 1, Slots: [2]Source{Seed("Maru"), Seed("Dark")}},
+	}}
+
+	tournament.Stages = append([]Stage{showmatch}, tournament.Stages...)
+
+	if winner, _ := tournament.Winner("a-opening-1"); winner != "TY" {
+		t.Errorf("Expected TY to have won a-opening-1 but got %q", winner)
+	}
+
+	mustRecord(t, tournament, "show", sweep("Dark", 1))
+
+	if winner, _ := tournament.Winner("show"); winner != "Dark" {
+		t.Errorf("Expected Dark to have won the showmatch but got %q", winner)
+	}
+}
+
+func TestPlayOutFinishesEveryMatch(t *testing.T) {
+	tournament := newTestTournament(t)
+
+	mustRecord(t, tournament, "a-opening-1", sweep("Rogue", 1))
+
+	// The first player always wins, apart from the map already played
+	err := tournament.PlayOut(func(p1 string, p2 string) MapResult {
+		return MapResult{Map: "Oxide", Winner: p1}
+	})
+
+	if err != nil {
+		t.Fatal("tournament.PlayOut:", err)
+	}
+
+	if winner, _ := tournament.Winner("a-opening-1"); winner != "TY" {
+		t.Errorf("Expected TY to come back and win a-opening-1 but got %q", winner)
+	}
+
+	if !tournament.Complete() {
+		t.Error("Expected every match to be played")
+	}
+}
+
+func TestPlayOutRefusesMapsWonBySomeoneElse(t *testing.T) {
+	tournament := newTestTournament(t)
+
+	err := tournament.PlayOut(func(string, string) MapResult {
+		return MapResult{Map: "Oxide", Winner: "Innovation"}
+	})
+
+	if !errors.Is(err, ErrInvalidResult) {
+		t.Errorf("Expected ErrInvalidResult but got %v", err)
+	}
+
+	if _, ok := tournament.Winner("a-opening-1"); ok {
+		t.Error("Expected the refused map not to be recorded")
+	}
+}
+
+func TestValidateCatchesBrokenTournaments(t *testing.T) {
+	tests := []struct {
+		name       string
+		tournament Tournament
+	}{
+		{
+			"ForwardReference",
+			Tournament{
+				Final: "b",
+				Stages: []Stage{{Matches: []Match{
+					{ID: "a", BestOf: 1, Slots: [2]Source{Seed("TY"), WinnerOf("b")}},
+					{ID: "b", BestOf: 1, Slots: [2]Source{Seed("Maru"), Seed("Rogue")}},
+				}}},
+			},
+		},
+		{
+			"EvenBestOf",
+			Tournament{
+				Final:  "a",
+				Stages: []Stage{{Matches: []Match{{ID: "a", BestOf: 2, Slots: [2]Source{Seed("TY"), Seed("Maru")}}}}},
+			},
+		},
+		{
+			"MissingFinal",
+			Tournament{
+				Final:  "nope",
+				Stages: []Stage{{Matches: []Match{{ID: "a", BestOf: 1, Slots: [2]Source{Seed("TY"), Seed("Maru")}}}}},
+			},
+		},
+		{
+			"AmbiguousSlot",
+			Tournament{
+				Final:  "a",
+				Stages: []Stage{{Matches: []Match{{ID: "a", BestOf: 1, Slots: [2]Source{{Player: "TY", WinnerOf: "x"}, Seed("Maru")}}}}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.tournament.Validate(); err == nil {
+				t.Error("Expected an error but got nil")
+			}
+		})
+	}
+}
+
+func TestDoubleEliminationPlaysEveryoneOut(t *testing.T) {
+	players := []string{"TY", "Rogue", "Maru", "Dark", "Zest", "Solar", "Stats", "Trap"}
+	entrants := make([]Source, len(players))
+
+	for i, player := range players {
+		entrants[i] = Seed(player)
+	}
+
+	stage, champion, err := DoubleEliminationBracket("Playoffs", "de", entrants, 1)
+
+	if err != nil {
+		t.Fatal("DoubleEliminationBracket:", err)
+	}
+
+	// Every match hands out exactly one loss.  Everyone but the two
+	// finalists goes out on their second loss, the lower bracket winner
+	// already has one, and the grand final adds one more: 2(n-2)+1+1.
+	if len(stage.Matches) != 2*len(players)-2 {
+		t.Errorf("Expected %d matches but got %d", 2*len(players)-2, len(stage.Matches))
+	}
+
+	tournament := &Tournament{ID: "de", Stages: []Stage{stage}, Final: champion.WinnerOf}
+
+	if err := tournament.Validate(); err != nil {
+		t.Fatal("tournament.Validate:", err)
+	}
+
+	// The first listed player wins everything they play, and otherwise the
+	// earlier seed wins.  Walking the matches in order always works because
+	// matches only depend on earlier ones.
+	rank := make(map[string]int)
+
+	for i, player := range players {
+		rank[player] = i
+	}
+
+	for _, match := range stage.Matches {
+		p1, p2, err := tournament.Players(match.ID)
+
+		if err != nil {
+			t.Fatal("tournament.Players:", err)
+		}
+
+		winner := p1
+
+		if rank[p2] < rank[p1] {
+			winner = p2
+		}
+
+		mustRecord(t, tournament, match.ID, sweep(winner, 1))
+	}
+
+	if got, _ := tournament.Champion(); got != "TY" {
+		t.Errorf("Expected TY to win but got %q", got)
+	}
+
+	// Rogue loses to TY straight away, then wins the whole lower bracket
+	p1, p2, _ := tournament.Players("de-grand-final")
+
+	if p1 != "TY" || p2 != "Rogue" {
+		t.Errorf("Expected TY vs Rogue in the grand final but got %s vs %s", p1, p2)
+	}
+}
+
+func TestSingleEliminationNeedsPowerOfTwo(t *testing.T) {
+	_, _, err := SingleEliminationBracket("Playoffs", "p", []Source{Seed("TY"), Seed("Maru"), Seed("Rogue")}, 5)
+
+	if err == nil {
+		t.Error("Expected an error for three entrants")
+	}
+}
@@ -0,0 +1,209 @@
+package bracket
+
+import (
+	"fmt"
+)
+
+// DualTournamentGroup builds a four player GSL group
+//
+// The two opening matches feed a winners' match and a losers' match.  The
+// winners' match decides first place.  Its loser then plays the winner of
+// the losers' match in the decider for second place.  Both first and second
+// advance, which is what the returned sources point at.
+func DualTournamentGroup(name string, idPrefix string, entrants [4]Source, bestOf int) (Stage, Source, Source) {
+	id := func(suffix string) string {
+		return idPrefix + "-" + suffix
+	}
+
+	stage := Stage{
+		Name:   name,
+		Format: DualTournament,
+		Matches: []Match{
+			{ID: id("opening-1"), Round: "Opening", BestOf: bestOf, Slots: [2]Source{entrants[0], entrants[1]}},
+			{ID: id("opening-2"), Round: "Opening", BestOf: bestOf, Slots: [2]Source{entrants[2], entrants[3]}},
+			{ID: id("winners"), Round: "Winners", BestOf: bestOf, Slots: [2]Source{WinnerOf(id("opening-1")), WinnerOf(id("opening-2"))}},
+			{ID: id("losers"), Round: "Losers", BestOf: bestOf, Slots: [2]Source{LoserOf(id("opening-1")), LoserOf(id("opening-2"))}},
+			{ID: id("decider"), Round: "Decider", BestOf: bestOf, Slots: [2]Source{LoserOf(id("winners")), WinnerOf(id("losers"))}},
+		},
+	}
+
+	return stage, WinnerOf(id("winners")), WinnerOf(id("decider"))
+}
+
+// SingleEliminationBracket builds a knockout bracket
+//
+// Entrants are paired up in order, first against second and so on, so put
+// them in seeded order before calling this.  There must be a power of two
+// of them.  bestOf gives the series length for each round starting from the
+// first, and the last value carries on for any rounds after that, so GSL
+// playoffs are SingleEliminationBracket(..., 5, 5, 7).
+func SingleEliminationBracket(name string, idPrefix string, entrants []Source, bestOf ...int) (Stage, Source, error) {
+	rounds, err := roundsFor(len(entrants), 2)
+
+	if err != nil {
+		return Stage{}, Source{}, err
+	}
+
+	stage := Stage{
+		Name:   name,
+		Format: SingleElimination,
+	}
+
+	current := entrants
+
+	for round := 1; round <= rounds; round++ {
+		label := roundLabel(len(current))
+		next := make([]Source, 0, len(current)/2)
+
+		for i := 0; i < len(current); i += 2 {
+			id := fmt.Sprintf("%s-r%d-m%d", idPrefix, round, i/2+1)
+
+			stage.Matches = append(stage.Matches, Match{
+				ID:     id,
+				Round:  label,
+				BestOf: bestOfRound(bestOf, round),
+				Slots:  [2]Source{current[i], current[i+1]},
+			})
+
+			next = append(next, WinnerOf(id))
+		}
+
+		current = next
+	}
+
+	return stage, current[0], nil
+}
+
+// DoubleEliminationBracket builds a bracket where you're out after two
+// losses
+//
+// The upper bracket works like single elimination.  Losers of the first
+// upper round play each other, and every upper round after that drops its
+// losers into the lower bracket to face the lower bracket's survivors.  The
+// grand final is one series between the two bracket winners, with no reset.
+// There must be a power of two entrants, and at least four.
+func DoubleEliminationBracket(name string, idPrefix string, entrants []Source, bestOf int) (Stage, Source, error) {
+	rounds, err := roundsFor(len(entrants), 4)
+
+	if err != nil {
+		return Stage{}, Source{}, err
+	}
+
+	stage := Stage{
+		Name:   name,
+		Format: DoubleElimination,
+	}
+
+	add := func(id string, round string, a Source, b Source) Source {
+		stage.Matches = append(stage.Matches, Match{
+			ID:     id,
+			Round:  round,
+			BestOf: bestOf,
+			Slots:  [2]Source{a, b},
+		})
+
+		return WinnerOf(id)
+	}
+
+	// The upper bracket, remembering who drops out of each round
+	upperLosers := make([][]Source, rounds+1)
+	current := entrants
+
+	for round := 1; round <= rounds; round++ {
+		next := make([]Source, 0, len(current)/2)
+
+		for i := 0; i < len(current); i += 2 {
+			id := fmt.Sprintf("%s-upper-r%d-m%d", idPrefix, round, i/2+1)
+
+			next = append(next, add(id, "Upper "+roundLabel(len(current)), current[i], current[i+1]))
+			upperLosers[round] = append(upperLosers[round], LoserOf(id))
+		}
+
+		current = next
+	}
+
+	upperWinner := current[0]
+
+	// The lower bracket starts with the first round's losers playing each other
+	lowerRound := 1
+	lower := make([]Source, 0, len(upperLosers[1])/2)
+
+	for i := 0; i < len(upperLosers[1]); i += 2 {
+		id := fmt.Sprintf("%s-lower-r%d-m%d", idPrefix, lowerRound, i/2+1)
+		lower = append(lower, add(id, fmt.Sprintf("Lower Round %d", lowerRound), upperLosers[1][i], upperLosers[1][i+1]))
+	}
+
+	for round := 2; round <= rounds; round++ {
+		// Losers dropping down meet the lower bracket survivors.  Dropping
+		// them in reverse order keeps people from facing the same opponent
+		// twice as much as we easily can.
+		lowerRound++
+		dropped := upperLosers[round]
+		next := make([]Source, 0, len(lower))
+
+		for i := range lower {
+			id := fmt.Sprintf("%s-lower-r%d-m%d", idPrefix, lowerRound, i+1)
+			next = append(next, add(id, fmt.Sprintf("Lower Round %d", lowerRound), lower[i], dropped[len(dropped)-1-i]))
+		}
+
+		lower = next
+
+		if len(lower) == 1 {
+			break
+		}
+
+		// Then the survivors play each other to halve the field again
+		lowerRound++
+		next = make([]Source, 0, len(lower)/2)
+
+		for i := 0; i < len(lower); i += 2 {
+			id := fmt.Sprintf("%s-lower-r%d-m%d", idPrefix, lowerRound, i/2+1)
+			next = append(next, add(id, fmt.Sprintf("Lower Round %d", lowerRound), lower[i], lower[i+1]))
+		}
+
+		lower = next
+	}
+
+	champion := add(idPrefix+"-grand-final", "Grand Final", upperWinner, lower[0])
+
+	return stage, champion, nil
+}
+
+func roundsFor(entrants int, minimum int) (int, error) {
+	if entrants < minimum || entrants&(entrants-1) != 0 {
+		return 0, fmt.Errorf("need a power of two entrants, at least %d, but got %d", minimum, entrants)
+	}
+
+	rounds := 0
+
+	for n := entrants; n > 1; n /= 2 {
+		rounds++
+	}
+
+	return rounds, nil
+}
+
+func bestOfRound(bestOf []int, round int) int {
+	if len(bestOf) == 0 {
+		return 1
+	}
+
+	if round > len(bestOf) {
+		return bestOf[len(bestOf)-1]
+	}
+
+	return bestOf[round-1]
+}
+
+func roundLabel(players int) string {
+	switch players {
+	case 2:
+		return "Final"
+	case 4:
+		return "Semifinals"
+	case 8:
+		return "Quarterfinals"
+	}
+
+	return fmt.Sprintf("Round of %d", players)
+}
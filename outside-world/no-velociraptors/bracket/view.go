@@ -0,0 +1,97 @@
+package bracket
+
+import (
+	"time"
+)
+
+// Match statuses as shown in a view
+const (
+	StatusWaiting  = "waiting"
+	StatusReady    = "ready"
+	StatusPlaying  = "playing"
+	StatusComplete = "complete"
+)
+
+// MatchView is a match with everything worked out, ready to show someone
+type MatchView struct {
+	ID      string      `json:"id"`
+	Round   string      `json:"round,omitempty"`
+	BestOf  int         `json:"bestOf"`
+	Status  string      `json:"status"`
+	Player1 string      `json:"player1,omitempty"`
+	Player2 string      `json:"player2,omitempty"`
+	Score   [2]int      `json:"score"`
+	Winner  string      `json:"winner,omitempty"`
+	Maps    []MapResult `json:"maps,omitempty"`
+}
+
+// StageView is a stage with all its matches worked out
+type StageView struct {
+	Name    string      `json:"name"`
+	Format  Format      `json:"format"`
+	Matches []MatchView `json:"matches"`
+}
+
+// TournamentView is the whole bracket as it stands right now
+type TournamentView struct {
+	ID       string      `json:"id"`
+	Name     string      `json:"name"`
+	Date     time.Time   `json:"date"`
+	Champion string      `json:"champion,omitempty"`
+	Stages   []StageView `json:"stages"`
+}
+
+// View works out every match's players, score and status
+func (t *Tournament) View() TournamentView {
+	results := t.resolveAll()
+	champion := results[t.Final].winner
+
+	view := TournamentView{
+		ID:       t.ID,
+		Name:     t.Name,
+		Date:     t.Date,
+		Champion: champion,
+		Stages:   make([]StageView, 0, len(t.Stages)),
+	}
+
+	for _, stage := range t.Stages {
+		stageView := StageView{
+			Name:    stage.Name,
+			Format:  stage.Format,
+			Matches: make([]MatchView, 0, len(stage.Matches)),
+		}
+
+		for _, match := range stage.Matches {
+			r := results[match.ID]
+
+			var status string
+
+			switch {
+			case r.done:
+				status = StatusComplete
+			case r.p1 == "" || r.p2 == "":
+				status = StatusWaiting
+			case len(match.Maps) > 0:
+				status = StatusPlaying
+			default:
+				status = StatusReady
+			}
+
+			stageView.Matches = append(stageView.Matches, MatchView{
+				ID:      match.ID,
+				Round:   match.Round,
+				BestOf:  match.BestOf,
+				Status:  status,
+				Player1: r.p1,
+				Player2: r.p2,
+				Score:   [2]int{r.s1, r.s2},
+				Winner:  r.winner,
+				Maps:    match.Maps,
+			})
+		}
+
+		view.Stages = append(view.Stages, stageView)
+	}
+
+	return view
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when a circuit breaker refuses to let a call through
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type circuitState int
+
+const (
+	// Everything is fine, let calls through
+	circuitClosed circuitState = iota
+
+	// Too many failures, don't even try until the cooldown is over
+	circuitOpen
+
+	// Cooldown is over, let a single trial call through to see if things recovered
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitClosed:
+		return "closed"
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	}
+
+	return "unknown"
+}
+
+// CircuitBreaker stops us from hammering something that keeps failing
+//
+// After failureThreshold consecutive failures the breaker opens and rejects
+// every call for the cooldown period.  Once that's over it goes half-open and
+// lets exactly one trial call through.  If the trial works we close again,
+// if it fails we go right back to open.
+type CircuitBreaker struct {
+	failureThreshold int
+	cooldown         time.Duration
+
+	// Injected so tests don't have to actually wait around
+	now func() time.Time
+
+	mu            sync.Mutex
+	state         circuitState
+	failures      int
+	openedAt      time.Time
+	trialInFlight bool
+}
+
+// NewCircuitBreaker returns a closed CircuitBreaker that opens after
+// failureThreshold consecutive failures and stays open for cooldown
+func NewCircuitBreaker(failureThreshold int, cooldown time.Duration) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		now:              time.Now,
+	}
+}
+
+// Allow reports whether a call should be attempted right now.  Every call
+// that is allowed must be followed by exactly one Success, Failure or Release.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+
+		b.state = circuitHalfOpen
+		b.trialInFlight = true
+
+		return true
+
+	case circuitHalfOpen:
+		if b.trialInFlight {
+			return false
+		}
+
+		b.trialInFlight = true
+
+		return true
+	}
+
+	return true
+}
+
+// Success records that an allowed call worked
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = circuitClosed
+	b.failures = 0
+	b.trialInFlight = false
+}
+
+// Failure records that an allowed call failed
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trialInFlight = false
+
+	if b.state == circuitHalfOpen || b.failures >= b.failureThreshold {
+		b.state = circuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Release gives back an allowed call without counting it either way, for
+// when the call was abandoned before it could tell us anything
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trialInFlight = false
+}
+
+func (b *CircuitBreaker) currentState() circuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// A clock we can move around ourselves, so we never have to sleep in tests
+type fakeClock struct {
+	current time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.current
+}
+
+func newTestBreaker(threshold int, cooldown time.Duration) (*CircuitBreaker, *fakeClock) {
+	clock := &fakeClock{current: time.Date(2020, 7, 18, 0, 0, 0, 0, time.UTC)}
+	breaker := NewCircuitBreaker(threshold, cooldown)
+	breaker.now = clock.now
+
+	return breaker, clock
+}
+
+func TestCircuitBreakerOpensAfterThresholdFailures(t *testing.T) {
+	breaker, _ := newTestBreaker(2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if !breaker.Allow() {
+			t.Fatalf("Expected call %d to be allowed while closed", i)
+		}
+
+		breaker.Failure()
+	}
+
+	if breaker.currentState() != circuitOpen {
+		t.Fatalf("Expected breaker to be open but it was %v", breaker.currentState())
+	}
+
+	if breaker.Allow() {
+		t.Error("Expected open breaker to reject calls")
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailureCount(t *testing.T) {
+	breaker, _ := newTestBreaker(2, time.Minute)
+
+	breaker.Allow()
+	breaker.Failure()
+	breaker.Allow()
+	breaker.Success()
+	breaker.Allow()
+	breaker.Failure()
+
+	if breaker.currentState() != circuitClosed {
+		t.Errorf("Expected breaker to still be closed but it was %v", breaker.currentState())
+	}
+}
+
+func TestCircuitBreakerHalfOpenAllowsSingleTrial(t *testing.T) {
+	breaker, clock := newTestBreaker(1, time.Minute)
+
+	breaker.Allow()
+	breaker.Failure()
+
+	clock.current = clock.current.Add(time.Minute)
+
+	if !breaker.Allow() {
+		t.Fatal("Expected a trial call to be allowed after the cooldown")
+	}
+
+	if breaker.currentState() != circuitHalfOpen {
+		t.Fatalf("Expected breaker to be half-open but it was %v", breaker.currentState())
+	}
+
+	if breaker.Allow() {
+		t.Error("Expected only one trial call while half-open")
+	}
+}
+
+func TestCircuitBreakerClosesWhenTrialSucceeds(t *testing.T) {
+	breaker, clock := newTestBreaker(1, time.Minute)
+
+	breaker.Allow()
+	breaker.Failure()
+	clock.current = clock.current.Add(time.Minute)
+	breaker.Allow()
+	breaker.Success()
+
+	if breaker.currentState() != circuitClosed {
+		t.Errorf("Expected breaker to be closed but it was %v", breaker.currentState())
+	}
+}
+
+func TestCircuitBreakerReopensWhenTrialFails(t *testing.T) {
+	breaker, clock := newTestBreaker(3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		breaker.Allow()
+		breaker.Failure()
+	}
+
+	clock.current = clock.current.Add(time.Minute)
+	breaker.Allow()
+	breaker.Failure()
+
+	if breaker.currentState() != circuitOpen {
+		t.Fatalf("Expected breaker to be open again but it was %v", breaker.currentState())
+	}
+
+	clock.current = clock.current.Add(time.Second)
+
+	if breaker.Allow() {
+		t.Error("Expected the cooldown to start over after a failed trial")
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ChampionVersionGetter can tell us which version of the champion we have
+//
+// This is all the caching layer needs.  It never sees the champion itself,
+// just enough to tell a client "what you have is still good".
+type ChampionVersionGetter interface {
+	GetCurrentChampionVersion(ctx context.Context) (ChampionVersion, error)
+}
+
+// fileChampionSource is the name of the fallback source that reads the same
+// file our versions describe
+const fileChampionSource = "file"
+
+// bufferedResponse holds on to a response until we know which validators,
+// if any, belong on it
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header)}
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(body []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+
+	return b.body.Write(body)
+}
+
+// sendTo writes the held response out for real
+func (b *bufferedResponse) sendTo(res http.ResponseWriter) {
+	for name, values := range b.header {
+		res.Header()[name] = values
+	}
+
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+
+	res.WriteHeader(b.status)
+	res.Write(b.body.Bytes())
+}
+
+// withConditionalGet adds ETag, Last-Modified and Cache-Control headers to
+// GET and HEAD responses, and answers with a 304 when the client already
+// has the current version
+//
+// If we can't get the version we just serve the request normally without
+// any validators.  Clients will get the full body, which is always correct,
+// just a little less efficient.
+//
+// The version describes the champion file, but the body can come from any
+// of the fallback sources.  A validator on a body it doesn't describe would
+// have clients caching, and sending back in If-Match, something we never
+// checked.  So validators only go on bodies the file served, and only if
+// the file didn't change while we were busy serving it.
+func withConditionalGet(championVersionGetter ChampionVersionGetter, cacheControl string, logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		// Each representation gets its own ETag, so we need to know which
+		// one we're about to send.  If there isn't one, let the handler
+		// explain that to the client.
+		representation, ok := negotiate(req)
+
+		if !ok {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		ctx := req.Context()
+
+		version, err := championVersionGetter.GetCurrentChampionVersion(ctx)
+
+		if err != nil {
+			logger.WarnContext(ctx, "Failed to get current champion version, serving without validators", "error", err)
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		// HTTP dates only go down to the second, so compare at that precision
+		lastModified := version.LastModified.UTC().Truncate(time.Second)
+
+		etag := representation.etag(version.ETag)
+
+		// The file is there and readable, so it's what the client should
+		// have.  No need to ask anyone for the body.
+		if notModified(req, etag, lastModified) {
+			setValidators(res.Header(), etag, lastModified, cacheControl)
+			res.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		buffered := newBufferedResponse()
+
+		next.ServeHTTP(buffered, req)
+
+		source := buffered.header.Get(championSourceHeader)
+
+		if buffered.status == http.StatusOK && (source == "" || source == fileChampionSource) {
+			after, err := championVersionGetter.GetCurrentChampionVersion(ctx)
+
+			if err == nil && after.ETag == version.ETag && after.LastModified.Equal(version.LastModified) {
+				setValidators(buffered.header, etag, lastModified, cacheControl)
+			}
+		}
+
+		buffered.sendTo(res)
+	})
+}
+
+// setValidators puts the caching headers for one version on a response
+func setValidators(header http.Header, etag string, lastModified time.Time, cacheControl string) {
+	header.Set("ETag", etag)
+	header.Set("Vary", "Accept")
+
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if cacheControl != "" {
+		header.Set("Cache-Control", cacheControl)
+	}
+}
+
+// notModified follows RFC 7232: If-None-Match wins if it's there, and
+// If-Modified-Since only counts when it isn't
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagListMatches(ifNoneMatch, etag)
+	}
+
+	ifModifiedSince := req.Header.Get("If-Modified-Since")
+
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.After(since)
+}
+
+// etagListMatches does the weak comparison If-None-Match asks for, against
+// a comma separated list of entity tags
+func etagListMatches(list string, etag string) bool {
+	candidates, ok := parseETagList(list)
+
+	if !ok {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range candidates {
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseETagList splits an If-Match or If-None-Match header into its entity
+// tags, per RFC 9110 section 13.1.1.  That's either "*" on its own or a
+// list of quoted tags, each maybe with W/ in front.  Quoted tags can have
+// commas in them, so this can't just split on commas.
+func parseETagList(list string) ([]string, bool) {
+	list = strings.TrimSpace(list)
+
+	if list == "*" {
+		return []string{"*"}, true
+	}
+
+	var tags []string
+
+	for {
+		// Empty list elements are allowed, and ignored
+		list = strings.TrimLeft(list, " \t,")
+
+		if list == "" {
+			break
+		}
+
+		weak := ""
+
+		if strings.HasPrefix(list, "W/") {
+			weak = "W/"
+			list = list[len("W/"):]
+		}
+
+		if !strings.HasPrefix(list, `"`) {
+			return nil, false
+		}
+
+		end := strings.IndexByte(list[1:], '"')
+
+		if end < 0 {
+			return nil, false
+		}
+
+		tags = append(tags, weak+list[:end+2])
+		list = strings.TrimLeft(list[end+2:], " \t")
+
+		if list != "" && list[0] != ',' {
+			return nil, false
+		}
+	}
+
+	return tags, len(tags) > 0
+}
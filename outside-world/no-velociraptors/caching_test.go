@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type mockChampionVersionGetter struct {
+	pendingVersion ChampionVersion
+	pendingError   error
+}
+
+func (g *mockChampionVersionGetter) GetCurrentChampionVersion(ctx context.Context) (ChampionVersion, error) {
+	return g.pendingVersion, g.pendingError
+}
+
+var testChampionVersion = ChampionVersion{
+	ETag:         `"abc123"`,
+	LastModified: time.Date(2020, 7, 18, 12, 30, 15, 500, time.UTC),
+}
+
+func serveConditionalGet(versionGetter ChampionVersionGetter, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/champion", nil)
+	res := httptest.NewRecorder()
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	championGetter := &mockCurrentChampionGetter{current: "TY"}
+
+	handler := withConditionalGet(versionGetter, "public, max-age=30", testLogger, gslCurrentChampionHandler(championGetter, testLogger))
+
+	handler.ServeHTTP(res, req)
+
+	return res
+}
+
+func TestConditionalGetSetsValidators(t *testing.T) {
+	res := serveConditionalGet(&mockChampionVersionGetter{pendingVersion: testChampionVersion}, nil)
+
+	if res.Code != 200 {
+		t.Fatalf("Expected code 200 but got %d", res.Code)
+	}
+
+	if res.Body.String() != "TY" {
+		t.Errorf("Expected body %q but got %q", "TY", res.Body.String())
+	}
+
+	expectedHeaders := map[string]string{
+		"ETag":          `"abc123"`,
+		"Last-Modified": "Sat, 18 Jul 2020 12:30:15 GMT",
+		"Cache-Control": "public, max-age=30",
+	}
+
+	for key, expected := range expectedHeaders {
+		if got := res.Header().Get(key); got != expected {
+			t.Errorf("Expected %s to be %q but got %q", key, expected, got)
+		}
+	}
+}
+
+func TestConditionalGetReturns304WhenETagMatches(t *testing.T) {
+	res := serveConditionalGet(&mockChampionVersionGetter{pendingVersion: testChampionVersion}, map[string]string{
+		"If-None-Match": `"nope", W/"abc123"`,
+	})
+
+	if res.Code != http.StatusNotModified {
+		t.Errorf("Expected code 304 but got %d", res.Code)
+	}
+
+	if res.Body.Len() != 0 {
+		t.Errorf("Expected an empty body but got %q", res.Body.String())
+	}
+}
+
+func TestConditionalGetIgnoresIfModifiedSinceWhenETagDoesntMatch(t *testing.T) {
+	res := serveConditionalGet(&mockChampionVersionGetter{pendingVersion: testChampionVersion}, map[string]string{
+		"If-None-Match":     `"old"`,
+		"If-Modified-Since": "Sat, 18 Jul 2020 12:30:15 GMT",
+	})
+
+	if res.Code != 200 {
+		t.Errorf("Expected code 200 but got %d", res.Code)
+	}
+}
+
+func TestConditionalGetHonoursIfModifiedSince(t *testing.T) {
+	cases := map[string]int{
+		"Sat, 18 Jul 2020 12:30:15 GMT": 304,
+		"Sun, 19 Jul 2020 00:00:00 GMT": 304,
+		"Sat, 18 Jul 2020 12:30:14 GMT": 200,
+		"not a date":                    200,
+	}
+
+	for since, expectedCode := range cases {
+		res := serveConditionalGet(&mockChampionVersionGetter{pendingVersion: testChampionVersion}, map[string]string{
+			"If-Modified-Since": since,
+		})
+
+		if res.Code != expectedCode {
+			t.Errorf("Expected code %d for If-Modified-Since %q but got %d", expectedCode, since, res.Code)
+		}
+	}
+}
+
+func TestConditionalGetServesNormallyWhenVersionUnavailable(t *testing.T) {
+	res := serveConditionalGet(&mockChampionVersionGetter{pendingError: errors.New("no idea")}, map[string]string{
+		"If-None-Match": "*",
+	})
+
+	if res.Code != 200 {
+		t.Errorf("Expected code 200 but got %d", res.Code)
+	}
+
+	if res.Header().Get("ETag") != "" {
+		t.Errorf("Expected no ETag but got %q", res.Header().Get("ETag"))
+	}
+}
+
+func TestConditionalGetLeavesOutValidatorsWhenFallbackServed(t *testing.T) {
+	championGetter := NewFallbackChampionGetter(
+		ChampionSource{Name: fileChampionSource, Getter: &mockCurrentChampionGetter{pendingError: errors.New("breaker open")}},
+		ChampionSource{Name: "bracket", Getter: &mockCurrentChampionGetter{current: "Maru"}},
+	)
+
+	handler := withConditionalGet(&mockChampionVersionGetter{pendingVersion: testChampionVersion}, "public, max-age=30", testLogger, gslCurrentChampionHandler(championGetter, testLogger))
+
+	req := httptest.NewRequest("GET", "/champion", nil)
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if res.Code != 200 || res.Body.String() != "Maru" {
+		t.Fatalf("Expected 200 with the bracket's champion but got %d %q", res.Code, res.Body.String())
+	}
+
+	for _, key := range []string{"ETag", "Last-Modified", "Cache-Control"} {
+		if got := res.Header().Get(key); got != "" {
+			t.Errorf("Expected no %s on a body the file didn't serve but got %q", key, got)
+		}
+	}
+}
+
+// changingVersionGetter has a new version every time it's asked
+type changingVersionGetter struct {
+	calls int
+}
+
+func (g *changingVersionGetter) GetCurrentChampionVersion(ctx context.Context) (ChampionVersion, error) {
+	g.calls++
+
+	return ChampionVersion{
+		ETag:         `"v` + strconv.Itoa(g.calls) + `"`,
+		LastModified: testChampionVersion.LastModified.Add(time.Duration(g.calls) * time.Second),
+	}, nil
+}
+
+func TestConditionalGetLeavesOutValidatorsWhenChampionChangedMeanwhile(t *testing.T) {
+	res := serveConditionalGet(&changingVersionGetter{}, nil)
+
+	if res.Code != 200 {
+		t.Fatalf("Expected code 200 but got %d", res.Code)
+	}
+
+	if got := res.Header().Get("ETag"); got != "" {
+		t.Errorf("Expected no ETag when the champion changed while serving but got %q", got)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/chaos"
+)
+
+// The fallback chain exists for when the data store misbehaves, so make it
+// misbehave
+
+func TestFallbackSurvivesAFlakyDataStore(t *testing.T) {
+	injector := chaos.New(chaos.Config{Seed: 5, ErrorRate: 0.3, DeadlineRate: 0.2})
+
+	file := NewCurrentChampionGetterDecorator(NewGSLDataStore("./champion.txt"), injector.Middleware())
+
+	getter := NewFallbackChampionGetter(
+		ChampionSource{Name: "file", Getter: file, Timeout: 5 * time.Millisecond},
+		ChampionSource{Name: "last-known", Getter: NewInMemoryChampionStore("Rogue")},
+	)
+
+	sources := make(map[string]int)
+
+	for i := 0; i < 50; i++ {
+		champion, source, err := getter.GetCurrentChampionWithSource(context.Background())
+
+		if err != nil {
+			t.Fatalf("Call %d: expected the fallback to cover for the data store but got %v", i+1, err)
+		}
+
+		expected := map[string]string{"file": "TY", "last-known": "Rogue"}[source]
+
+		if champion != expected {
+			t.Errorf("Call %d: expected %q from %s but got %q", i+1, expected, source, champion)
+		}
+
+		sources[source]++
+	}
+
+	if sources["file"] == 0 || sources["last-known"] == 0 {
+		t.Errorf("Expected both sources to answer sometimes but got %v", sources)
+	}
+}
+
+func TestGSLDataStoreDeadlineFaultIsReported(t *testing.T) {
+	injector := chaos.NewScripted(&chaos.Scenario{
+		Steps: []chaos.Step{{Method: "GetCurrentChampion", Call: 1, Fault: chaos.FaultDeadline}},
+	})
+
+	getter := NewCurrentChampionGetterDecorator(NewGSLDataStore("./champion.txt"), injector.Middleware())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	_, err := getter.GetCurrentChampion(ctx)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded but got %v", err)
+	}
+
+	// Only the first call was scripted
+	champion, err := getter.GetCurrentChampion(context.Background())
+
+	if err != nil || champion != "TY" {
+		t.Errorf("Expected TY on the second call but got %q and %v", champion, err)
+	}
+}
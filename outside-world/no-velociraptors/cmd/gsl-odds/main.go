@@ -0,0 +1,188 @@
+// gsl-odds simulates the rest of a tournament from the command line
+//
+//	gsl-odds -tournament 2020-s2 -runs 100000 -seed 7
+//
+// It reads the same tournaments directory and players file as the GSL
+// server, rates everyone from every finished match, then plays out what's
+// left of the tournament using those ratings.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/bracket"
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/players"
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/ratings"
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/simulate"
+)
+
+func main() {
+	tournamentsDir := flag.String("tournaments", "./tournaments", "directory of tournament JSON files")
+	playersFile := flag.String("players", "./players.json", "players file, for aliases")
+	tournamentID := flag.String("tournament", "", "ID of the tournament to simulate")
+	runs := flag.Int("runs", 10000, "number of simulations")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed, to repeat a previous run")
+	workers := flag.Int("workers", 0, "goroutines to use, defaults to one per CPU")
+	asJSON := flag.Bool("json", false, "print JSON instead of a table")
+	flag.Parse()
+
+	if *tournamentID == "" {
+		log.Fatal("-tournament is required")
+	}
+
+	registry, err := loadRegistry(*playersFile)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tournaments, err := loadTournaments(*tournamentsDir)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rater := ratings.NewRater(ratings.DefaultEloK, ratings.DefaultGlickoTau)
+
+	var target *bracket.Tournament
+	var history []players.MatchRecord
+
+	for _, tournament := range tournaments {
+		if tournament.ID == *tournamentID {
+			target = tournament
+		}
+
+		history = append(history, players.HistoryFromTournament(tournament, registry)...)
+	}
+
+	if target == nil {
+		log.Fatalf("no tournament %q in %s", *tournamentID, *tournamentsDir)
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Date.Before(history[j].Date)
+	})
+
+	for _, match := range history {
+		rater.Record(match)
+	}
+
+	probability := func(player1 string, player2 string) float64 {
+		return ratings.EloExpected(rater.Rating(registry.Canonical(player1)).Elo, rater.Rating(registry.Canonical(player2)).Elo)
+	}
+
+	// Big runs take a while, so Ctrl+C stops between batches
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := simulate.Run(ctx, target, probability, simulate.Options{
+		Runs:    *runs,
+		Seed:    *seed,
+		Workers: *workers,
+	})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+		return
+	}
+
+	printTable(target, result)
+}
+
+func loadRegistry(filename string) (*players.Registry, error) {
+	contents, err := os.ReadFile(filename)
+
+	if os.IsNotExist(err) {
+		return players.NewRegistry(nil)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var list []players.Player
+
+	err = json.Unmarshal(contents, &list)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	return players.NewRegistry(list)
+}
+
+func loadTournaments(dir string) ([]*bracket.Tournament, error) {
+	filenames, err := filepath.Glob(filepath.Join(dir, "*.json"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var tournaments []*bracket.Tournament
+
+	for _, filename := range filenames {
+		contents, err := os.ReadFile(filename)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var tournament bracket.Tournament
+
+		err = json.Unmarshal(contents, &tournament)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+		}
+
+		tournaments = append(tournaments, &tournament)
+	}
+
+	sort.SliceStable(tournaments, func(i, j int) bool {
+		return tournaments[i].Date.Before(tournaments[j].Date)
+	})
+
+	return tournaments, nil
+}
+
+func printTable(tournament *bracket.Tournament, result simulate.Result) {
+	fmt.Printf("%s: %d runs, seed %d\n\n", tournament.Name, result.Runs, result.Seed)
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprint(table, "Player\t")
+
+	for _, stage := range tournament.Stages {
+		fmt.Fprintf(table, "%s\t", stage.Name)
+	}
+
+	fmt.Fprintln(table, "Champion\t")
+
+	for _, odds := range result.Players {
+		fmt.Fprintf(table, "%s\t", odds.Player)
+
+		for _, stage := range tournament.Stages {
+			fmt.Fprintf(table, "%.1f%%\t", odds.Stages[stage.Name]*100)
+		}
+
+		fmt.Fprintf(table, "%.1f%%\t\n", odds.Champion*100)
+	}
+
+	table.Flush()
+}
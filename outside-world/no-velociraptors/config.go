@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/ratelimit"
+)
+
+// gslConfig is everything about the GSL server that changes between
+// machines, loaded by the config package from a file, GSL_* environment
+// variables and flags
+//
+// This is only main's business.  Main reads it and hands out plain values
+// and dependencies, so nothing else needs to know it exists.
+type gslConfig struct {
+	Address         string        `yaml:"address" help:"Where the server listens"`
+	RequestTimeout  time.Duration `yaml:"request_timeout" help:"How long any single request gets"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" help:"How long requests in flight get to finish on SIGTERM"`
+	CacheControl    string        `yaml:"cache_control" help:"Cache-Control header to send with champion responses"`
+	IdempotencyTTL  time.Duration `yaml:"idempotency_ttl" help:"How long responses are kept to replay for retries with the same Idempotency-Key"`
+
+	// Tokens come from the environment or the file rather than flags, so
+	// they don't show up in ps
+	AdminTokens string `yaml:"admin_tokens" flag:"-" secret:"true" help:"Admin bearer tokens as name:token, comma separated"`
+
+	Data struct {
+		ChampionFile  string `yaml:"champion_file" help:"File holding the current champion"`
+		TournamentDir string `yaml:"tournament_dir" help:"Directory with one bracket file per tournament"`
+		PlayersFile   string `yaml:"players_file" help:"Player registry"`
+		AuditLog      string `yaml:"audit_log" help:"Where champion changes are recorded"`
+	} `yaml:"data"`
+
+	Fallback struct {
+		Upstream  string `yaml:"upstream" help:"URL of another GSL server's /champion endpoint to fall back on"`
+		LastKnown string `yaml:"last_known" help:"Champion to serve when every other source fails"`
+	} `yaml:"fallback"`
+
+	// How fast each client can call each route, by admin or else by IP
+	RateLimits struct {
+		Champion    ratelimit.Limit `yaml:"champion"`
+		Feed        ratelimit.Limit `yaml:"feed"`
+		Tournaments ratelimit.Limit `yaml:"tournaments"`
+		Players     ratelimit.Limit `yaml:"players"`
+		Predict     ratelimit.Limit `yaml:"predict"`
+	} `yaml:"rate_limits"`
+
+	Webhooks struct {
+		RegistryFile  string        `yaml:"registry_file" help:"Where webhook subscriptions are kept"`
+		MaxAttempts   int           `yaml:"max_attempts" help:"Tries per delivery before giving up"`
+		BaseDelay     time.Duration `yaml:"base_delay" help:"Wait after the first failed delivery, doubling after each"`
+		Timeout       time.Duration `yaml:"timeout" help:"How long a subscriber gets to answer each delivery"`
+		WatchInterval time.Duration `yaml:"watch_interval" help:"How often to check for a new champion"`
+		QueueSize     int           `yaml:"queue_size" help:"Champion changes waiting to be delivered before new ones are dropped"`
+	} `yaml:"webhooks"`
+}
+
+// defaultGSLConfig is what the server ran with back when all of this was
+// hard-coded in main
+func defaultGSLConfig() *gslConfig {
+	cfg := &gslConfig{
+		Address:         ":8080",
+		RequestTimeout:  defaultRequestTimeout,
+		ShutdownTimeout: defaultShutdownTimeout,
+		CacheControl:    "public, max-age=30",
+		IdempotencyTTL:  24 * time.Hour,
+	}
+
+	cfg.Data.ChampionFile = "./champion.txt"
+	cfg.Data.TournamentDir = "./tournaments"
+	cfg.Data.PlayersFile = "./players.json"
+	cfg.Data.AuditLog = "./champion-audit.log"
+
+	// This was correct as of 2020-07-18
+	cfg.Fallback.LastKnown = "TY"
+
+	// Champion responses are cheap and cacheable, predictions less so
+	cfg.RateLimits.Champion = ratelimit.Limit{Requests: 20, Per: time.Second}
+	cfg.RateLimits.Feed = ratelimit.Limit{Requests: 10, Per: time.Second}
+	cfg.RateLimits.Tournaments = ratelimit.Limit{Requests: 10, Per: time.Second}
+	cfg.RateLimits.Players = ratelimit.Limit{Requests: 10, Per: time.Second}
+	cfg.RateLimits.Predict = ratelimit.Limit{Requests: 5, Per: time.Second}
+
+	cfg.Webhooks.RegistryFile = "./webhooks.json"
+	cfg.Webhooks.MaxAttempts = 5
+	cfg.Webhooks.BaseDelay = time.Second
+	cfg.Webhooks.Timeout = 10 * time.Second
+	cfg.Webhooks.WatchInterval = 5 * time.Second
+	cfg.Webhooks.QueueSize = 100
+
+	return cfg
+}
+
+// Validate catches mistakes before we start, rather than on the first
+// request that trips over them
+func (c *gslConfig) Validate() error {
+	var problems []error
+
+	require := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Errorf(format, args...))
+		}
+	}
+
+	require(c.Address != "", "address is required")
+	require(c.RequestTimeout > 0, "request_timeout must be positive")
+	require(c.ShutdownTimeout > 0, "shutdown_timeout must be positive")
+	require(c.IdempotencyTTL > 0, "idempotency_ttl must be positive")
+	require(c.Data.ChampionFile != "", "data.champion_file is required")
+	require(c.Data.TournamentDir != "", "data.tournament_dir is required")
+	require(c.Data.PlayersFile != "", "data.players_file is required")
+	require(c.Data.AuditLog != "", "data.audit_log is required")
+	require(c.Webhooks.RegistryFile != "", "webhooks.registry_file is required")
+	require(c.Webhooks.MaxAttempts >= 1, "webhooks.max_attempts must be at least 1")
+	require(c.Webhooks.BaseDelay >= 0, "webhooks.base_delay can't be negative")
+	require(c.Webhooks.Timeout > 0, "webhooks.timeout must be positive")
+	require(c.Webhooks.WatchInterval > 0, "webhooks.watch_interval must be positive")
+	require(c.Webhooks.QueueSize >= 1, "webhooks.queue_size must be at least 1")
+
+	if c.Fallback.Upstream != "" {
+		upstream, err := url.Parse(c.Fallback.Upstream)
+
+		require(err == nil && (upstream.Scheme == "http" || upstream.Scheme == "https") && upstream.Host != "",
+			"fallback.upstream %q must be an http or https URL", c.Fallback.Upstream)
+	}
+
+	for pattern, limit := range c.routeRateLimits() {
+		err := limit.Validate()
+
+		require(err == nil, "rate_limits for %s: %v", pattern, err)
+	}
+
+	_, err := parseAdminTokens(c.AdminTokens)
+
+	require(err == nil, "admin_tokens: %v", err)
+
+	return errors.Join(problems...)
+}
+
+// routeRateLimits is the rate limits keyed by the route pattern they apply to
+func (c *gslConfig) routeRateLimits() map[string]ratelimit.Limit {
+	return map[string]ratelimit.Limit{
+		"/champion":       c.RateLimits.Champion,
+		"/champions/feed": c.RateLimits.Feed,
+		"/tournaments/":   c.RateLimits.Tournaments,
+		"/players/":       c.RateLimits.Players,
+		"/predict":        c.RateLimits.Predict,
+	}
+}
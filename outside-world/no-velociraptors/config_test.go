@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Evertras/go-interface-examples/config"
+)
+
+func loadGSLConfig(env map[string]string, args ...string) (*gslConfig, error) {
+	cfg := defaultGSLConfig()
+
+	err := config.Loader{
+		Name:      "gsl",
+		EnvPrefix: "GSL",
+		LookupEnv: func(key string) (string, bool) {
+			value, ok := env[key]
+			return value, ok
+		},
+	}.Load(cfg, args)
+
+	return cfg, err
+}
+
+func TestDefaultGSLConfigIsValid(t *testing.T) {
+	err := defaultGSLConfig().Validate()
+
+	if err != nil {
+		t.Error("Expected the defaults to be valid but got:", err)
+	}
+}
+
+func TestGSLConfigKeepsAdminTokensInTheEnvironment(t *testing.T) {
+	cfg, err := loadGSLConfig(map[string]string{"GSL_ADMIN_TOKENS": "evertras:hunter2"}, "-fallback.upstream", "http://other-gsl/champion")
+
+	if err != nil {
+		t.Fatal("Load:", err)
+	}
+
+	if cfg.AdminTokens != "evertras:hunter2" || cfg.Fallback.Upstream != "http://other-gsl/champion" {
+		t.Errorf("Unexpected config %+v", *cfg)
+	}
+
+	// No flag, so it can't end up in ps
+	_, err = loadGSLConfig(nil, "-admin-tokens", "evertras:hunter2")
+
+	if err == nil {
+		t.Error("Expected admin tokens to be refused as a flag")
+	}
+}
+
+func TestGSLConfigValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		contains string
+	}{
+		{"BadAdminTokens", map[string]string{"GSL_ADMIN_TOKENS": "justatoken"}, nil, "admin_tokens"},
+		{"NoAttempts", nil, []string{"-webhooks.max-attempts", "0"}, "webhooks.max_attempts"},
+		{"UpstreamNotAURL", nil, []string{"-fallback.upstream", "other-gsl"}, "fallback.upstream"},
+		{"NoChampionFile", map[string]string{"GSL_DATA_CHAMPION_FILE": ""}, nil, "data.champion_file"},
+		{"RateLimitWithoutPer", nil, []string{"-rate-limits.predict.per", "0s"}, "rate_limits for /predict"},
+		{"NoTimeout", nil, []string{"-request-timeout", "0s"}, "request_timeout"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadGSLConfig(test.env, test.args...)
+
+			if err == nil || !strings.Contains(err.Error(), test.contains) {
+				t.Errorf("Expected an error about %s but got %v", test.contains, err)
+			}
+		})
+	}
+}
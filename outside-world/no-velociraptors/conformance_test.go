@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/decorate"
+	"github.com/Evertras/go-interface-examples/outside-world/championtest"
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/bracket"
+)
+
+// Every CurrentChampionGetter we have gets run through the same contract,
+// so the mocks in our handler tests aren't promising anything the real
+// things don't do
+
+func writeChampionFixture(t *testing.T, fixture championtest.Fixture) string {
+	t.Helper()
+
+	championFile := filepath.Join(t.TempDir(), "champion.txt")
+
+	if fixture.Missing {
+		return championFile
+	}
+
+	err := os.WriteFile(championFile, []byte(fixture.Contents), 0644)
+
+	if err != nil {
+		t.Fatal("os.WriteFile:", err)
+	}
+
+	return championFile
+}
+
+func TestGSLDataStoreConforms(t *testing.T) {
+	championtest.Run(t, func(t *testing.T, fixture championtest.Fixture) championtest.CurrentChampionGetter {
+		return NewGSLDataStore(writeChampionFixture(t, fixture))
+	})
+}
+
+// Decorators have to keep the contract of whatever they wrap, retries and
+// all
+func TestDecoratedGSLDataStoreConforms(t *testing.T) {
+	championtest.Run(t, func(t *testing.T, fixture championtest.Fixture) championtest.CurrentChampionGetter {
+		stats := decorate.NewStats()
+
+		return NewCurrentChampionGetterDecorator(NewGSLDataStore(writeChampionFixture(t, fixture)),
+			stats.Middleware(),
+			decorate.Retry(2, time.Millisecond, nil),
+		)
+	})
+}
+
+func TestInMemoryChampionStoreConforms(t *testing.T) {
+	championtest.Run(t, func(t *testing.T, fixture championtest.Fixture) championtest.CurrentChampionGetter {
+		// Nothing in memory is the same as never being told anything
+		return NewInMemoryChampionStore(fixture.Contents)
+	})
+}
+
+func TestUpstreamChampionGetterConforms(t *testing.T) {
+	championtest.Run(t, func(t *testing.T, fixture championtest.Fixture) championtest.CurrentChampionGetter {
+		server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			if fixture.Missing {
+				res.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			res.Write([]byte(fixture.Contents))
+		}))
+
+		t.Cleanup(server.Close)
+
+		return NewUpstreamChampionGetter(server.URL, server.Client())
+	})
+}
+
+func TestFallbackChampionGetterConforms(t *testing.T) {
+	championtest.Run(t, func(t *testing.T, fixture championtest.Fixture) championtest.CurrentChampionGetter {
+		// The first source is always broken, so everything here also
+		// proves the fallback passes the second source's answer through
+		// untouched
+		return NewFallbackChampionGetter(
+			ChampionSource{
+				Name:    "broken",
+				Getter:  NewGSLDataStore(filepath.Join(t.TempDir(), "nope.txt")),
+				Timeout: time.Second,
+				Breaker: NewCircuitBreaker(3, time.Minute),
+			},
+			ChampionSource{
+				Name:    "file",
+				Getter:  NewGSLDataStore(writeChampionFixture(t, fixture)),
+				Timeout: time.Second,
+			},
+		)
+	})
+}
+
+func TestBracketChampionGetterConforms(t *testing.T) {
+	championtest.Run(t, func(t *testing.T, fixture championtest.Fixture) championtest.CurrentChampionGetter {
+		dir := t.TempDir()
+
+		// No brackets at all is as missing as it gets
+		if !fixture.Missing {
+			tournament := &bracket.Tournament{
+				ID:   "s1",
+				Date: time.Now(),
+				Stages: []bracket.Stage{{Matches: []bracket.Match{
+					{ID: "final", BestOf: 1, Slots: [2]bracket.Source{bracket.Seed(fixture.Contents), bracket.Seed("Rogue")}},
+				}}},
+				Final: "final",
+			}
+
+			// A blank player can't win anything, which leaves the bracket
+			// without a champion
+			_ = tournament.RecordResult("final", []bracket.MapResult{{Map: "Oxide", Winner: fixture.Contents}})
+
+			writeTestBracket(t, dir, tournament)
+		}
+
+		return NewBracketChampionGetter(NewFileTournamentStore(dir))
+	})
+}
@@ -1,29 +1,236 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 )
 
+// ErrChampionChanged means someone else changed the champion since the
+// caller last looked, so we refused to overwrite their change
+var ErrChampionChanged = errors.New("champion was changed by someone else")
+
 // GSLDataStore knows how to get GSL data
 type GSLDataStore struct {
 	championFile string
+
+	// Only one writer at a time, so compare-and-swap actually means something
+	writeMu sync.Mutex
 }
 
 // NewGSLDataStore returns a GSLDataStore ready to tell us about the GSL
 func NewGSLDataStore(championFile string) *GSLDataStore {
 	return &GSLDataStore{
-		championFile,
+		championFile: championFile,
 	}
 }
 
+// ChampionUpdate describes a change to the current champion
+type ChampionUpdate struct {
+	Previous string
+	Champion string
+
+	// ETag identifies the new champion, for the next optimistic update
+	ETag string
+}
+
+// ChampionVersion identifies which champion we currently have without
+// having to send the whole thing around
+type ChampionVersion struct {
+	ETag         string
+	LastModified time.Time
+}
+
+type readFileResult struct {
+	contents []byte
+	err      error
+}
+
 // GetCurrentChampion returns the name of the current GSL champion
-func (s *GSLDataStore) GetCurrentChampion() (string, error) {
-	contents, err := os.ReadFile(s.championFile)
+func (s *GSLDataStore) GetCurrentChampion(ctx context.Context) (string, error) {
+	// Don't bother touching the disk if nobody wants the answer anymore
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	// Reading a file can't be interrupted, but we can stop waiting on it.
+	// Buffered so the read can always finish and go away on its own.
+	results := make(chan readFileResult, 1)
+
+	go func() {
+		contents, err := os.ReadFile(s.championFile)
+		results <- readFileResult{contents, err}
+	}()
+
+	select {
+	case result := <-results:
+		if result.err != nil {
+			return "", fmt.Errorf("failed to read file: %w", result.err)
+		}
+
+		// Editors love adding a newline at the end, and it's never part
+		// of anyone's handle
+		champion := strings.TrimSpace(string(result.contents))
+
+		if champion == "" {
+			return "", errors.New("champion file is empty")
+		}
+
+		return champion, nil
+
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// GetCurrentChampionVersion returns validators for the current champion
+//
+// The ETag is a hash of the file's contents, which is the same thing
+// SetCurrentChampion checks against.  Both validators come from the one
+// open file, so a champion written in between can't give us one file's
+// hash with another's modification time.  Writes rename a new file over
+// the old one, so the file we opened never changes under us.
+func (s *GSLDataStore) GetCurrentChampionVersion(ctx context.Context) (ChampionVersion, error) {
+	if err := ctx.Err(); err != nil {
+		return ChampionVersion{}, err
+	}
+
+	file, err := os.Open(s.championFile)
+
+	if err != nil {
+		return ChampionVersion{}, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+
+	if err != nil {
+		return ChampionVersion{}, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	contents, err := io.ReadAll(file)
+
+	if err != nil {
+		return ChampionVersion{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return ChampionVersion{
+		ETag:         championETag(contents),
+		LastModified: info.ModTime().UTC(),
+	}, nil
+}
+
+// SetCurrentChampion replaces the current champion
+//
+// If expectedETags is set, the update only happens if the champion we have
+// right now still matches one of them, the way If-Match does.  Only strong
+// ETags can match, and "*" on its own matches any champion at all.  No
+// expectedETags always overwrites.
+//
+// The new file is written next to the old one and renamed over it, so
+// readers only ever see the old champion or the new one and never half a
+// file.
+func (s *GSLDataStore) SetCurrentChampion(ctx context.Context, champion string, expectedETags []string) (ChampionUpdate, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return ChampionUpdate{}, err
+	}
+
+	previous, err := os.ReadFile(s.championFile)
+	exists := err == nil
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return ChampionUpdate{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if len(expectedETags) > 0 && !(exists && etagsMatch(expectedETags, championETag(previous))) {
+		return ChampionUpdate{}, ErrChampionChanged
+	}
+
+	err = writeFileAtomically(s.championFile, []byte(champion), 0644)
+
+	if err != nil {
+		return ChampionUpdate{}, err
+	}
+
+	return ChampionUpdate{
+		Previous: strings.TrimSpace(string(previous)),
+		Champion: champion,
+		ETag:     championETag([]byte(champion)),
+	}, nil
+}
+
+// etagsMatch does the strong comparison If-Match asks for.  Our ETags are
+// never weak, so a weak one can't match.
+func etagsMatch(expectedETags []string, current string) bool {
+	for _, expected := range expectedETags {
+		if expected == "*" || expected == current {
+			return true
+		}
+	}
+
+	return false
+}
+
+// championETag is a strong validator for the champion file's contents
+func championETag(contents []byte) string {
+	sum := sha256.Sum256(contents)
+
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// writeFileAtomically replaces filename with contents, so readers see either
+// the old file or the new one and never half of each.  perm is what the
+// file ends up as, so files holding secrets can stay private.
+func writeFileAtomically(filename string, contents []byte, perm os.FileMode) error {
+	// Has to be in the same directory, renames across file systems
+	// aren't atomic (or even possible)
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
+
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	// Harmless once the rename has happened, cleans up if it didn't
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(contents)
+
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	// CreateTemp is very private by default, which isn't always what we want
+	err = os.Chmod(tmp.Name(), perm)
+
+	if err != nil {
+		return fmt.Errorf("failed to chmod temp file: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), filename)
 
 	if err != nil {
-		return "", fmt.Errorf("failed to read file: %w", err)
+		return fmt.Errorf("failed to replace file: %w", err)
 	}
 
-	return string(contents), nil
+	return nil
 }
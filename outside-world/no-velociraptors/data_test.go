@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// These tests are about GSLDataStore itself, so touching the file system
+// is the whole point.  Notice we make our own file in a temp dir rather than
+// leaning on champion.txt, so nothing here cares what that file says.
+// Reading is covered by the conformance suite, so these are about writing.
+func TestGSLDataStoreSetsChampionOnlyWhenETagMatches(t *testing.T) {
+	championFile := filepath.Join(t.TempDir(), "champion.txt")
+
+	err := os.WriteFile(championFile, []byte("TY"), 0644)
+
+	if err != nil {
+		t.Fatal("os.WriteFile:", err)
+	}
+
+	dataStore := NewGSLDataStore(championFile)
+	ctx := context.Background()
+
+	update, err := dataStore.SetCurrentChampion(ctx, "Maru", []string{championETag([]byte("TY"))})
+
+	if err != nil {
+		t.Fatal("dataStore.SetCurrentChampion:", err)
+	}
+
+	if update.Previous != "TY" || update.Champion != "Maru" {
+		t.Errorf("Unexpected update %+v", update)
+	}
+
+	// Somebody still thinks TY is the champion, they should be told no
+	_, err = dataStore.SetCurrentChampion(ctx, "Rogue", []string{championETag([]byte("TY"))})
+
+	if !errors.Is(err, ErrChampionChanged) {
+		t.Errorf("Expected ErrChampionChanged but got %v", err)
+	}
+
+	_, err = dataStore.SetCurrentChampion(ctx, "Rogue", []string{update.ETag})
+
+	if err != nil {
+		t.Fatal("dataStore.SetCurrentChampion:", err)
+	}
+
+	champion, err := dataStore.GetCurrentChampion(ctx)
+
+	if err != nil {
+		t.Fatal("dataStore.GetCurrentChampion:", err)
+	}
+
+	if champion != "Rogue" {
+		t.Errorf("Expected champion %q but got %q", "Rogue", champion)
+	}
+}
+
+func TestGSLDataStoreMatchesAnyStrongETagInTheList(t *testing.T) {
+	championFile := filepath.Join(t.TempDir(), "champion.txt")
+
+	err := os.WriteFile(championFile, []byte("TY"), 0644)
+
+	if err != nil {
+		t.Fatal("os.WriteFile:", err)
+	}
+
+	dataStore := NewGSLDataStore(championFile)
+	ctx := context.Background()
+	current := championETag([]byte("TY"))
+
+	// Weak ETags never match for If-Match, even when the tag is right
+	_, err = dataStore.SetCurrentChampion(ctx, "Maru", []string{"W/" + current})
+
+	if !errors.Is(err, ErrChampionChanged) {
+		t.Errorf("Expected ErrChampionChanged for a weak ETag but got %v", err)
+	}
+
+	_, err = dataStore.SetCurrentChampion(ctx, "Maru", []string{`"stale"`, current})
+
+	if err != nil {
+		t.Errorf("Expected a list with the current ETag in it to match but got %v", err)
+	}
+}
+
+func TestGSLDataStoreWildcardETagRequiresExistingChampion(t *testing.T) {
+	dataStore := NewGSLDataStore(filepath.Join(t.TempDir(), "champion.txt"))
+
+	_, err := dataStore.SetCurrentChampion(context.Background(), "Maru", []string{"*"})
+
+	if !errors.Is(err, ErrChampionChanged) {
+		t.Errorf("Expected ErrChampionChanged but got %v", err)
+	}
+}
+
+func TestGSLDataStoreLeavesNoTempFilesBehind(t *testing.T) {
+	dir := t.TempDir()
+	dataStore := NewGSLDataStore(filepath.Join(dir, "champion.txt"))
+
+	_, err := dataStore.SetCurrentChampion(context.Background(), "Maru", nil)
+
+	if err != nil {
+		t.Fatal("dataStore.SetCurrentChampion:", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		t.Fatal("os.ReadDir:", err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("Expected only champion.txt in the directory but found %d entries", len(entries))
+	}
+}
+
+func TestGSLDataStoreVersionMatchesWhatSetCurrentChampionChecks(t *testing.T) {
+	dataStore := NewGSLDataStore(filepath.Join(t.TempDir(), "champion.txt"))
+	ctx := context.Background()
+
+	update, err := dataStore.SetCurrentChampion(ctx, "Maru", nil)
+
+	if err != nil {
+		t.Fatal("dataStore.SetCurrentChampion:", err)
+	}
+
+	version, err := dataStore.GetCurrentChampionVersion(ctx)
+
+	if err != nil {
+		t.Fatal("dataStore.GetCurrentChampionVersion:", err)
+	}
+
+	if version.ETag != update.ETag {
+		t.Errorf("Expected ETag %s but got %s", update.ETag, version.ETag)
+	}
+
+	if version.LastModified.IsZero() {
+		t.Error("Expected LastModified to be set")
+	}
+}
+
+func TestGSLDataStoreVersionIsAlwaysOneWholeChampion(t *testing.T) {
+	dataStore := NewGSLDataStore(filepath.Join(t.TempDir(), "champion.txt"))
+	ctx := context.Background()
+
+	champions := []string{"TY", "Maru"}
+	known := map[string]bool{}
+
+	for _, champion := range champions {
+		known[championETag([]byte(champion))] = true
+	}
+
+	if _, err := dataStore.SetCurrentChampion(ctx, "TY", nil); err != nil {
+		t.Fatal("dataStore.SetCurrentChampion:", err)
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			dataStore.SetCurrentChampion(ctx, champions[i%2], nil)
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		version, err := dataStore.GetCurrentChampionVersion(ctx)
+
+		if err != nil {
+			t.Fatal("dataStore.GetCurrentChampionVersion:", err)
+		}
+
+		if !known[version.ETag] {
+			t.Fatalf("Expected the ETag of a whole champion but got %s", version.ETag)
+		}
+	}
+
+	<-done
+}
@@ -0,0 +1,47 @@
+// Code generated by localdecorate; DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+
+	"github.com/Evertras/go-interface-examples/decorate"
+)
+
+// CurrentChampionGetterDecorator runs every CurrentChampionGetter call through middleware, and
+// satisfies CurrentChampionGetter itself so nobody using it can tell
+type CurrentChampionGetterDecorator struct {
+	next       CurrentChampionGetter
+	middleware decorate.Middleware
+}
+
+// NewCurrentChampionGetterDecorator wraps next so every call goes through the middleware,
+// the first one outermost
+func NewCurrentChampionGetterDecorator(next CurrentChampionGetter, middleware ...decorate.Middleware) *CurrentChampionGetterDecorator {
+	return &CurrentChampionGetterDecorator{
+		next:       next,
+		middleware: decorate.Chain(middleware...),
+	}
+}
+
+// GetCurrentChampion calls through to the wrapped CurrentChampionGetter
+func (d *CurrentChampionGetterDecorator) GetCurrentChampion(ctx context.Context) (string, error) {
+	var result0 string
+
+	call := decorate.Call{
+		Interface: "CurrentChampionGetter",
+		Method:    "GetCurrentChampion",
+		Args:      []interface{}{},
+		Results:   []interface{}{&result0},
+	}
+
+	err := d.middleware(ctx, call, func(ctx context.Context) error {
+		var err error
+
+		result0, err = d.next.GetCurrentChampion(ctx)
+
+		return err
+	})
+
+	return result0, err
+}
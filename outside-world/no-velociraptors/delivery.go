@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ChampionChange is a new champion being crowned
+type ChampionChange struct {
+	Previous string    `json:"previous"`
+	Champion string    `json:"champion"`
+	Time     time.Time `json:"time"`
+}
+
+// WebhookSubscriptionLister can tell us who wants webhooks
+//
+// Delivery only needs to read subscriptions, so that's all it gets.
+type WebhookSubscriptionLister interface {
+	ListSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+}
+
+// Headers sent with every webhook delivery
+const (
+	webhookEventHeader     = "X-GSL-Event"
+	webhookDeliveryHeader  = "X-GSL-Delivery"
+	webhookTimestampHeader = "X-GSL-Timestamp"
+	webhookSignatureHeader = "X-GSL-Signature"
+
+	championChangedEvent = "champion.changed"
+)
+
+type webhookPayload struct {
+	Event string `json:"event"`
+	ChampionChange
+}
+
+// WebhookDispatcher sends champion changes to everyone who subscribed
+//
+// Each delivery is signed with the subscriber's secret so they know it
+// really came from us, and retried with exponential backoff if their end
+// is having a bad day.
+type WebhookDispatcher struct {
+	subscriptionLister WebhookSubscriptionLister
+	client             *http.Client
+
+	maxAttempts int
+	baseDelay   time.Duration
+
+	// Injected so tests don't have to actually wait around
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	logger *slog.Logger
+
+	// Optional, finds out how every delivery went once retries are done
+	notificationObserver NotificationObserver
+}
+
+// NotificationObserver finds out whether each notification made it
+type NotificationObserver interface {
+	ObserveNotification(channel string, err error)
+}
+
+// NewWebhookDispatcher returns a WebhookDispatcher that tries each delivery
+// up to maxAttempts times, doubling the wait after each failure
+func NewWebhookDispatcher(subscriptionLister WebhookSubscriptionLister, client *http.Client, maxAttempts int, baseDelay time.Duration, logger *slog.Logger) *WebhookDispatcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return &WebhookDispatcher{
+		subscriptionLister: subscriptionLister,
+		client:             client,
+		maxAttempts:        maxAttempts,
+		baseDelay:          baseDelay,
+		logger:             logger,
+		now:                time.Now,
+		sleep:              sleepContext,
+	}
+}
+
+// WithNotificationObserver tells the observer about every delivery
+func (d *WebhookDispatcher) WithNotificationObserver(observer NotificationObserver) *WebhookDispatcher {
+	d.notificationObserver = observer
+
+	return d
+}
+
+// NotifyChampionChanged delivers the change to every subscriber, and only
+// returns once every delivery has either worked or run out of retries
+func (d *WebhookDispatcher) NotifyChampionChanged(ctx context.Context, change ChampionChange) {
+	subscriptions, err := d.subscriptionLister.ListSubscriptions(ctx)
+
+	if err != nil {
+		d.logger.ErrorContext(ctx, "Failed to list webhook subscriptions", "error", err)
+		return
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		Event:          championChangedEvent,
+		ChampionChange: change,
+	})
+
+	if err != nil {
+		d.logger.ErrorContext(ctx, "Failed to encode webhook payload", "error", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+
+	for _, subscription := range subscriptions {
+		wg.Add(1)
+
+		go func(subscription WebhookSubscription) {
+			defer wg.Done()
+
+			err := d.deliver(ctx, subscription, body)
+
+			if d.notificationObserver != nil {
+				d.notificationObserver.ObserveNotification("webhook", err)
+			}
+
+			if err != nil {
+				d.logger.ErrorContext(ctx, "Giving up on webhook", "webhook", subscription.ID, "url", subscription.URL, "error", err)
+			}
+		}(subscription)
+	}
+
+	wg.Wait()
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, subscription WebhookSubscription, body []byte) error {
+	deliveryID, err := newWebhookID()
+
+	if err != nil {
+		return err
+	}
+
+	delay := d.baseDelay
+
+	for attempt := 1; ; attempt++ {
+		retry, err := d.attempt(ctx, subscription, deliveryID, body)
+
+		if err == nil {
+			return nil
+		}
+
+		if !retry || attempt >= d.maxAttempts {
+			return fmt.Errorf("attempt %d: %w", attempt, err)
+		}
+
+		err = d.sleep(ctx, delay)
+
+		if err != nil {
+			return err
+		}
+
+		delay *= 2
+	}
+}
+
+// attempt makes a single delivery, and reports whether it's worth trying again
+func (d *WebhookDispatcher) attempt(ctx context.Context, subscription WebhookSubscription, deliveryID string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+
+	if err != nil {
+		return false, fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, championChangedEvent)
+	req.Header.Set(webhookDeliveryHeader, deliveryID)
+	req.Header.Set(webhookTimestampHeader, timestamp)
+	req.Header.Set(webhookSignatureHeader, signWebhook(subscription.Secret, timestamp, body))
+
+	res, err := d.client.Do(req)
+
+	if err != nil {
+		// Network trouble is usually temporary, unless we gave up ourselves
+		return ctx.Err() == nil, err
+	}
+
+	defer res.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+
+	// Their server is struggling or asked us to back off, so try again later.
+	// Anything else in the 4xx range means retrying won't help.
+	retry := res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests
+
+	return retry, fmt.Errorf("subscriber returned status %d", res.StatusCode)
+}
+
+// signWebhook signs the timestamp and body together, so a captured delivery
+// can't be replayed later with a fresh timestamp
+func signWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockWebhookSubscriptionLister struct {
+	pendingSubscriptions []WebhookSubscription
+	pendingError         error
+}
+
+func (l *mockWebhookSubscriptionLister) ListSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	return l.pendingSubscriptions, l.pendingError
+}
+
+// A subscriber that fails the first few deliveries and records what it got
+type flakySubscriber struct {
+	failuresLeft int
+	failWith     int
+
+	mu       sync.Mutex
+	attempts int
+	received []*http.Request
+	bodies   [][]byte
+}
+
+func (s *flakySubscriber) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts++
+	s.received = append(s.received, req)
+	s.bodies = append(s.bodies, body)
+
+	if s.failuresLeft > 0 {
+		s.failuresLeft--
+		res.WriteHeader(s.failWith)
+		return
+	}
+
+	res.WriteHeader(204)
+}
+
+func newTestDispatcher(t *testing.T, subscriber *flakySubscriber, maxAttempts int) (*WebhookDispatcher, *[]time.Duration) {
+	server := httptest.NewServer(subscriber)
+	t.Cleanup(server.Close)
+
+	lister := &mockWebhookSubscriptionLister{
+		pendingSubscriptions: []WebhookSubscription{
+			{ID: "sub-1", URL: server.URL, Secret: "a-very-secret-secret"},
+		},
+	}
+
+	dispatcher := NewWebhookDispatcher(lister, server.Client(), maxAttempts, time.Second, testLogger)
+
+	var sleeps []time.Duration
+
+	dispatcher.now = func() time.Time {
+		return time.Unix(1595030400, 0)
+	}
+
+	dispatcher.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+
+	return dispatcher, &sleeps
+}
+
+var testChampionChange = ChampionChange{
+	Previous: "TY",
+	Champion: "Rogue",
+	Time:     time.Date(2020, 10, 3, 0, 0, 0, 0, time.UTC),
+}
+
+func TestWebhookDispatcherSignsDeliveries(t *testing.T) {
+	subscriber := &flakySubscriber{}
+	dispatcher, _ := newTestDispatcher(t, subscriber, 1)
+
+	dispatcher.NotifyChampionChanged(context.Background(), testChampionChange)
+
+	if len(subscriber.received) != 1 {
+		t.Fatalf("Expected %d delivery but got %d", 1, len(subscriber.received))
+	}
+
+	req := subscriber.received[0]
+	body := subscriber.bodies[0]
+
+	expectedSignature := signWebhook("a-very-secret-secret", "1595030400", body)
+
+	if req.Header.Get(webhookSignatureHeader) != expectedSignature {
+		t.Errorf("Expected signature %q but got %q", expectedSignature, req.Header.Get(webhookSignatureHeader))
+	}
+
+	if req.Header.Get(webhookEventHeader) != championChangedEvent {
+		t.Errorf("Expected event %q but got %q", championChangedEvent, req.Header.Get(webhookEventHeader))
+	}
+
+	var payload webhookPayload
+
+	err := json.Unmarshal(body, &payload)
+
+	if err != nil {
+		t.Fatal("json.Unmarshal:", err)
+	}
+
+	if payload.Champion != "Rogue" || payload.Previous != "TY" {
+		t.Errorf("Unexpected payload %+v", payload)
+	}
+}
+
+func TestWebhookDispatcherRetriesWithBackoff(t *testing.T) {
+	subscriber := &flakySubscriber{failuresLeft: 2, failWith: 503}
+	dispatcher, sleeps := newTestDispatcher(t, subscriber, 5)
+
+	dispatcher.NotifyChampionChanged(context.Background(), testChampionChange)
+
+	if subscriber.attempts != 3 {
+		t.Errorf("Expected %d attempts but got %d", 3, subscriber.attempts)
+	}
+
+	expectedSleeps := []time.Duration{time.Second, 2 * time.Second}
+
+	if len(*sleeps) != len(expectedSleeps) {
+		t.Fatalf("Expected sleeps %v but got %v", expectedSleeps, *sleeps)
+	}
+
+	for i := range expectedSleeps {
+		if (*sleeps)[i] != expectedSleeps[i] {
+			t.Errorf("Expected sleeps %v but got %v", expectedSleeps, *sleeps)
+		}
+	}
+
+	// Retries are the same delivery, so subscribers can drop duplicates
+	firstID := subscriber.received[0].Header.Get(webhookDeliveryHeader)
+
+	for _, req := range subscriber.received {
+		if req.Header.Get(webhookDeliveryHeader) != firstID {
+			t.Error("Expected every retry to share a delivery ID")
+		}
+	}
+}
+
+func TestWebhookDispatcherGivesUpAfterMaxAttempts(t *testing.T) {
+	subscriber := &flakySubscriber{failuresLeft: 10, failWith: 500}
+	dispatcher, _ := newTestDispatcher(t, subscriber, 3)
+
+	dispatcher.NotifyChampionChanged(context.Background(), testChampionChange)
+
+	if subscriber.attempts != 3 {
+		t.Errorf("Expected %d attempts but got %d", 3, subscriber.attempts)
+	}
+}
+
+func TestWebhookDispatcherDoesntRetryClientErrors(t *testing.T) {
+	subscriber := &flakySubscriber{failuresLeft: 10, failWith: 410}
+	dispatcher, _ := newTestDispatcher(t, subscriber, 5)
+
+	dispatcher.NotifyChampionChanged(context.Background(), testChampionChange)
+
+	if subscriber.attempts != 1 {
+		t.Errorf("Expected %d attempt but got %d", 1, subscriber.attempts)
+	}
+}
+
+type mockNotificationObserver struct {
+	mu       sync.Mutex
+	channels []string
+	errs     []error
+}
+
+func (o *mockNotificationObserver) ObserveNotification(channel string, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.channels = append(o.channels, channel)
+	o.errs = append(o.errs, err)
+}
+
+func TestWebhookDispatcherReportsEachDeliveryOnce(t *testing.T) {
+	tests := []struct {
+		name       string
+		subscriber *flakySubscriber
+		fails      bool
+	}{
+		{"SentAfterRetries", &flakySubscriber{failuresLeft: 2, failWith: 500}, false},
+		{"Failed", &flakySubscriber{failuresLeft: 10, failWith: 410}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			observer := &mockNotificationObserver{}
+			dispatcher, _ := newTestDispatcher(t, test.subscriber, 5)
+
+			dispatcher.WithNotificationObserver(observer).NotifyChampionChanged(context.Background(), testChampionChange)
+
+			if len(observer.channels) != 1 || observer.channels[0] != "webhook" {
+				t.Fatalf("Expected one webhook notification but got %v", observer.channels)
+			}
+
+			if (observer.errs[0] != nil) != test.fails {
+				t.Errorf("Expected failure to be %v but got error %v", test.fails, observer.errs[0])
+			}
+		})
+	}
+}
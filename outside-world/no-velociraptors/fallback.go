@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ChampionSource is one place we can ask for the current champion
+type ChampionSource struct {
+	// Name is reported back to clients so they know who answered
+	Name string
+
+	// Getter is where the champion actually comes from
+	Getter CurrentChampionGetter
+
+	// Timeout is how long we're willing to wait for this source before
+	// moving on to the next one.  Zero means wait as long as the caller's
+	// context lets us.
+	Timeout time.Duration
+
+	// Breaker is optional.  If it's set, we'll skip this source entirely
+	// while the breaker is open instead of waiting on it to fail again.
+	Breaker *CircuitBreaker
+}
+
+// FallbackChampionGetter tries a list of sources in order until one of
+// them knows who the champion is
+//
+// Notice this is itself a CurrentChampionGetter, and every source it uses
+// is just a CurrentChampionGetter too.  The handler doesn't know or care
+// that it's talking to several data stores instead of one.
+type FallbackChampionGetter struct {
+	sources []ChampionSource
+}
+
+// NewFallbackChampionGetter returns a FallbackChampionGetter that tries the
+// given sources in the order they're given
+func NewFallbackChampionGetter(sources ...ChampionSource) *FallbackChampionGetter {
+	return &FallbackChampionGetter{
+		sources: sources,
+	}
+}
+
+// GetCurrentChampion returns the champion from the first source that answers
+func (g *FallbackChampionGetter) GetCurrentChampion(ctx context.Context) (string, error) {
+	champion, _, err := g.GetCurrentChampionWithSource(ctx)
+
+	return champion, err
+}
+
+// GetCurrentChampionWithSource returns the champion along with the name of
+// the source that answered
+func (g *FallbackChampionGetter) GetCurrentChampionWithSource(ctx context.Context) (string, string, error) {
+	if len(g.sources) == 0 {
+		return "", "", errors.New("no champion sources configured")
+	}
+
+	failures := make([]string, 0, len(g.sources))
+
+	for _, source := range g.sources {
+		// If the caller has given up there's no point asking anyone else
+		if err := ctx.Err(); err != nil {
+			return "", "", err
+		}
+
+		champion, err := source.get(ctx)
+
+		if err == nil {
+			return champion, source.Name, nil
+		}
+
+		// Same deal if the caller gave up while this source was working.
+		// Don't blame the source for it either.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", "", fmt.Errorf("%s: %w", source.Name, ctxErr)
+		}
+
+		failures = append(failures, fmt.Sprintf("%s: %v", source.Name, err))
+	}
+
+	return "", "", fmt.Errorf("all champion sources failed: %s", strings.Join(failures, "; "))
+}
+
+func (s ChampionSource) get(ctx context.Context) (string, error) {
+	if s.Breaker != nil && !s.Breaker.Allow() {
+		return "", ErrCircuitOpen
+	}
+
+	champion, err := s.getWithTimeout(ctx)
+
+	if s.Breaker != nil {
+		switch {
+		case err == nil:
+			s.Breaker.Success()
+
+		case ctx.Err() != nil:
+			// The caller gave up, which says nothing about this source.
+			// Give the trial back so the breaker doesn't get stuck.
+			s.Breaker.Release()
+
+		default:
+			s.Breaker.Failure()
+		}
+	}
+
+	return champion, err
+}
+
+type championResult struct {
+	champion string
+	err      error
+}
+
+func (s ChampionSource) getWithTimeout(ctx context.Context) (string, error) {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	// Well behaved sources stop when the context is done, but we don't want
+	// a badly behaved one to hold up the whole chain.  Buffered so the
+	// goroutine can always finish and go away, even if we've stopped waiting.
+	results := make(chan championResult, 1)
+
+	go func() {
+		champion, err := s.Getter.GetCurrentChampion(ctx)
+		results <- championResult{champion, err}
+	}()
+
+	select {
+	case result := <-results:
+		return result.champion, result.err
+
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
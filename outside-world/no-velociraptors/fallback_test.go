@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Like mockCurrentChampionGetter, but it can also be slow and counts how
+// often it was asked.  It rudely ignores the context while it sleeps, so
+// we can check that the fallback doesn't wait on it anyway.
+type slowChampionGetter struct {
+	current      string
+	pendingError error
+	delay        time.Duration
+
+	calls int
+}
+
+func (g *slowChampionGetter) GetCurrentChampion(ctx context.Context) (string, error) {
+	g.calls++
+
+	time.Sleep(g.delay)
+
+	if g.pendingError != nil {
+		return "", g.pendingError
+	}
+
+	return g.current, nil
+}
+
+func TestFallbackUsesFirstSourceThatAnswers(t *testing.T) {
+	getter := NewFallbackChampionGetter(
+		ChampionSource{
+			Name:   "primary",
+			Getter: &mockCurrentChampionGetter{pendingError: errors.New("nope")},
+		},
+		ChampionSource{
+			Name:   "secondary",
+			Getter: &mockCurrentChampionGetter{current: "Maru"},
+		},
+		ChampionSource{
+			Name:   "tertiary",
+			Getter: &mockCurrentChampionGetter{current: "Rogue"},
+		},
+	)
+
+	champion, source, err := getter.GetCurrentChampionWithSource(context.Background())
+
+	if err != nil {
+		t.Fatal("getter.GetCurrentChampionWithSource:", err)
+	}
+
+	if champion != "Maru" {
+		t.Errorf("Expected champion %q but got %q", "Maru", champion)
+	}
+
+	if source != "secondary" {
+		t.Errorf("Expected source %q but got %q", "secondary", source)
+	}
+}
+
+func TestFallbackErrorsWhenEverySourceFails(t *testing.T) {
+	getter := NewFallbackChampionGetter(
+		ChampionSource{
+			Name:   "primary",
+			Getter: &mockCurrentChampionGetter{pendingError: errors.New("nope")},
+		},
+		ChampionSource{
+			Name:   "secondary",
+			Getter: &mockCurrentChampionGetter{pendingError: errors.New("also nope")},
+		},
+	)
+
+	_, err := getter.GetCurrentChampion(context.Background())
+
+	if err == nil {
+		t.Fatal("Expected an error but didn't get one")
+	}
+}
+
+func TestFallbackMovesOnWhenSourceTimesOut(t *testing.T) {
+	getter := NewFallbackChampionGetter(
+		ChampionSource{
+			Name:    "slow",
+			Getter:  &slowChampionGetter{current: "Zest", delay: time.Second},
+			Timeout: 10 * time.Millisecond,
+		},
+		ChampionSource{
+			Name:   "fast",
+			Getter: &mockCurrentChampionGetter{current: "TY"},
+		},
+	)
+
+	champion, source, err := getter.GetCurrentChampionWithSource(context.Background())
+
+	if err != nil {
+		t.Fatal("getter.GetCurrentChampionWithSource:", err)
+	}
+
+	if champion != "TY" || source != "fast" {
+		t.Errorf("Expected TY from fast but got %q from %q", champion, source)
+	}
+}
+
+func TestFallbackSkipsSourceWithOpenBreaker(t *testing.T) {
+	broken := &slowChampionGetter{pendingError: errors.New("on fire")}
+
+	getter := NewFallbackChampionGetter(
+		ChampionSource{
+			Name:    "broken",
+			Getter:  broken,
+			Breaker: NewCircuitBreaker(2, time.Hour),
+		},
+		ChampionSource{
+			Name:   "backup",
+			Getter: &mockCurrentChampionGetter{current: "TY"},
+		},
+	)
+
+	for i := 0; i < 5; i++ {
+		_, err := getter.GetCurrentChampion(context.Background())
+
+		if err != nil {
+			t.Fatal("getter.GetCurrentChampion:", err)
+		}
+	}
+
+	if broken.calls != 2 {
+		t.Errorf("Expected broken source to be called %d times but it was called %d times", 2, broken.calls)
+	}
+}
+
+func TestGSLCurrentChampionReportsSource(t *testing.T) {
+	getter := NewFallbackChampionGetter(
+		ChampionSource{
+			Name:   "primary",
+			Getter: &mockCurrentChampionGetter{pendingError: errors.New("nope")},
+		},
+		ChampionSource{
+			Name:   "backup",
+			Getter: &mockCurrentChampionGetter{current: "TY"},
+		},
+	)
+
+	req := httptest.NewRequest("GET", "/champion", nil)
+	res := httptest.NewRecorder()
+
+	handler := gslCurrentChampionHandler(getter, testLogger)
+
+	handler(res, req)
+
+	if res.Code != 200 {
+		t.Errorf("Expected code 200 but got %d", res.Code)
+	}
+
+	gotSource := res.Header().Get(championSourceHeader)
+
+	if gotSource != "backup" {
+		t.Errorf("Expected source header %q but got %q", "backup", gotSource)
+	}
+}
+
+func TestFallbackStopsWhenCallerGivesUp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	backup := &slowChampionGetter{current: "TY"}
+	breaker := NewCircuitBreaker(1, time.Hour)
+
+	getter := NewFallbackChampionGetter(
+		ChampionSource{
+			Name:    "primary",
+			Getter:  &mockCurrentChampionGetter{current: "Maru"},
+			Breaker: breaker,
+		},
+		ChampionSource{
+			Name:   "backup",
+			Getter: backup,
+		},
+	)
+
+	_, err := getter.GetCurrentChampion(ctx)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled but got %v", err)
+	}
+
+	if backup.calls != 0 {
+		t.Errorf("Expected backup to never be asked but it was asked %d times", backup.calls)
+	}
+
+	if breaker.currentState() != circuitClosed {
+		t.Errorf("Expected breaker to stay closed but it was %v", breaker.currentState())
+	}
+}
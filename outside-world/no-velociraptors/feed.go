@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// ChampionHistoryGetter can tell us who the champion used to be
+type ChampionHistoryGetter interface {
+	GetChampionHistory(ctx context.Context, limit int) ([]ChampionAuditEntry, error)
+}
+
+// feedEntryLimit is how many past champions end up in the feed
+const feedEntryLimit = 50
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Author  atomAuthor `xml:"author"`
+	Content string     `xml:"content"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// Creates a handler that serves champion history as an Atom feed
+//
+// Feed readers can poll this instead of /champion, and they get to see
+// every change instead of just the latest.
+func gslChampionFeedHandler(championHistoryGetter ChampionHistoryGetter, logger *slog.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		history, err := championHistoryGetter.GetChampionHistory(req.Context(), feedEntryLimit)
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "Failed to get champion history", "error", err)
+			writeDataStoreError(res, err)
+			return
+		}
+
+		feed := buildChampionFeed(feedBaseURL(req), history)
+
+		writeData(res, req, logger, atomData, http.StatusOK, feed)
+	}
+}
+
+func feedBaseURL(req *http.Request) string {
+	scheme := "http"
+
+	if req.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + req.Host
+}
+
+// buildChampionFeed turns history (newest first) into an Atom feed
+func buildChampionFeed(baseURL string, history []ChampionAuditEntry) atomFeed {
+	feedURL := baseURL + "/champions/feed"
+
+	// Atom insists on an updated time even for an empty feed.  The start of
+	// time is honest: nothing has happened yet.
+	updated := time.Unix(0, 0).UTC()
+
+	if len(history) > 0 {
+		updated = history[0].Time.UTC()
+	}
+
+	feed := atomFeed{
+		Xmlns:   atomNamespace,
+		ID:      feedURL,
+		Title:   "GSL Champions",
+		Updated: updated.Format(time.RFC3339),
+		Link: []atomLink{
+			{Href: feedURL, Rel: "self"},
+			{Href: baseURL + "/champion"},
+		},
+		Author: atomAuthor{Name: "GSL Server"},
+	}
+
+	for i, entry := range history {
+		// The watcher recorded an admin's change just before the admin's
+		// own entry landed.  The admin's entry says who did it, so it wins.
+		if i > 0 && entry.Actor == outsideAPIActor && sameChange(history[i-1], entry) {
+			continue
+		}
+
+		content := fmt.Sprintf("%s is the new GSL champion", entry.Champion)
+
+		if entry.Previous != "" {
+			content = fmt.Sprintf("%s took the title from %s", entry.Champion, entry.Previous)
+		}
+
+		feed.Entries = append(feed.Entries, atomEntry{
+			// Unique as long as nobody crowns two champions in the same
+			// nanosecond, which seems safe
+			ID:      fmt.Sprintf("%s#%d", feedURL, entry.Time.UnixNano()),
+			Title:   fmt.Sprintf("%s is the GSL champion", entry.Champion),
+			Updated: entry.Time.UTC().Format(time.RFC3339),
+			Author:  atomAuthor{Name: entry.Actor},
+			Content: content,
+		})
+	}
+
+	return feed
+}
+
+func sameChange(a ChampionAuditEntry, b ChampionAuditEntry) bool {
+	return a.Previous == b.Previous && a.Champion == b.Champion
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type mockChampionHistoryGetter struct {
+	pendingHistory []ChampionAuditEntry
+	pendingError   error
+}
+
+func (g *mockChampionHistoryGetter) GetChampionHistory(ctx context.Context, limit int) ([]ChampionAuditEntry, error) {
+	return g.pendingHistory, g.pendingError
+}
+
+func TestChampionFeedListsHistory(t *testing.T) {
+	historyGetter := &mockChampionHistoryGetter{
+		pendingHistory: []ChampionAuditEntry{
+			{Time: time.Date(2020, 10, 3, 0, 0, 0, 0, time.UTC), Actor: "evertras", Previous: "TY", Champion: "Rogue"},
+			{Time: time.Date(2020, 7, 18, 0, 0, 0, 0, time.UTC), Actor: "evertras", Champion: "TY"},
+		},
+	}
+
+	req := httptest.NewRequest("GET", "http://gsl.example.com/champions/feed", nil)
+	res := httptest.NewRecorder()
+
+	gslChampionFeedHandler(historyGetter, testLogger)(res, req)
+
+	if res.Code != 200 {
+		t.Fatalf("Expected code 200 but got %d", res.Code)
+	}
+
+	if res.Header().Get("Content-Type") != "application/atom+xml; charset=utf-8" {
+		t.Errorf("Unexpected content type %q", res.Header().Get("Content-Type"))
+	}
+
+	var feed atomFeed
+
+	err := xml.Unmarshal(res.Body.Bytes(), &feed)
+
+	if err != nil {
+		t.Fatal("xml.Unmarshal:", err)
+	}
+
+	if feed.ID != "http://gsl.example.com/champions/feed" {
+		t.Errorf("Unexpected feed ID %q", feed.ID)
+	}
+
+	if feed.Updated != "2020-10-03T00:00:00Z" {
+		t.Errorf("Expected feed to be updated at the latest change but got %q", feed.Updated)
+	}
+
+	if len(feed.Entries) != 2 {
+		t.Fatalf("Expected %d entries but got %d", 2, len(feed.Entries))
+	}
+
+	if feed.Entries[0].Content != "Rogue took the title from TY" {
+		t.Errorf("Unexpected first entry content %q", feed.Entries[0].Content)
+	}
+
+	if feed.Entries[0].ID == feed.Entries[1].ID {
+		t.Error("Expected entries to have unique IDs")
+	}
+}
+
+func TestChampionFeedReturns500WhenHistoryFails(t *testing.T) {
+	historyGetter := &mockChampionHistoryGetter{
+		pendingError: errors.New("lost it"),
+	}
+
+	req := httptest.NewRequest("GET", "/champions/feed", nil)
+	res := httptest.NewRecorder()
+
+	gslChampionFeedHandler(historyGetter, testLogger)(res, req)
+
+	if res.Code != 500 {
+		t.Errorf("Expected code 500 but got %d", res.Code)
+	}
+}
+
+func TestChampionFeedDropsChangesRecordedTwice(t *testing.T) {
+	feed := buildChampionFeed("http://gsl.example.com", []ChampionAuditEntry{
+		{Time: time.Date(2020, 10, 3, 0, 0, 1, 0, time.UTC), Actor: "evertras", Previous: "TY", Champion: "Rogue"},
+		{Time: time.Date(2020, 10, 3, 0, 0, 0, 0, time.UTC), Actor: outsideAPIActor, Previous: "TY", Champion: "Rogue"},
+		{Time: time.Date(2020, 7, 18, 0, 0, 0, 0, time.UTC), Actor: outsideAPIActor, Previous: "Maru", Champion: "TY"},
+	})
+
+	if len(feed.Entries) != 2 {
+		t.Fatalf("Expected %d entries but got %+v", 2, feed.Entries)
+	}
+
+	if feed.Entries[0].Author.Name != "evertras" || feed.Entries[1].Author.Name != outsideAPIActor {
+		t.Errorf("Expected the admin's entry and the hand edit but got %+v", feed.Entries)
+	}
+}
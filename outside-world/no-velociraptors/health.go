@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/Evertras/go-interface-examples/graceful"
+)
+
+// healthzHandler says the process is up and able to answer at all
+//
+// This deliberately checks nothing else.  If the data store is down,
+// restarting us won't fix it.
+func healthzHandler(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Cache-Control", "no-store")
+	res.Write([]byte("ok"))
+}
+
+// readyzHandler says whether we can actually serve champions right now
+//
+// The probe is the real data store, not the fallback chain.  The chain
+// never fails, which is the point of it, but it also means it would never
+// tell anyone that the file has gone missing.  With no probe at all,
+// being up is being ready.
+func readyzHandler(readinessProbe CurrentChampionGetter, logger *slog.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Cache-Control", "no-store")
+
+		if readinessProbe != nil {
+			_, err := readinessProbe.GetCurrentChampion(req.Context())
+
+			if err != nil {
+				logger.WarnContext(req.Context(), "Not ready, failed to get current champion", "error", err)
+				res.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		res.Write([]byte("ok"))
+	}
+}
+
+// serve runs the server on the listener until ctx is done, then gives
+// requests already in flight until the shutdown timeout to finish
+//
+// Taking a listener rather than an address lets tests use whatever port is
+// free.
+func serve(ctx context.Context, listener net.Listener, config serverConfig, deps serverDependencies) error {
+	server := &http.Server{
+		Handler: newServerHandler(config, deps),
+	}
+
+	return graceful.Serve(ctx, server, listener, config.shutdownTimeout)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// blockingChampionGetter holds every request until it's released, so a
+// test can have a request in flight at exactly the wrong moment
+type blockingChampionGetter struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newBlockingChampionGetter() *blockingChampionGetter {
+	return &blockingChampionGetter{
+		entered: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+}
+
+func (g *blockingChampionGetter) GetCurrentChampion(ctx context.Context) (string, error) {
+	g.entered <- struct{}{}
+
+	select {
+	case <-g.release:
+		return "TY", nil
+
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	tests := []struct {
+		name          string
+		probe         CurrentChampionGetter
+		readyzStatus  int
+		healthzStatus int
+	}{
+		{"Ready", &mockCurrentChampionGetter{current: "TY"}, 200, 200},
+		{"DataStoreDown", &mockCurrentChampionGetter{pendingError: errors.New("no file")}, 503, 200},
+		{"NoProbe", nil, 200, 200},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := newServerHandler(serverConfig{requestTimeout: defaultRequestTimeout}, serverDependencies{readinessProbe: test.probe, logger: testLogger})
+
+			for path, expected := range map[string]int{"/healthz": test.healthzStatus, "/readyz": test.readyzStatus} {
+				res := httptest.NewRecorder()
+
+				handler.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+
+				if res.Code != expected {
+					t.Errorf("Expected %s %d but got %d", path, expected, res.Code)
+				}
+
+				if res.Header().Get("Cache-Control") != "no-store" {
+					t.Errorf("Expected %s not to be cached but got Cache-Control %q", path, res.Header().Get("Cache-Control"))
+				}
+			}
+		})
+	}
+}
+
+// Draining and cutting off is graceful's job and tested there.  What's ours
+// is handing it the configured timeout.
+func TestShutdownUsesConfiguredTimeout(t *testing.T) {
+	getter := newBlockingChampionGetter()
+	defer close(getter.release)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal("net.Listen:", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+
+	go func() {
+		stopped <- serve(ctx, listener, serverConfig{requestTimeout: defaultRequestTimeout, shutdownTimeout: 50 * time.Millisecond}, serverDependencies{
+			logger:                testLogger,
+			currentChampionGetter: getter,
+			championVersionGetter: &mockChampionVersionGetter{pendingVersion: testChampionVersion},
+		})
+	}()
+
+	go func() {
+		res, err := http.Get("http://" + listener.Addr().String() + "/champion")
+
+		if err == nil {
+			res.Body.Close()
+		}
+	}()
+
+	<-getter.entered
+
+	cancel()
+
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected %v but got %v", context.DeadlineExceeded, err)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected shutdown to give up after the configured timeout")
+	}
+}
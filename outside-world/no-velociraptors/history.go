@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+)
+
+// outsideAPIActor is who the history says made a change that didn't come
+// through PUT /champion, like someone editing champion.txt by hand
+const outsideAPIActor = "(outside the API)"
+
+// ChampionHistoryRecorder keeps the champion history complete, by
+// recording changes the watcher spots that nobody recorded through the API
+//
+// Admins changing the champion through PUT /champion are already recorded
+// with their name, so those are left alone.
+type ChampionHistoryRecorder struct {
+	championHistoryGetter ChampionHistoryGetter
+	championAuditor       ChampionChangeAuditor
+	logger                *slog.Logger
+}
+
+// NewChampionHistoryRecorder returns a recorder that checks the history
+// before adding to it
+func NewChampionHistoryRecorder(championHistoryGetter ChampionHistoryGetter, championAuditor ChampionChangeAuditor, logger *slog.Logger) *ChampionHistoryRecorder {
+	return &ChampionHistoryRecorder{
+		championHistoryGetter: championHistoryGetter,
+		championAuditor:       championAuditor,
+		logger:                logger,
+	}
+}
+
+// NotifyChampionChanged records the change unless the history already ends
+// with it
+//
+// There's a moment between an admin's write and their audit entry where we
+// can see the change first.  Then it ends up in the history twice, and the
+// feed keeps only the admin's entry.
+func (r *ChampionHistoryRecorder) NotifyChampionChanged(ctx context.Context, change ChampionChange) {
+	latest, err := r.championHistoryGetter.GetChampionHistory(ctx, 1)
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to check champion history", "error", err)
+		return
+	}
+
+	if len(latest) > 0 && latest[0].Champion == change.Champion {
+		return
+	}
+
+	err = r.championAuditor.RecordChampionChange(ctx, ChampionAuditEntry{
+		Time:     change.Time,
+		Actor:    outsideAPIActor,
+		Previous: change.Previous,
+		Champion: change.Champion,
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to record champion change", "previous", change.Previous, "champion", change.Champion, "error", err)
+	}
+}
+
+// championChangeNotifiers tells every one of them about each change, in
+// order
+type championChangeNotifiers []ChampionChangeNotifier
+
+func (n championChangeNotifiers) NotifyChampionChanged(ctx context.Context, change ChampionChange) {
+	for _, notifier := range n {
+		notifier.NotifyChampionChanged(ctx, change)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestChampionHistoryRecorderOnlyRecordsWhatsMissing(t *testing.T) {
+	ctx := context.Background()
+	auditLog := NewFileAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	recorder := NewChampionHistoryRecorder(auditLog, auditLog, testLogger)
+
+	// An admin crowned Rogue through the API, then someone edited the file
+	err := auditLog.RecordChampionChange(ctx, ChampionAuditEntry{Time: time.Now().UTC(), Actor: "evertras", Previous: "TY", Champion: "Rogue"})
+
+	if err != nil {
+		t.Fatal("RecordChampionChange:", err)
+	}
+
+	recorder.NotifyChampionChanged(ctx, ChampionChange{Previous: "TY", Champion: "Rogue", Time: time.Now().UTC()})
+	recorder.NotifyChampionChanged(ctx, ChampionChange{Previous: "Rogue", Champion: "Maru", Time: time.Now().UTC()})
+
+	history, err := auditLog.GetChampionHistory(ctx, 0)
+
+	if err != nil {
+		t.Fatal("GetChampionHistory:", err)
+	}
+
+	if len(history) != 2 {
+		t.Fatalf("Expected the admin's change and the hand edit but got %+v", history)
+	}
+
+	if history[0].Champion != "Maru" || history[0].Actor != outsideAPIActor {
+		t.Errorf("Expected the hand edit to be recorded as outside the API but got %+v", history[0])
+	}
+
+	if history[1].Actor != "evertras" {
+		t.Errorf("Expected the admin's entry to be left alone but got %+v", history[1])
+	}
+}
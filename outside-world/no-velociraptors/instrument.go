@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/internal/httpstatus"
+)
+
+// RequestObserver finds out about every request once it's been served
+//
+// Main hands us a metrics registry here, but all the server needs to know
+// is that someone wants to hear about requests.
+type RequestObserver interface {
+	ObserveRequest(route string, method string, status int, elapsed time.Duration)
+}
+
+// instrumentRoute tells the observer about every request to the route.  The
+// route is the pattern, not the path, so /players/TY and /players/Rogue
+// count together.
+func instrumentRoute(route string, observer RequestObserver, next http.Handler) http.Handler {
+	if observer == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		recorder := httpstatus.NewRecorder(res)
+		start := time.Now()
+
+		next.ServeHTTP(recorder, req)
+
+		observer.ObserveRequest(route, req.Method, recorder.Status(), time.Since(start))
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/metrics"
+)
+
+type observedRequest struct {
+	route  string
+	method string
+	status int
+}
+
+type mockRequestObserver struct {
+	mu       sync.Mutex
+	requests []observedRequest
+}
+
+func (o *mockRequestObserver) ObserveRequest(route string, method string, status int, elapsed time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.requests = append(o.requests, observedRequest{route, method, status})
+}
+
+func TestRequestsAreObservedByRoute(t *testing.T) {
+	observer := &mockRequestObserver{}
+
+	server := newServerHandler(serverConfig{requestTimeout: defaultRequestTimeout}, serverDependencies{
+		logger:                testLogger,
+		currentChampionGetter: &mockCurrentChampionGetter{current: "TY"},
+		championVersionGetter: &mockChampionVersionGetter{pendingVersion: testChampionVersion},
+		playerGetter:          &mockPlayerGetter{},
+		matchHistoryGetter:    &mockMatchHistoryGetter{},
+		requestObserver:       observer,
+	})
+
+	for _, request := range []struct{ method, path string }{
+		{"GET", "/champion"},
+		{"DELETE", "/champion"},
+		{"GET", "/players/nobody"},
+	} {
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(request.method, request.path, nil))
+	}
+
+	expected := []observedRequest{
+		{"/champion", "GET", 200},
+		{"/champion", "DELETE", 405},
+		{"/players/", "GET", 404},
+	}
+
+	if len(observer.requests) != len(expected) {
+		t.Fatalf("Expected %v but got %v", expected, observer.requests)
+	}
+
+	for i := range expected {
+		if observer.requests[i] != expected[i] {
+			t.Errorf("Request %d: expected %+v but got %+v", i, expected[i], observer.requests[i])
+		}
+	}
+}
+
+func TestMetricsEndpointServesRegistry(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	server := newServerHandler(serverConfig{requestTimeout: defaultRequestTimeout}, serverDependencies{
+		logger:                testLogger,
+		currentChampionGetter: &mockCurrentChampionGetter{current: "TY"},
+		championVersionGetter: &mockChampionVersionGetter{pendingVersion: testChampionVersion},
+		requestObserver:       metrics.NewHTTPMetrics(registry),
+		metricsHandler:        registry,
+	})
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/champion", nil))
+
+	res := httptest.NewRecorder()
+	server.ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+
+	if res.Code != 200 {
+		t.Fatalf("Expected 200 but got %d", res.Code)
+	}
+
+	expected := `http_requests_total{route="/champion",method="GET",status="200"} 1`
+
+	if !strings.Contains(res.Body.String(), expected) {
+		t.Errorf("Expected /metrics to contain %s but got:\n%s", expected, res.Body.String())
+	}
+}
+
+func TestNoMetricsEndpointWithoutRegistry(t *testing.T) {
+	res := httptest.NewRecorder()
+
+	newServerHandler(serverConfig{requestTimeout: defaultRequestTimeout}, serverDependencies{logger: testLogger}).ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+
+	if res.Code != 404 {
+		t.Errorf("Expected 404 but got %d", res.Code)
+	}
+}
@@ -1,17 +1,201 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/config"
+	"github.com/Evertras/go-interface-examples/decorate"
+	"github.com/Evertras/go-interface-examples/graceful"
+	"github.com/Evertras/go-interface-examples/idempotency"
+	"github.com/Evertras/go-interface-examples/metrics"
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/ratings"
+	"github.com/Evertras/go-interface-examples/ratelimit"
+	"github.com/Evertras/go-interface-examples/requestid"
 )
 
+//go:generate go run ../../cmd/localdecorate -type CurrentChampionGetter
+
 func main() {
-	log.Println("Running GSL server on :8080")
+	// Defaults, then a file, then GSL_* environment variables, then flags.
+	// Run with -print-config to see what that all adds up to.
+	cfg := defaultGSLConfig()
+
+	// Everything logs JSON, with the request ID of whatever request it was
+	// working on
+	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(os.Stderr, nil)))
+
+	err := config.Loader{Name: "gsl", EnvPrefix: "GSL"}.Load(cfg, os.Args[1:])
+
+	switch {
+	case errors.Is(err, config.ErrPrinted), errors.Is(err, flag.ErrHelp):
+		return
+
+	case err != nil:
+		logger.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	// However we get asked to stop, we drain what's in flight first
+	ctx, stop := graceful.SignalContext(context.Background())
+	defer stop()
+
+	logger.Info("Running GSL server", "address", cfg.Address)
 
 	// This is the same as before, because dataStore matches the CurrentChampionGetter interface
-	dataStore := NewGSLDataStore("./champion.txt")
-	err := runServer(":8080", dataStore)
+	dataStore := NewGSLDataStore(cfg.Data.ChampionFile)
+
+	// Brackets live in their own files, one per tournament
+	tournamentStore := NewFileTournamentStore(cfg.Data.TournamentDir)
+
+	playerRegistry, err := LoadPlayerRegistry(cfg.Data.PlayersFile)
+
+	if err != nil {
+		logger.Error("Failed to load players", "error", err)
+		os.Exit(1)
+	}
+
+	playerDirectory := NewPlayerDirectory(playerRegistry, tournamentStore)
+	ratingTracker := NewRatingTracker(playerRegistry, tournamentStore, tournamentStore, ratings.DefaultEloK, ratings.DefaultGlickoTau)
+
+	// If the file goes missing we'd rather serve a possibly stale champion
+	// than a 500
+	lastKnown := NewInMemoryChampionStore(cfg.Fallback.LastKnown)
+
+	// Everything we measure ends up here, and gets served on /metrics
+	registry := metrics.NewRegistry()
+	storeMetrics := metrics.NewCallMetrics(registry, "store")
+
+	// The fallback chain quietly moves on when the file fails, which is
+	// what we want for the client but not for whoever has to fix the file.
+	// The decorator is still a CurrentChampionGetter, so the chain can't
+	// tell it's there.
+	fileGetter := NewCurrentChampionGetterDecorator(dataStore,
+		decorate.Hooks{
+			OnError: func(ctx context.Context, call decorate.Call, err error) error {
+				logger.ErrorContext(ctx, "Champion file call failed", "method", call.Method, "error", err)
+				return err
+			},
+		}.Middleware(),
+		storeMetrics.Middleware(),
+		decorate.Retry(2, 10*time.Millisecond, nil),
+	)
+
+	// The fallback getter is also a CurrentChampionGetter, so runServer
+	// doesn't change at all
+	sources := []ChampionSource{
+		{
+			Name:    fileChampionSource,
+			Getter:  fileGetter,
+			Timeout: time.Second,
+			Breaker: NewCircuitBreaker(3, 30*time.Second),
+		},
+		{
+			// If someone forgot to update champion.txt, the last finished
+			// bracket still knows who won
+			Name:    "bracket",
+			Getter:  NewBracketChampionGetter(tournamentStore),
+			Timeout: time.Second,
+			Breaker: NewCircuitBreaker(3, 30*time.Second),
+		},
+	}
+
+	if cfg.Fallback.Upstream != "" {
+		sources = append(sources, ChampionSource{
+			Name:    "upstream",
+			Getter:  NewUpstreamChampionGetter(cfg.Fallback.Upstream, &http.Client{}),
+			Timeout: 2 * time.Second,
+			Breaker: NewCircuitBreaker(3, time.Minute),
+		})
+	}
+
+	sources = append(sources, ChampionSource{
+		Name:   "last-known",
+		Getter: lastKnown,
+	})
+
+	championGetter := NewFallbackChampionGetter(sources...)
+
+	// Admins prove who they are with a bearer token.  Validate already
+	// checked these parse.
+	adminTokens, err := parseAdminTokens(cfg.AdminTokens)
+
+	if err != nil {
+		logger.Error("Failed to parse admin tokens", "error", err)
+		os.Exit(1)
+	}
+
+	serverConfig := serverConfig{
+		address:         cfg.Address,
+		requestTimeout:  cfg.RequestTimeout,
+		shutdownTimeout: cfg.ShutdownTimeout,
+		adminTokens:     adminTokens,
+		cacheControl:    cfg.CacheControl,
+		rateLimits:      cfg.routeRateLimits(),
+	}
+
+	// The audit log doubles as our champion history for the feed
+	auditLog := NewFileAuditLog(cfg.Data.AuditLog)
+
+	webhookRegistry, err := NewWebhookRegistry(cfg.Webhooks.RegistryFile)
+
+	if err != nil {
+		logger.Error("Failed to load webhook registry", "error", err)
+		os.Exit(1)
+	}
+
+	// Watch the real data store rather than the fallback chain, we only
+	// want to tell people about champions that actually changed
+	dispatcher := NewWebhookDispatcher(webhookRegistry, &http.Client{Timeout: cfg.Webhooks.Timeout}, cfg.Webhooks.MaxAttempts, cfg.Webhooks.BaseDelay, logger).
+		WithNotificationObserver(metrics.NewNotificationMetrics(registry))
+
+	// Deliveries happen on their own worker, so a slow subscriber doesn't
+	// stop us noticing the next change
+	deliveries := NewChampionChangeQueue(dispatcher, cfg.Webhooks.QueueSize, logger)
+
+	go deliveries.Run(ctx)
+	// Changes made outside the API go in the history too, so they show up
+	// in the feed as well as in webhooks
+	go watchChampion(ctx, dataStore, cfg.Webhooks.WatchInterval, championChangeNotifiers{
+		NewChampionHistoryRecorder(auditLog, auditLog, logger),
+		deliveries,
+	}, logger)
+
+	// Reads can fall back all they like, but writes always go to the real
+	// data store.  It's the same value, it just fills a different role.
+	deps := serverDependencies{
+		currentChampionGetter: championGetter,
+		championVersionGetter: dataStore,
+		currentChampionSetter: dataStore,
+		championAuditor:       auditLog,
+		championHistoryGetter: auditLog,
+		webhookSubscriber:     webhookRegistry,
+		tournamentGetter:      tournamentStore,
+		matchResultRecorder:   tournamentStore,
+		playerGetter:          playerDirectory,
+		matchHistoryGetter:    playerDirectory,
+		matchPredictor:        ratingTracker,
+		ratingHistoryGetter:   ratingTracker,
+		ratingsGetter:         ratingTracker,
+		requestObserver:       metrics.NewHTTPMetrics(registry),
+		metricsHandler:        registry,
+		readinessProbe:        dataStore,
+		logger:                logger,
+
+		// Admins retrying a champion update or webhook change after a
+		// timeout get the first answer back rather than doing it twice
+		requestDeduplicator: idempotency.New(cfg.IdempotencyTTL, ratelimit.ClientKey(adminPrincipal(adminTokens))),
+	}
+
+	err = runServer(ctx, serverConfig, deps)
 
 	if err != nil {
-		log.Fatal(err)
+		logger.Error("GSL server stopped", "error", err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// InMemoryChampionStore keeps the current champion in memory
+//
+// This is handy as a last resort when everything else has fallen over,
+// and it's also a nice example of how something completely different from
+// GSLDataStore can still be a CurrentChampionGetter.
+type InMemoryChampionStore struct {
+	mu       sync.RWMutex
+	champion string
+}
+
+// NewInMemoryChampionStore returns an InMemoryChampionStore that starts out
+// knowing the given champion
+func NewInMemoryChampionStore(champion string) *InMemoryChampionStore {
+	return &InMemoryChampionStore{
+		champion: champion,
+	}
+}
+
+// GetCurrentChampion returns whatever champion we were last told about
+func (s *InMemoryChampionStore) GetCurrentChampion(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	champion := strings.TrimSpace(s.champion)
+
+	if champion == "" {
+		return "", errors.New("no champion has been set")
+	}
+
+	return champion, nil
+}
+
+// SetCurrentChampion replaces the champion we know about
+func (s *InMemoryChampionStore) SetCurrentChampion(champion string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.champion = champion
+}
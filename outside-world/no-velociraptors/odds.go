@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/ratings"
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/simulate"
+)
+
+// Anyone can ask for odds, and every run is CPU time.  A full 32 player
+// season does about 10000 runs a second on one core, so even the most
+// anyone can ask for finishes well inside the request deadline, which
+// cuts off anything that doesn't.
+const (
+	defaultSimulationRuns = 2000
+	maxSimulationRuns     = 10000
+)
+
+// RatingsGetter can get the current ratings for a bunch of players at once
+type RatingsGetter interface {
+	GetRatings(ctx context.Context, handles []string) (map[string]ratings.PlayerRating, error)
+}
+
+// GetRatings catches up with the brackets, then returns each player's current
+// ratings keyed by the name they were asked for with
+func (t *RatingTracker) GetRatings(ctx context.Context, handles []string) (map[string]ratings.PlayerRating, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.refresh(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]ratings.PlayerRating, len(handles))
+
+	for _, handle := range handles {
+		current[handle] = t.rater.Rating(t.playerResolver.Canonical(handle))
+	}
+
+	return current, nil
+}
+
+// EloMapWinProbability uses Elo ratings as the chance of winning each map
+//
+// Ratings come from whole matches, and a favourite's edge in a single map is
+// smaller than over a best of seven.  Using the match odds for every map
+// overrates favourites a little, but it's simple and it's only a guess
+// about the future anyway.
+func EloMapWinProbability(current map[string]ratings.PlayerRating) simulate.MapWinProbability {
+	return func(player1 string, player2 string) float64 {
+		elo := func(player string) float64 {
+			if rating, ok := current[player]; ok {
+				return rating.Elo
+			}
+
+			return ratings.DefaultElo
+		}
+
+		return ratings.EloExpected(elo(player1), elo(player2))
+	}
+}
+
+// Creates a handler that simulates the rest of a tournament and shows each
+// player's odds, like /tournaments/{id}/odds?runs=10000&seed=1
+func gslTournamentOddsHandler(tournamentGetter TournamentGetter, ratingsGetter RatingsGetter, logger *slog.Logger, tournamentID string) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		opts := simulate.Options{
+			Runs: defaultSimulationRuns,
+			Seed: 1,
+		}
+
+		var err error
+
+		if runs := req.URL.Query().Get("runs"); runs != "" {
+			opts.Runs, err = strconv.Atoi(runs)
+
+			if err != nil || opts.Runs < 1 || opts.Runs > maxSimulationRuns {
+				res.WriteHeader(http.StatusBadRequest)
+				res.Write([]byte("runs must be between 1 and " + strconv.Itoa(maxSimulationRuns)))
+				return
+			}
+		}
+
+		if seed := req.URL.Query().Get("seed"); seed != "" {
+			opts.Seed, err = strconv.ParseInt(seed, 10, 64)
+
+			if err != nil {
+				res.WriteHeader(http.StatusBadRequest)
+				res.Write([]byte("seed must be a number"))
+				return
+			}
+		}
+
+		tournament, err := tournamentGetter.GetTournament(req.Context(), tournamentID)
+
+		if errors.Is(err, ErrTournamentNotFound) {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "Failed to get tournament", "error", err)
+			writeDataStoreError(res, err)
+			return
+		}
+
+		current, err := ratingsGetter.GetRatings(req.Context(), tournament.Entrants())
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "Failed to get ratings", "error", err)
+			writeDataStoreError(res, err)
+			return
+		}
+
+		result, err := simulate.Run(req.Context(), tournament, EloMapWinProbability(current), opts)
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "Failed to simulate tournament", "error", err)
+			writeDataStoreError(res, err)
+			return
+		}
+
+		writeJSON(res, req, logger, http.StatusOK, result)
+	}
+}
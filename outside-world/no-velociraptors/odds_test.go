@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/ratings"
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/simulate"
+)
+
+type mockRatingsGetter struct {
+	pendingRatings map[string]ratings.PlayerRating
+	pendingError   error
+
+	requestedHandles []string
+
+	// Called once the ratings have been got, to pull the rug out
+	afterGet func()
+}
+
+func (g *mockRatingsGetter) GetRatings(ctx context.Context, handles []string) (map[string]ratings.PlayerRating, error) {
+	g.requestedHandles = handles
+
+	if g.afterGet != nil {
+		g.afterGet()
+	}
+
+	return g.pendingRatings, g.pendingError
+}
+
+func serveOddsRequest(t *testing.T, ratingsGetter RatingsGetter, path string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeTestBracket(t, dir, newTestBracket("2020-s2", time.Now()))
+
+	server := newServerHandler(serverConfig{requestTimeout: defaultRequestTimeout}, serverDependencies{
+		logger:           testLogger,
+		tournamentGetter: NewFileTournamentStore(dir),
+		ratingsGetter:    ratingsGetter,
+	})
+
+	res := httptest.NewRecorder()
+	server.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return res
+}
+
+func TestTournamentOddsFavourStrongerPlayers(t *testing.T) {
+	ratingsGetter := &mockRatingsGetter{
+		pendingRatings: map[string]ratings.PlayerRating{
+			"TY": {Handle: "TY", Elo: 2200},
+		},
+	}
+
+	res := serveOddsRequest(t, ratingsGetter, "/tournaments/2020-s2/odds?runs=2000&seed=5")
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("Expected status %d but got %d: %s", http.StatusOK, res.Code, res.Body.String())
+	}
+
+	var result simulate.Result
+
+	err := json.NewDecoder(res.Body).Decode(&result)
+
+	if err != nil {
+		t.Fatal("json.Decode:", err)
+	}
+
+	if result.Runs != 2000 || result.Seed != 5 || len(result.Players) != 4 {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+
+	if result.Players[0].Player != "TY" || result.Players[0].Champion < 0.9 {
+		t.Errorf("Expected TY to be a heavy favourite but got %+v", result.Players[0])
+	}
+
+	if len(ratingsGetter.requestedHandles) != 4 {
+		t.Errorf("Expected ratings for all four entrants but got %v", ratingsGetter.requestedHandles)
+	}
+}
+
+func TestTournamentOddsRejectsBadParameters(t *testing.T) {
+	for _, query := range []string{"runs=0", "runs=lots", "runs=1000000", "seed=abc"} {
+		res := serveOddsRequest(t, &mockRatingsGetter{}, "/tournaments/2020-s2/odds?"+query)
+
+		if res.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %q but got %d", http.StatusBadRequest, query, res.Code)
+		}
+	}
+
+	res := serveOddsRequest(t, &mockRatingsGetter{}, "/tournaments/nope/odds")
+
+	if res.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d but got %d", http.StatusNotFound, res.Code)
+	}
+}
+
+func TestTournamentOddsStopWhenTheClientGoesAway(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBracket(t, dir, newTestBracket("2020-s2", time.Now()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The client hangs up just as the simulation is about to start
+	ratingsGetter := &mockRatingsGetter{afterGet: cancel}
+
+	req := httptest.NewRequest(http.MethodGet, "/tournaments/2020-s2/odds?runs=10000", nil).WithContext(ctx)
+	res := httptest.NewRecorder()
+
+	gslTournamentOddsHandler(NewFileTournamentStore(dir), ratingsGetter, testLogger, "2020-s2")(res, req)
+
+	if res.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d but got %d", http.StatusServiceUnavailable, res.Code)
+	}
+}
@@ -0,0 +1,194 @@
+package players
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/bracket"
+)
+
+// MatchRecord is a finished match, with everyone's handles already sorted
+// out so aliases don't split someone's history in two
+type MatchRecord struct {
+	TournamentID string    `json:"tournamentId"`
+	MatchID      string    `json:"matchId"`
+	Date         time.Time `json:"date"`
+	Round        string    `json:"round,omitempty"`
+
+	// Final is set for the match that decided the tournament, so winning
+	// it means winning a title
+	Final bool `json:"final,omitempty"`
+
+	Player1 string `json:"player1"`
+	Player2 string `json:"player2"`
+	Race1   Race   `json:"race1,omitempty"`
+	Race2   Race   `json:"race2,omitempty"`
+	Score1  int    `json:"score1"`
+	Score2  int    `json:"score2"`
+	Winner  string `json:"winner"`
+}
+
+// Involves reports whether the player played in this match
+func (m MatchRecord) Involves(handle string) bool {
+	return m.Player1 == handle || m.Player2 == handle
+}
+
+// Opponent returns who the player was up against
+func (m MatchRecord) Opponent(handle string) string {
+	if m.Player1 == handle {
+		return m.Player2
+	}
+
+	return m.Player1
+}
+
+// handleResolver is the part of a Registry that sorts out who played
+type handleResolver interface {
+	Canonical(name string) string
+	RaceOf(name string) Race
+}
+
+// HistoryFromTournament pulls every finished match out of a tournament
+//
+// Races come from the registry rather than the bracket, which doesn't know
+// about them.  Players who swap races will have their whole history counted
+// as their current race, which is wrong but rare enough not to worry about.
+func HistoryFromTournament(tournament *bracket.Tournament, registry handleResolver) []MatchRecord {
+	var history []MatchRecord
+
+	// The view works the whole bracket out in one go, rather than once for
+	// every match we ask about
+	for _, stage := range tournament.View().Stages {
+		for _, match := range stage.Matches {
+			if match.Status != bracket.StatusComplete {
+				continue
+			}
+
+			history = append(history, MatchRecord{
+				TournamentID: tournament.ID,
+				MatchID:      match.ID,
+				Date:         tournament.Date,
+				Round:        match.Round,
+				Final:        match.ID == tournament.Final,
+				Player1:      registry.Canonical(match.Player1),
+				Player2:      registry.Canonical(match.Player2),
+				Race1:        registry.RaceOf(match.Player1),
+				Race2:        registry.RaceOf(match.Player2),
+				Score1:       match.Score[0],
+				Score2:       match.Score[1],
+				Winner:       registry.Canonical(match.Winner),
+			})
+		}
+	}
+
+	return history
+}
+
+// Record is a win/loss count
+type Record struct {
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	WinRate float64 `json:"winRate"`
+}
+
+func (r *Record) add(won bool) {
+	if won {
+		r.Wins++
+	} else {
+		r.Losses++
+	}
+
+	r.WinRate = float64(r.Wins) / float64(r.Wins+r.Losses)
+}
+
+// Summary is everything interesting about one player's results
+type Summary struct {
+	Titles   int               `json:"titles"`
+	Overall  Record            `json:"overall"`
+	Matchups map[string]Record `json:"matchups"`
+}
+
+// Summarize works out a player's titles and records from their history.
+// Matches the player wasn't in are ignored.
+func Summarize(handle string, history []MatchRecord) Summary {
+	summary := Summary{
+		Matchups: make(map[string]Record),
+	}
+
+	for _, match := range history {
+		if !match.Involves(handle) {
+			continue
+		}
+
+		won := match.Winner == handle
+
+		if won && match.Final {
+			summary.Titles++
+		}
+
+		summary.Overall.add(won)
+
+		own, opponent := match.Race1, match.Race2
+
+		if match.Player2 == handle {
+			own, opponent = opponent, own
+		}
+
+		if matchup := Matchup(own, opponent); matchup != "" {
+			record := summary.Matchups[matchup]
+			record.add(won)
+			summary.Matchups[matchup] = record
+		}
+	}
+
+	return summary
+}
+
+// HeadToHead is how two players have done against each other
+type HeadToHead struct {
+	Player1 string        `json:"player1"`
+	Player2 string        `json:"player2"`
+	Wins1   int           `json:"wins1"`
+	Wins2   int           `json:"wins2"`
+	Maps1   int           `json:"maps1"`
+	Maps2   int           `json:"maps2"`
+	Matches []MatchRecord `json:"matches"`
+}
+
+// CompareHeadToHead finds every match between two players, newest first
+func CompareHeadToHead(player1 string, player2 string, history []MatchRecord) HeadToHead {
+	result := HeadToHead{
+		Player1: player1,
+		Player2: player2,
+		Matches: []MatchRecord{},
+	}
+
+	for _, match := range history {
+		if !match.Involves(player1) || match.Opponent(player1) != player2 {
+			continue
+		}
+
+		own, other := match.Score1, match.Score2
+
+		if match.Player2 == player1 {
+			own, other = other, own
+		}
+
+		result.Maps1 += own
+		result.Maps2 += other
+
+		if match.Winner == player1 {
+			result.Wins1++
+		} else {
+			result.Wins2++
+		}
+
+		result.Matches = append(result.Matches, match)
+	}
+
+	sort.SliceStable(result.Matches, func(i, j int) bool {
+		return result.Matches[i].Date.After(result.Matches[j].Date)
+	})
+
+	return result
+}
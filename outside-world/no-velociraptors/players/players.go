@@ -0,0 +1,111 @@
+// Package players knows who the GSL players are and what they've done.
+//
+// The registry holds the things that don't change often, like race and
+// team.  Everything else, like titles and win rates, is worked out from
+// match history so there's only one place results are kept: the brackets.
+package players
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDuplicateName means two players share a handle or alias
+var ErrDuplicateName = errors.New("handle or alias used by more than one player")
+
+// Race is what a player plays
+type Race string
+
+// The races, plus random for the brave
+const (
+	Terran  Race = "Terran"
+	Zerg    Race = "Zerg"
+	Protoss Race = "Protoss"
+	Random  Race = "Random"
+)
+
+// Letter is the single letter everyone uses for the race, like the T in TvZ
+func (r Race) Letter() string {
+	switch r {
+	case Terran, Zerg, Protoss, Random:
+		return string(r)[:1]
+	}
+
+	return ""
+}
+
+// Matchup describes a match from one race's point of view, like "TvZ".
+// It's empty if either race is unknown.
+func Matchup(own Race, opponent Race) string {
+	if own.Letter() == "" || opponent.Letter() == "" {
+		return ""
+	}
+
+	return own.Letter() + "v" + opponent.Letter()
+}
+
+// Player is someone who plays in the GSL
+type Player struct {
+	Handle  string   `json:"handle"`
+	Race    Race     `json:"race"`
+	Team    string   `json:"team,omitempty"`
+	Country string   `json:"country,omitempty"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// Registry finds players by handle or by any of their aliases
+//
+// Lookups ignore case, since nobody agrees whether it's "Ty" or "TY".
+type Registry struct {
+	players map[string]Player
+}
+
+// NewRegistry builds a registry from a list of players
+func NewRegistry(players []Player) (*Registry, error) {
+	registry := &Registry{
+		players: make(map[string]Player),
+	}
+
+	for _, player := range players {
+		if player.Handle == "" {
+			return nil, errors.New("player without a handle")
+		}
+
+		for _, name := range append([]string{player.Handle}, player.Aliases...) {
+			key := strings.ToLower(name)
+
+			if existing, ok := registry.players[key]; ok {
+				return nil, fmt.Errorf("%w: %q is used by %s and %s", ErrDuplicateName, name, existing.Handle, player.Handle)
+			}
+
+			registry.players[key] = player
+		}
+	}
+
+	return registry, nil
+}
+
+// Lookup finds a player by handle or alias
+func (r *Registry) Lookup(name string) (Player, bool) {
+	player, ok := r.players[strings.ToLower(name)]
+
+	return player, ok
+}
+
+// Canonical returns the player's real handle, or the name as given if we
+// don't know who that is
+func (r *Registry) Canonical(name string) string {
+	if player, ok := r.Lookup(name); ok {
+		return player.Handle
+	}
+
+	return name
+}
+
+// RaceOf returns the player's race, or an empty race if we don't know them
+func (r *Registry) RaceOf(name string) Race {
+	player, _ := r.Lookup(name)
+
+	return player.Race
+}
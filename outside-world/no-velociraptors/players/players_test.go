@@ -0,0 +1,100 @@
+package players
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+
+	registry, err := NewRegistry([]Player{
+		{Handle: "TY", Race: Terran, Aliases: []string{"TaeYang"}},
+		{Handle: "Rogue", Race: Zerg},
+		{Handle: "Zest", Race: Protoss},
+	})
+
+	if err != nil {
+		t.Fatal("NewRegistry:", err)
+	}
+
+	return registry
+}
+
+func TestRegistryFindsPlayersByAliasIgnoringCase(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	for _, name := range []string{"TY", "ty", "taeyang"} {
+		player, ok := registry.Lookup(name)
+
+		if !ok || player.Handle != "TY" {
+			t.Errorf("Expected %q to find TY but got %+v (ok=%v)", name, player, ok)
+		}
+	}
+
+	if registry.Canonical("Nobody") != "Nobody" {
+		t.Error("Unknown names should be left alone")
+	}
+}
+
+func TestRegistryRejectsDuplicateNames(t *testing.T) {
+	_, err := NewRegistry([]Player{
+		{Handle: "TY", Race: Terran},
+		{Handle: "Other", Race: Zerg, Aliases: []string{"ty"}},
+	})
+
+	if !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("Expected ErrDuplicateName but got %v", err)
+	}
+}
+
+var testHistory = []MatchRecord{
+	{TournamentID: "s1", MatchID: "final", Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Final: true, Player1: "TY", Player2: "Rogue", Race1: Terran, Race2: Zerg, Score1: 4, Score2: 2, Winner: "TY"},
+	{TournamentID: "s2", MatchID: "semi", Date: time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC), Player1: "Rogue", Player2: "TY", Race1: Zerg, Race2: Terran, Score1: 3, Score2: 1, Winner: "Rogue"},
+	{TournamentID: "s2", MatchID: "other", Date: time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC), Player1: "TY", Player2: "Zest", Race1: Terran, Race2: Protoss, Score1: 2, Score2: 0, Winner: "TY"},
+	{TournamentID: "s3", MatchID: "final", Date: time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC), Final: true, Player1: "TY", Player2: "Rogue", Race1: Terran, Race2: Zerg, Score1: 4, Score2: 3, Winner: "TY"},
+}
+
+func TestSummarizeCountsTitlesAndMatchups(t *testing.T) {
+	summary := Summarize("TY", testHistory)
+
+	if summary.Titles != 2 {
+		t.Errorf("Expected 2 titles but got %d", summary.Titles)
+	}
+
+	if summary.Overall.Wins != 3 || summary.Overall.Losses != 1 || summary.Overall.WinRate != 0.75 {
+		t.Errorf("Unexpected overall record %+v", summary.Overall)
+	}
+
+	tvz := summary.Matchups["TvZ"]
+
+	if tvz.Wins != 2 || tvz.Losses != 1 {
+		t.Errorf("Unexpected TvZ record %+v", tvz)
+	}
+
+	if tvp := summary.Matchups["TvP"]; tvp.Wins != 1 || tvp.WinRate != 1 {
+		t.Errorf("Unexpected TvP record %+v", tvp)
+	}
+
+	// Rogue sees the same matches from the other side
+	if zvt := Summarize("Rogue", testHistory).Matchups["ZvT"]; zvt.Wins != 1 || zvt.Losses != 2 {
+		t.Errorf("Unexpected ZvT record %+v", zvt)
+	}
+}
+
+func TestCompareHeadToHead(t *testing.T) {
+	result := CompareHeadToHead("Rogue", "TY", testHistory)
+
+	if result.Wins1 != 1 || result.Wins2 != 2 {
+		t.Errorf("Expected Rogue 1-2 TY but got %d-%d", result.Wins1, result.Wins2)
+	}
+
+	if result.Maps1 != 8 || result.Maps2 != 9 {
+		t.Errorf("Expected maps 8-9 but got %d-%d", result.Maps1, result.Maps2)
+	}
+
+	if len(result.Matches) != 3 || result.Matches[0].TournamentID != "s3" {
+		t.Errorf("Expected three matches, newest first, but got %+v", result.Matches)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/players"
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/ratings"
+)
+
+// MatchPredictor can guess who wins a match between two players
+type MatchPredictor interface {
+	PredictMatch(ctx context.Context, player1 string, player2 string) (ratings.Prediction, error)
+}
+
+// RatingHistoryGetter can show how a player's rating changed over time
+type RatingHistoryGetter interface {
+	GetRatingHistory(ctx context.Context, handle string) ([]ratings.RatingPoint, error)
+}
+
+// PlayerResolver turns whatever name a player was entered under into their
+// real handle and race, so aliases don't split anyone's rating in two
+type PlayerResolver interface {
+	Canonical(name string) string
+	RaceOf(name string) players.Race
+}
+
+// TournamentVersionGetter can tell us whether any tournament has changed,
+// without loading them all to find out
+type TournamentVersionGetter interface {
+	GetTournamentsVersion(ctx context.Context) (string, error)
+}
+
+// RatingTracker keeps ratings up to date with the brackets
+//
+// Whenever the tournaments change, every match is rated again from the
+// start.  A result that gets corrected, or one recorded for an older
+// tournament after newer ones, ends up rated as if it had been right all
+// along.  While nothing changes, a request only costs asking for the
+// version.
+type RatingTracker struct {
+	playerResolver          PlayerResolver
+	tournamentLister        TournamentLister
+	tournamentVersionGetter TournamentVersionGetter
+
+	k   float64
+	tau float64
+
+	mu      sync.Mutex
+	rater   *ratings.Rater
+	version string
+}
+
+// NewRatingTracker returns a RatingTracker using the given Elo K factor and
+// Glicko-2 tau
+func NewRatingTracker(playerResolver PlayerResolver, tournamentLister TournamentLister, tournamentVersionGetter TournamentVersionGetter, k float64, tau float64) *RatingTracker {
+	return &RatingTracker{
+		playerResolver:          playerResolver,
+		tournamentLister:        tournamentLister,
+		tournamentVersionGetter: tournamentVersionGetter,
+		k:                       k,
+		tau:                     tau,
+	}
+}
+
+// PredictMatch catches up with the brackets, then compares the two players
+func (t *RatingTracker) PredictMatch(ctx context.Context, player1 string, player2 string) (ratings.Prediction, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.refresh(ctx)
+
+	if err != nil {
+		return ratings.Prediction{}, err
+	}
+
+	return t.rater.Predict(t.playerResolver.Canonical(player1), t.playerResolver.Canonical(player2)), nil
+}
+
+// GetRatingHistory catches up with the brackets, then returns the player's
+// ratings after each of their matches
+func (t *RatingTracker) GetRatingHistory(ctx context.Context, handle string) ([]ratings.RatingPoint, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.refresh(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return t.rater.History(t.playerResolver.Canonical(handle)), nil
+}
+
+// refresh rates everything again if the tournaments changed since last
+// time, and must be called with mu held
+//
+// The version is read before the tournaments, so if they change in between
+// we rate the newer ones under the older version.  The next request sees a
+// new version and rates them again, which costs a little but is never
+// wrong.
+func (t *RatingTracker) refresh(ctx context.Context) error {
+	version, err := t.tournamentVersionGetter.GetTournamentsVersion(ctx)
+
+	if err != nil {
+		return fmt.Errorf("tournamentVersionGetter.GetTournamentsVersion: %w", err)
+	}
+
+	if t.rater != nil && version == t.version {
+		return nil
+	}
+
+	tournaments, err := t.tournamentLister.ListTournaments(ctx)
+
+	if err != nil {
+		return fmt.Errorf("tournamentLister.ListTournaments: %w", err)
+	}
+
+	var history []players.MatchRecord
+
+	for _, tournament := range tournaments {
+		history = append(history, players.HistoryFromTournament(tournament, t.playerResolver)...)
+	}
+
+	// Tournaments are already oldest first and matches within one are in
+	// bracket order, this just makes sure of it
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Date.Before(history[j].Date)
+	})
+
+	rater := ratings.NewRater(t.k, t.tau)
+
+	for _, match := range history {
+		rater.Record(match)
+	}
+
+	t.rater = rater
+	t.version = version
+
+	return nil
+}
+
+// Creates a handler that predicts the winner of /predict?p1=..&p2=..
+func gslPredictHandler(matchPredictor MatchPredictor, logger *slog.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		player1 := strings.TrimSpace(req.URL.Query().Get("p1"))
+		player2 := strings.TrimSpace(req.URL.Query().Get("p2"))
+
+		if player1 == "" || player2 == "" {
+			res.WriteHeader(http.StatusBadRequest)
+			res.Write([]byte("need both p1 and p2"))
+			return
+		}
+
+		prediction, err := matchPredictor.PredictMatch(req.Context(), player1, player2)
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "Failed to predict match", "error", err)
+			writeDataStoreError(res, err)
+			return
+		}
+
+		writeJSON(res, req, logger, http.StatusOK, prediction)
+	}
+}
+
+// Creates a handler that shows how a player's rating has moved
+func gslRatingHistoryHandler(playerGetter PlayerGetter, ratingHistoryGetter RatingHistoryGetter, logger *slog.Logger, handle string) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		player, ok := getPlayer(res, req, playerGetter, logger, handle)
+
+		if !ok {
+			return
+		}
+
+		history, err := ratingHistoryGetter.GetRatingHistory(req.Context(), player.Handle)
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "Failed to get rating history", "error", err)
+			writeDataStoreError(res, err)
+			return
+		}
+
+		writeJSON(res, req, logger, http.StatusOK, history)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/bracket"
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/players"
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/ratings"
+)
+
+type mockMatchPredictor struct {
+	pendingPrediction ratings.Prediction
+	pendingError      error
+
+	requested [][2]string
+}
+
+func (p *mockMatchPredictor) PredictMatch(ctx context.Context, player1 string, player2 string) (ratings.Prediction, error) {
+	p.requested = append(p.requested, [2]string{player1, player2})
+
+	return p.pendingPrediction, p.pendingError
+}
+
+func TestPredictReturnsWinProbability(t *testing.T) {
+	predictor := &mockMatchPredictor{
+		pendingPrediction: ratings.Prediction{Elo: 0.64, Glicko2: 0.61},
+	}
+
+	res := servePlayerRequest(serverDependencies{matchPredictor: predictor, logger: testLogger}, "/predict?p1=TY&p2=Rogue")
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("Expected status %d but got %d", http.StatusOK, res.Code)
+	}
+
+	var prediction ratings.Prediction
+
+	err := json.NewDecoder(res.Body).Decode(&prediction)
+
+	if err != nil {
+		t.Fatal("json.Decode:", err)
+	}
+
+	if prediction.Elo != 0.64 || prediction.Glicko2 != 0.61 {
+		t.Errorf("Unexpected prediction %+v", prediction)
+	}
+
+	if len(predictor.requested) != 1 || predictor.requested[0] != [2]string{"TY", "Rogue"} {
+		t.Errorf("Unexpected requests %v", predictor.requested)
+	}
+}
+
+func TestPredictNeedsBothPlayers(t *testing.T) {
+	predictor := &mockMatchPredictor{}
+
+	res := servePlayerRequest(serverDependencies{matchPredictor: predictor, logger: testLogger}, "/predict?p1=TY")
+
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d but got %d", http.StatusBadRequest, res.Code)
+	}
+
+	if len(predictor.requested) != 0 {
+		t.Error("Predictor shouldn't have been asked")
+	}
+}
+
+func TestPredictReturns500WhenPredictorFails(t *testing.T) {
+	predictor := &mockMatchPredictor{
+		pendingError: errors.New("no brackets for you"),
+	}
+
+	res := servePlayerRequest(serverDependencies{matchPredictor: predictor, logger: testLogger}, "/predict?p1=TY&p2=Rogue")
+
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d but got %d", http.StatusInternalServerError, res.Code)
+	}
+}
+
+func TestRatingTrackerPicksUpNewResults(t *testing.T) {
+	registry, err := players.NewRegistry([]players.Player{
+		{Handle: "TY", Race: players.Terran, Aliases: []string{"TaeYang"}},
+		{Handle: "Rogue", Race: players.Zerg},
+	})
+
+	if err != nil {
+		t.Fatal("players.NewRegistry:", err)
+	}
+
+	tournament := &bracket.Tournament{
+		ID:   "s1",
+		Date: time.Now(),
+		Stages: []bracket.Stage{{Matches: []bracket.Match{
+			{ID: "m1", BestOf: 1, Slots: [2]bracket.Source{bracket.Seed("TY"), bracket.Seed("Rogue")}},
+			{ID: "m2", BestOf: 1, Slots: [2]bracket.Source{bracket.Seed("TY"), bracket.Seed("Rogue")}},
+		}}},
+		Final: "m2",
+	}
+
+	tournament.RecordResult("m1", []bracket.MapResult{{Map: "Oxide", Winner: "TY"}})
+
+	lister := &mockTournamentLister{
+		tournaments: []*bracket.Tournament{tournament},
+		version:     "1",
+	}
+
+	tracker := NewRatingTracker(registry, lister, lister, ratings.DefaultEloK, ratings.DefaultGlickoTau)
+
+	prediction, err := tracker.PredictMatch(context.Background(), "TaeYang", "Rogue")
+
+	if err != nil {
+		t.Fatal("tracker.PredictMatch:", err)
+	}
+
+	// 1516 vs 1484 after one win each way from 1500
+	if math.Abs(prediction.Player1.Elo-1516) > 1e-9 || prediction.Player1.Handle != "TY" {
+		t.Errorf("Unexpected player1 rating %+v", prediction.Player1)
+	}
+
+	tournament.RecordResult("m2", []bracket.MapResult{{Map: "Oxide", Winner: "Rogue"}})
+	lister.version = "2"
+
+	history, err := tracker.GetRatingHistory(context.Background(), "TY")
+
+	if err != nil {
+		t.Fatal("tracker.GetRatingHistory:", err)
+	}
+
+	if len(history) != 2 || history[1].MatchID != "m2" || history[1].Elo >= history[0].Elo {
+		t.Errorf("Expected TY to have dropped after m2 but got %+v", history)
+	}
+}
+
+func TestRatingTrackerRatesCorrectedResultsAgain(t *testing.T) {
+	registry, err := players.NewRegistry([]players.Player{
+		{Handle: "TY", Race: players.Terran},
+		{Handle: "Rogue", Race: players.Zerg},
+	})
+
+	if err != nil {
+		t.Fatal("players.NewRegistry:", err)
+	}
+
+	newTournament := func(winner string) *bracket.Tournament {
+		tournament := &bracket.Tournament{
+			ID:   "s1",
+			Date: time.Now(),
+			Stages: []bracket.Stage{{Matches: []bracket.Match{
+				{ID: "m1", BestOf: 1, Slots: [2]bracket.Source{bracket.Seed("TY"), bracket.Seed("Rogue")}},
+			}}},
+			Final: "m1",
+		}
+
+		tournament.RecordResult("m1", []bracket.MapResult{{Map: "Oxide", Winner: winner}})
+
+		return tournament
+	}
+
+	lister := &mockTournamentLister{
+		tournaments: []*bracket.Tournament{newTournament("TY")},
+		version:     "1",
+	}
+
+	tracker := NewRatingTracker(registry, lister, lister, ratings.DefaultEloK, ratings.DefaultGlickoTau)
+
+	prediction, err := tracker.PredictMatch(context.Background(), "TY", "Rogue")
+
+	if err != nil {
+		t.Fatal("tracker.PredictMatch:", err)
+	}
+
+	if prediction.Player1.Elo <= prediction.Player2.Elo {
+		t.Fatalf("Expected TY ahead after winning but got %+v", prediction)
+	}
+
+	// Somebody typed in the wrong winner and fixed it by hand
+	lister.tournaments = []*bracket.Tournament{newTournament("Rogue")}
+	lister.version = "2"
+
+	prediction, err = tracker.PredictMatch(context.Background(), "TY", "Rogue")
+
+	if err != nil {
+		t.Fatal("tracker.PredictMatch:", err)
+	}
+
+	if prediction.Player1.Elo >= prediction.Player2.Elo {
+		t.Errorf("Expected Rogue ahead after the correction but got %+v", prediction)
+	}
+
+	history, err := tracker.GetRatingHistory(context.Background(), "TY")
+
+	if err != nil {
+		t.Fatal("tracker.GetRatingHistory:", err)
+	}
+
+	if len(history) != 1 {
+		t.Errorf("Expected the corrected match to replace the old one but got %+v", history)
+	}
+}
+
+func TestRatingTrackerDoesntListTournamentsWhileNothingChanges(t *testing.T) {
+	registry, err := players.NewRegistry([]players.Player{{Handle: "TY", Race: players.Terran}})
+
+	if err != nil {
+		t.Fatal("players.NewRegistry:", err)
+	}
+
+	lister := &mockTournamentLister{version: "1"}
+
+	tracker := NewRatingTracker(registry, lister, lister, ratings.DefaultEloK, ratings.DefaultGlickoTau)
+
+	for i := 0; i < 3; i++ {
+		_, err := tracker.GetRatingHistory(context.Background(), "TY")
+
+		if err != nil {
+			t.Fatal("tracker.GetRatingHistory:", err)
+		}
+	}
+
+	if lister.listCalls != 1 {
+		t.Errorf("Expected the tournaments listed once but they were listed %d times", lister.listCalls)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/players"
+)
+
+// ErrPlayerNotFound means nobody has that handle or alias
+var ErrPlayerNotFound = errors.New("player not found")
+
+// PlayerGetter can look up a player by handle or alias
+type PlayerGetter interface {
+	GetPlayer(ctx context.Context, handle string) (players.Player, error)
+}
+
+// MatchHistoryGetter can get every finished match a player was in
+type MatchHistoryGetter interface {
+	GetMatchHistory(ctx context.Context, handle string) ([]players.MatchRecord, error)
+}
+
+// LoadPlayerRegistry reads players from a JSON file.  A missing file just
+// means we don't know anybody yet.
+func LoadPlayerRegistry(filename string) (*players.Registry, error) {
+	contents, err := os.ReadFile(filename)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return players.NewRegistry(nil)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read players file: %w", err)
+	}
+
+	var list []players.Player
+
+	err = json.Unmarshal(contents, &list)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse players file: %w", err)
+	}
+
+	return players.NewRegistry(list)
+}
+
+// PlayerDirectory answers questions about players using the registry for
+// who they are and the brackets for what they've done
+type PlayerDirectory struct {
+	registry         *players.Registry
+	tournamentLister TournamentLister
+}
+
+// NewPlayerDirectory returns a PlayerDirectory backed by the given registry
+// and tournaments
+func NewPlayerDirectory(registry *players.Registry, tournamentLister TournamentLister) *PlayerDirectory {
+	return &PlayerDirectory{
+		registry:         registry,
+		tournamentLister: tournamentLister,
+	}
+}
+
+// GetPlayer looks up a player by handle or alias
+func (d *PlayerDirectory) GetPlayer(ctx context.Context, handle string) (players.Player, error) {
+	if err := ctx.Err(); err != nil {
+		return players.Player{}, err
+	}
+
+	player, ok := d.registry.Lookup(handle)
+
+	if !ok {
+		return players.Player{}, ErrPlayerNotFound
+	}
+
+	return player, nil
+}
+
+// GetMatchHistory finds every finished match the player was in, across
+// every tournament, oldest first
+func (d *PlayerDirectory) GetMatchHistory(ctx context.Context, handle string) ([]players.MatchRecord, error) {
+	tournaments, err := d.tournamentLister.ListTournaments(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("tournamentLister.ListTournaments: %w", err)
+	}
+
+	handle = d.registry.Canonical(handle)
+	history := []players.MatchRecord{}
+
+	for _, tournament := range tournaments {
+		for _, match := range players.HistoryFromTournament(tournament, d.registry) {
+			if match.Involves(handle) {
+				history = append(history, match)
+			}
+		}
+	}
+
+	return history, nil
+}
+
+type playerProfile struct {
+	players.Player
+	players.Summary
+}
+
+// Creates a handler that shows who a player is and how they've done
+func gslPlayerHandler(playerGetter PlayerGetter, matchHistoryGetter MatchHistoryGetter, logger *slog.Logger, handle string) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		player, ok := getPlayer(res, req, playerGetter, logger, handle)
+
+		if !ok {
+			return
+		}
+
+		history, err := matchHistoryGetter.GetMatchHistory(req.Context(), player.Handle)
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "Failed to get match history", "error", err)
+			writeDataStoreError(res, err)
+			return
+		}
+
+		writeJSON(res, req, logger, http.StatusOK, playerProfile{
+			Player:  player,
+			Summary: players.Summarize(player.Handle, history),
+		})
+	}
+}
+
+// Creates a handler that compares two players' results against each other
+func gslHeadToHeadHandler(playerGetter PlayerGetter, matchHistoryGetter MatchHistoryGetter, logger *slog.Logger, handle1 string, handle2 string) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		player1, ok := getPlayer(res, req, playerGetter, logger, handle1)
+
+		if !ok {
+			return
+		}
+
+		player2, ok := getPlayer(res, req, playerGetter, logger, handle2)
+
+		if !ok {
+			return
+		}
+
+		history, err := matchHistoryGetter.GetMatchHistory(req.Context(), player1.Handle)
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "Failed to get match history", "error", err)
+			writeDataStoreError(res, err)
+			return
+		}
+
+		writeJSON(res, req, logger, http.StatusOK, players.CompareHeadToHead(player1.Handle, player2.Handle, history))
+	}
+}
+
+// getPlayer writes the error response itself if the player can't be found,
+// and reports whether the handler should carry on
+func getPlayer(res http.ResponseWriter, req *http.Request, playerGetter PlayerGetter, logger *slog.Logger, handle string) (players.Player, bool) {
+	player, err := playerGetter.GetPlayer(req.Context(), handle)
+
+	if errors.Is(err, ErrPlayerNotFound) {
+		res.WriteHeader(http.StatusNotFound)
+		return players.Player{}, false
+	}
+
+	if err != nil {
+		logger.ErrorContext(req.Context(), "Failed to get player", "error", err)
+		writeDataStoreError(res, err)
+		return players.Player{}, false
+	}
+
+	return player, true
+}
+
+// playerRoutes handles everything under /players/
+//
+//	GET /players/{handle}
+//	GET /players/{handle}/ratings
+//	GET /players/{handle}/vs/{other}
+func playerRoutes(deps serverDependencies) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, "/players/"), "/"), "/")
+
+		switch {
+		case len(parts) == 1 && parts[0] != "":
+			methodHandlers{
+				http.MethodGet: gslPlayerHandler(deps.playerGetter, deps.matchHistoryGetter, deps.logger, parts[0]),
+			}.ServeHTTP(res, req)
+
+		case len(parts) == 2 && parts[1] == "ratings":
+			methodHandlers{
+				http.MethodGet: gslRatingHistoryHandler(deps.playerGetter, deps.ratingHistoryGetter, deps.logger, parts[0]),
+			}.ServeHTTP(res, req)
+
+		case len(parts) == 3 && parts[1] == "vs":
+			methodHandlers{
+				http.MethodGet: gslHeadToHeadHandler(deps.playerGetter, deps.matchHistoryGetter, deps.logger, parts[0], parts[2]),
+			}.ServeHTTP(res, req)
+
+		default:
+			res.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
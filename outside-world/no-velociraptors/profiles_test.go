@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/bracket"
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/players"
+)
+
+type mockPlayerGetter struct {
+	players      map[string]players.Player
+	pendingError error
+}
+
+func (g *mockPlayerGetter) GetPlayer(ctx context.Context, handle string) (players.Player, error) {
+	if g.pendingError != nil {
+		return players.Player{}, g.pendingError
+	}
+
+	player, ok := g.players[handle]
+
+	if !ok {
+		return players.Player{}, ErrPlayerNotFound
+	}
+
+	return player, nil
+}
+
+type mockMatchHistoryGetter struct {
+	history      []players.MatchRecord
+	pendingError error
+
+	requestedHandles []string
+}
+
+func (g *mockMatchHistoryGetter) GetMatchHistory(ctx context.Context, handle string) ([]players.MatchRecord, error) {
+	g.requestedHandles = append(g.requestedHandles, handle)
+
+	return g.history, g.pendingError
+}
+
+func newTestPlayerDeps() (serverDependencies, *mockMatchHistoryGetter) {
+	historyGetter := &mockMatchHistoryGetter{
+		history: []players.MatchRecord{
+			{TournamentID: "s1", Final: true, Player1: "TY", Player2: "Rogue", Race1: players.Terran, Race2: players.Zerg, Score1: 4, Score2: 2, Winner: "TY"},
+			{TournamentID: "s2", Player1: "Rogue", Player2: "TY", Race1: players.Zerg, Race2: players.Terran, Score1: 3, Score2: 0, Winner: "Rogue"},
+		},
+	}
+
+	deps := serverDependencies{
+		logger: testLogger,
+		playerGetter: &mockPlayerGetter{
+			players: map[string]players.Player{
+				// Looking up an alias gives back the real handle
+				"TaeYang": {Handle: "TY", Race: players.Terran},
+				"TY":      {Handle: "TY", Race: players.Terran},
+				"Rogue":   {Handle: "Rogue", Race: players.Zerg},
+			},
+		},
+		matchHistoryGetter: historyGetter,
+	}
+
+	return deps, historyGetter
+}
+
+func servePlayerRequest(deps serverDependencies, path string) *httptest.ResponseRecorder {
+	server := newServerHandler(serverConfig{requestTimeout: defaultRequestTimeout}, deps)
+
+	res := httptest.NewRecorder()
+	server.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return res
+}
+
+func TestPlayerProfileIncludesTitlesAndMatchups(t *testing.T) {
+	deps, historyGetter := newTestPlayerDeps()
+
+	res := servePlayerRequest(deps, "/players/TaeYang")
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("Expected status %d but got %d", http.StatusOK, res.Code)
+	}
+
+	var profile struct {
+		Handle   string                    `json:"handle"`
+		Race     string                    `json:"race"`
+		Titles   int                       `json:"titles"`
+		Matchups map[string]players.Record `json:"matchups"`
+	}
+
+	err := json.NewDecoder(res.Body).Decode(&profile)
+
+	if err != nil {
+		t.Fatal("json.Decode:", err)
+	}
+
+	if profile.Handle != "TY" || profile.Race != "Terran" || profile.Titles != 1 {
+		t.Errorf("Unexpected profile %+v", profile)
+	}
+
+	if tvz := profile.Matchups["TvZ"]; tvz.Wins != 1 || tvz.Losses != 1 || tvz.WinRate != 0.5 {
+		t.Errorf("Unexpected TvZ record %+v", tvz)
+	}
+
+	if len(historyGetter.requestedHandles) != 1 || historyGetter.requestedHandles[0] != "TY" {
+		t.Errorf("Expected history to be requested for TY but got %v", historyGetter.requestedHandles)
+	}
+}
+
+func TestPlayerProfileReturns404ForUnknownPlayer(t *testing.T) {
+	deps, _ := newTestPlayerDeps()
+
+	res := servePlayerRequest(deps, "/players/Nobody")
+
+	if res.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d but got %d", http.StatusNotFound, res.Code)
+	}
+}
+
+func TestPlayerProfileReturns500WhenHistoryFails(t *testing.T) {
+	deps, historyGetter := newTestPlayerDeps()
+	historyGetter.pendingError = errors.New("disk on fire")
+
+	res := servePlayerRequest(deps, "/players/TY")
+
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d but got %d", http.StatusInternalServerError, res.Code)
+	}
+}
+
+func TestHeadToHead(t *testing.T) {
+	deps, _ := newTestPlayerDeps()
+
+	res := servePlayerRequest(deps, "/players/Rogue/vs/TY")
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("Expected status %d but got %d", http.StatusOK, res.Code)
+	}
+
+	var result players.HeadToHead
+
+	err := json.NewDecoder(res.Body).Decode(&result)
+
+	if err != nil {
+		t.Fatal("json.Decode:", err)
+	}
+
+	if result.Wins1 != 1 || result.Wins2 != 1 || result.Maps1 != 5 || result.Maps2 != 4 {
+		t.Errorf("Unexpected head to head %+v", result)
+	}
+
+	res = servePlayerRequest(deps, "/players/Rogue/vs/Nobody")
+
+	if res.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d but got %d", http.StatusNotFound, res.Code)
+	}
+}
+
+// PlayerDirectory is the real thing behind both interfaces, so check it
+// stitches the registry and brackets together properly
+func TestPlayerDirectoryResolvesAliasesInHistory(t *testing.T) {
+	registry, err := players.NewRegistry([]players.Player{
+		{Handle: "TY", Race: players.Terran, Aliases: []string{"TaeYang"}},
+		{Handle: "Rogue", Race: players.Zerg},
+	})
+
+	if err != nil {
+		t.Fatal("players.NewRegistry:", err)
+	}
+
+	tournament := &bracket.Tournament{
+		ID:   "s1",
+		Date: time.Now(),
+		Stages: []bracket.Stage{{Matches: []bracket.Match{
+			{ID: "final", BestOf: 1, Slots: [2]bracket.Source{bracket.Seed("TaeYang"), bracket.Seed("Rogue")}},
+		}}},
+		Final: "final",
+	}
+
+	err = tournament.RecordResult("final", []bracket.MapResult{{Map: "Oxide", Winner: "TaeYang"}})
+
+	if err != nil {
+		t.Fatal("tournament.RecordResult:", err)
+	}
+
+	directory := NewPlayerDirectory(registry, &mockTournamentLister{
+		tournaments: []*bracket.Tournament{tournament},
+	})
+
+	history, err := directory.GetMatchHistory(context.Background(), "ty")
+
+	if err != nil {
+		t.Fatal("directory.GetMatchHistory:", err)
+	}
+
+	if len(history) != 1 || history[0].Winner != "TY" || history[0].Race1 != players.Terran {
+		t.Errorf("Unexpected history %+v", history)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ChampionChangeQueue lets whoever spots a new champion hand it off and get
+// back to work, while a worker does the slow part of telling everyone
+//
+// Without it a single dead webhook subscriber, with its retries and
+// backoff, would hold up the watcher for the best part of a minute, and
+// no other changes would be noticed in the meantime.
+type ChampionChangeQueue struct {
+	next    ChampionChangeNotifier
+	changes chan ChampionChange
+	logger  *slog.Logger
+}
+
+// NewChampionChangeQueue returns a queue that holds up to size changes for
+// next.  Nothing is delivered until Run is called.
+func NewChampionChangeQueue(next ChampionChangeNotifier, size int, logger *slog.Logger) *ChampionChangeQueue {
+	return &ChampionChangeQueue{
+		next:    next,
+		changes: make(chan ChampionChange, size),
+		logger:  logger,
+	}
+}
+
+// NotifyChampionChanged queues the change and returns straight away
+//
+// If the queue is full the change is dropped rather than making the caller
+// wait, since waiting is exactly what the queue is here to avoid.
+func (q *ChampionChangeQueue) NotifyChampionChanged(ctx context.Context, change ChampionChange) {
+	select {
+	case q.changes <- change:
+
+	default:
+		q.logger.WarnContext(ctx, "Champion change queue is full, dropping change", "previous", change.Previous, "champion", change.Champion)
+	}
+}
+
+// Run hands queued changes to next one at a time, so they arrive in the
+// order they happened, until ctx is done
+func (q *ChampionChangeQueue) Run(ctx context.Context) {
+	for {
+		select {
+		case change := <-q.changes:
+			q.next.NotifyChampionChanged(ctx, change)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
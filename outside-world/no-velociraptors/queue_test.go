@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// blockingChampionChangeNotifier takes forever until it's released
+type blockingChampionChangeNotifier struct {
+	release chan struct{}
+	mock    mockChampionChangeNotifier
+}
+
+func (n *blockingChampionChangeNotifier) NotifyChampionChanged(ctx context.Context, change ChampionChange) {
+	<-n.release
+
+	n.mock.NotifyChampionChanged(ctx, change)
+}
+
+func TestChampionChangeQueueDoesntMakeCallersWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	slow := &blockingChampionChangeNotifier{release: make(chan struct{})}
+	queue := NewChampionChangeQueue(slow, 2, testLogger)
+
+	go queue.Run(ctx)
+
+	queued := make(chan struct{})
+
+	go func() {
+		// The first is picked up by the worker and gets stuck, the next
+		// two fill the queue, and the last has nowhere to go
+		for _, champion := range []string{"Maru", "Rogue", "Serral", "Clem"} {
+			queue.NotifyChampionChanged(ctx, ChampionChange{Champion: champion})
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		close(queued)
+	}()
+
+	select {
+	case <-queued:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected queueing to return while delivery is stuck")
+	}
+
+	close(slow.release)
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		slow.mock.mu.Lock()
+		delivered := len(slow.mock.changes)
+		slow.mock.mu.Unlock()
+
+		if delivered == 3 || time.Now().After(deadline) {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	slow.mock.mu.Lock()
+	defer slow.mock.mu.Unlock()
+
+	var champions []string
+
+	for _, change := range slow.mock.changes {
+		champions = append(champions, change.Champion)
+	}
+
+	if len(champions) != 3 || champions[0] != "Maru" || champions[1] != "Rogue" || champions[2] != "Serral" {
+		t.Errorf("Expected the first three in order and the last dropped but got %v", champions)
+	}
+}
@@ -0,0 +1,43 @@
+// Package ratings turns match results into Elo and Glicko-2 ratings, and
+// ratings into guesses about who wins next.
+//
+// Both systems are here because they disagree in interesting ways.  Elo is
+// simple and everyone understands it.  Glicko-2 also tracks how sure it is
+// about each rating, so a player who's only played twice doesn't get
+// treated like a veteran.
+package ratings
+
+import (
+	"math"
+)
+
+// DefaultElo is where everyone starts
+const DefaultElo = 1500
+
+// DefaultEloK is how far one match can move an Elo rating
+const DefaultEloK = 32
+
+// EloResult is one game from a player's point of view.  Score is 1 for a
+// win, 0 for a loss and 0.5 for a draw.
+type EloResult struct {
+	Opponent float64
+	Score    float64
+}
+
+// EloExpected is the score a player rated a should expect against a player
+// rated b, which is also their chance of winning
+func EloExpected(a float64, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+// EloUpdate returns the player's new rating after a batch of games, all
+// played against opponents' ratings as they stood before the batch
+func EloUpdate(rating float64, k float64, results []EloResult) float64 {
+	change := 0.0
+
+	for _, result := range results {
+		change += result.Score - EloExpected(rating, result.Opponent)
+	}
+
+	return rating + k*change
+}
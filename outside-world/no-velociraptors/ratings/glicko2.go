@@ -0,0 +1,155 @@
+package ratings
+
+import (
+	"math"
+)
+
+// This follows Mark Glickman's "Example of the Glicko-2 system" step by
+// step, so the step numbers in the comments match the paper.
+
+const (
+	// glicko2Scale converts between the Glicko and Glicko-2 scales
+	glicko2Scale = 173.7178
+
+	// convergence is how close the volatility iteration has to get
+	convergence = 0.000001
+)
+
+// Defaults for new players and the system constant, as suggested by the paper
+const (
+	DefaultGlickoRating     = 1500
+	DefaultGlickoDeviation  = 350
+	DefaultGlickoVolatility = 0.06
+	DefaultGlickoTau        = 0.5
+)
+
+// Glicko2Rating is a player's rating, how unsure we are about it (the
+// rating deviation), and how erratic they are (the volatility).  Rating
+// and deviation are on the familiar Glicko scale, not the Glicko-2 one.
+type Glicko2Rating struct {
+	Rating     float64 `json:"rating"`
+	Deviation  float64 `json:"deviation"`
+	Volatility float64 `json:"volatility"`
+}
+
+// NewGlicko2Rating is what a player we've never seen starts with
+func NewGlicko2Rating() Glicko2Rating {
+	return Glicko2Rating{
+		Rating:     DefaultGlickoRating,
+		Deviation:  DefaultGlickoDeviation,
+		Volatility: DefaultGlickoVolatility,
+	}
+}
+
+// Glicko2Result is one game from a player's point of view
+type Glicko2Result struct {
+	Opponent Glicko2Rating
+	Score    float64
+}
+
+// Step 2: convert to the Glicko-2 scale
+func (r Glicko2Rating) scaled() (float64, float64) {
+	return (r.Rating - DefaultGlickoRating) / glicko2Scale, r.Deviation / glicko2Scale
+}
+
+func g(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func expected(mu float64, muOpponent float64, phiOpponent float64) float64 {
+	return 1 / (1 + math.Exp(-g(phiOpponent)*(mu-muOpponent)))
+}
+
+// Glicko2Update returns the player's rating after one rating period.  tau
+// limits how fast volatility can change; 0.3 to 1.2 is sensible.  A player
+// with no results keeps their rating but becomes less certain.
+func Glicko2Update(player Glicko2Rating, tau float64, results []Glicko2Result) Glicko2Rating {
+	mu, phi := player.scaled()
+	sigma := player.Volatility
+
+	if len(results) == 0 {
+		return Glicko2Rating{
+			Rating:     player.Rating,
+			Deviation:  math.Sqrt(phi*phi+sigma*sigma) * glicko2Scale,
+			Volatility: sigma,
+		}
+	}
+
+	// Steps 3 and 4: the estimated variance and improvement
+	varianceInverse := 0.0
+	improvement := 0.0
+
+	for _, result := range results {
+		muJ, phiJ := result.Opponent.scaled()
+		e := expected(mu, muJ, phiJ)
+
+		varianceInverse += g(phiJ) * g(phiJ) * e * (1 - e)
+		improvement += g(phiJ) * (result.Score - e)
+	}
+
+	v := 1 / varianceInverse
+	delta := v * improvement
+
+	// Step 5: the new volatility, found with the Illinois algorithm
+	a := math.Log(sigma * sigma)
+
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(tau*tau)
+	}
+
+	A := a
+	var B float64
+
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+
+		for f(a-k*tau) < 0 {
+			k++
+		}
+
+		B = a - k*tau
+	}
+
+	fA, fB := f(A), f(B)
+
+	for math.Abs(B-A) > convergence {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+
+		B, fB = C, fC
+	}
+
+	newSigma := math.Exp(A / 2)
+
+	// Steps 6 and 7: the new deviation and rating
+	phiStar := math.Sqrt(phi*phi + newSigma*newSigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*improvement
+
+	// Step 8: back to the Glicko scale
+	return Glicko2Rating{
+		Rating:     newMu*glicko2Scale + DefaultGlickoRating,
+		Deviation:  newPhi * glicko2Scale,
+		Volatility: newSigma,
+	}
+}
+
+// Glicko2WinProbability is the chance a beats b.  Both deviations widen the
+// curve, so two uncertain ratings give a prediction closer to a coin flip.
+func Glicko2WinProbability(a Glicko2Rating, b Glicko2Rating) float64 {
+	muA, phiA := a.scaled()
+	muB, phiB := b.scaled()
+
+	return expected(muA, muB, math.Sqrt(phiA*phiA+phiB*phiB))
+}
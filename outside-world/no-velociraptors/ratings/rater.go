@@ -0,0 +1,128 @@
+package ratings
+
+import (
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/players"
+)
+
+// PlayerRating is where a player stands right now in both systems
+type PlayerRating struct {
+	Handle  string        `json:"handle"`
+	Matches int           `json:"matches"`
+	Elo     float64       `json:"elo"`
+	Glicko2 Glicko2Rating `json:"glicko2"`
+}
+
+// RatingPoint is a player's ratings just after one of their matches
+type RatingPoint struct {
+	players.MatchRecord
+	Elo     float64       `json:"elo"`
+	Glicko2 Glicko2Rating `json:"glicko2"`
+}
+
+// Prediction is how likely Player1 is to beat Player2, according to each
+// system
+type Prediction struct {
+	Player1 PlayerRating `json:"player1"`
+	Player2 PlayerRating `json:"player2"`
+	Elo     float64      `json:"eloWinProbability"`
+	Glicko2 float64      `json:"glicko2WinProbability"`
+}
+
+// Rater keeps ratings up to date one match at a time
+//
+// Each match is treated as its own Glicko-2 rating period.  The paper
+// prefers periods with several games each, but GSL players go weeks between
+// matches, so waiting to batch them up would leave ratings stale for ages.
+//
+// A Rater isn't safe to use from more than one goroutine at once.
+type Rater struct {
+	k   float64
+	tau float64
+
+	recorded map[string]bool
+	current  map[string]PlayerRating
+	history  map[string][]RatingPoint
+}
+
+// NewRater returns a Rater using the given Elo K factor and Glicko-2 tau
+func NewRater(k float64, tau float64) *Rater {
+	return &Rater{
+		k:        k,
+		tau:      tau,
+		recorded: make(map[string]bool),
+		current:  make(map[string]PlayerRating),
+		history:  make(map[string][]RatingPoint),
+	}
+}
+
+// Record updates both players' ratings from a finished match.  Recording
+// the same match twice does nothing the second time, so it's safe to feed
+// in the whole history again to pick up anything new.  It reports whether
+// the match was new.
+func (r *Rater) Record(match players.MatchRecord) bool {
+	key := match.TournamentID + "/" + match.MatchID
+
+	if r.recorded[key] {
+		return false
+	}
+
+	r.recorded[key] = true
+
+	before1, before2 := r.Rating(match.Player1), r.Rating(match.Player2)
+
+	score1 := 0.0
+
+	if match.Winner == match.Player1 {
+		score1 = 1
+	}
+
+	r.update(match, before1, before2, score1)
+	r.update(match, before2, before1, 1-score1)
+
+	return true
+}
+
+func (r *Rater) update(match players.MatchRecord, player PlayerRating, opponent PlayerRating, score float64) {
+	player.Matches++
+	player.Elo = EloUpdate(player.Elo, r.k, []EloResult{{Opponent: opponent.Elo, Score: score}})
+	player.Glicko2 = Glicko2Update(player.Glicko2, r.tau, []Glicko2Result{{Opponent: opponent.Glicko2, Score: score}})
+
+	r.current[player.Handle] = player
+	r.history[player.Handle] = append(r.history[player.Handle], RatingPoint{
+		MatchRecord: match,
+		Elo:         player.Elo,
+		Glicko2:     player.Glicko2,
+	})
+}
+
+// Rating returns a player's current ratings.  Players who haven't played
+// get the starting ratings.
+func (r *Rater) Rating(handle string) PlayerRating {
+	if rating, ok := r.current[handle]; ok {
+		return rating
+	}
+
+	return PlayerRating{
+		Handle:  handle,
+		Elo:     DefaultElo,
+		Glicko2: NewGlicko2Rating(),
+	}
+}
+
+// History returns a player's ratings after each of their matches, oldest
+// first
+func (r *Rater) History(handle string) []RatingPoint {
+	return append([]RatingPoint{}, r.history[handle]...)
+}
+
+// Predict guesses how likely player1 is to beat player2
+func (r *Rater) Predict(player1 string, player2 string) Prediction {
+	rating1, rating2 := r.Rating(player1), r.Rating(player2)
+
+	return Prediction{
+		Player1: rating1,
+		Player2: rating2,
+		Elo:     EloExpected(rating1.Elo, rating2.Elo),
+		Glicko2: Glicko2WinProbability(rating1.Glicko2, rating2.Glicko2),
+	}
+}
@@ -0,0 +1,110 @@
+package ratings
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/players"
+)
+
+func assertClose(t *testing.T, name string, expected float64, actual float64, tolerance float64) {
+	t.Helper()
+
+	if math.Abs(expected-actual) > tolerance {
+		t.Errorf("Expected %s to be %v (±%v) but got %v", name, expected, tolerance, actual)
+	}
+}
+
+// The worked example from the Wikipedia article on Elo: a 1613 player
+// expects 2.88 points from these five games, scores 2.5, and drops to 1601.
+// The article rounds each expected score to two places before adding them
+// up, so the unrounded total is a little lower.
+func TestEloUpdateMatchesReferenceExample(t *testing.T) {
+	results := []EloResult{
+		{Opponent: 1609, Score: 0},
+		{Opponent: 1477, Score: 0.5},
+		{Opponent: 1388, Score: 1},
+		{Opponent: 1586, Score: 1},
+		{Opponent: 1720, Score: 0},
+	}
+
+	expected := 0.0
+
+	for _, result := range results {
+		expected += EloExpected(1613, result.Opponent)
+	}
+
+	assertClose(t, "expected score", 2.88, expected, 0.02)
+	assertClose(t, "new rating", 1601, EloUpdate(1613, 32, results), 0.5)
+}
+
+func TestEloExpectedIsSymmetric(t *testing.T) {
+	assertClose(t, "even match", 0.5, EloExpected(1500, 1500), 0)
+	assertClose(t, "400 points up", 10.0/11.0, EloExpected(1900, 1500), 1e-12)
+	assertClose(t, "both sides", 1, EloExpected(1700, 1550)+EloExpected(1550, 1700), 1e-12)
+}
+
+// The example from Glickman's "Example of the Glicko-2 system" paper
+func TestGlicko2UpdateMatchesReferenceExample(t *testing.T) {
+	player := Glicko2Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+
+	updated := Glicko2Update(player, 0.5, []Glicko2Result{
+		{Opponent: Glicko2Rating{Rating: 1400, Deviation: 30, Volatility: 0.06}, Score: 1},
+		{Opponent: Glicko2Rating{Rating: 1550, Deviation: 100, Volatility: 0.06}, Score: 0},
+		{Opponent: Glicko2Rating{Rating: 1700, Deviation: 300, Volatility: 0.06}, Score: 0},
+	})
+
+	assertClose(t, "rating", 1464.06, updated.Rating, 0.01)
+	assertClose(t, "deviation", 151.52, updated.Deviation, 0.01)
+	assertClose(t, "volatility", 0.05999, updated.Volatility, 0.00001)
+}
+
+func TestGlicko2UpdateWithoutGamesOnlyGrowsDeviation(t *testing.T) {
+	player := Glicko2Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+
+	updated := Glicko2Update(player, 0.5, nil)
+
+	assertClose(t, "rating", 1500, updated.Rating, 0)
+	assertClose(t, "deviation", 200.27, updated.Deviation, 0.01)
+}
+
+func TestRaterRecordsEachMatchOnce(t *testing.T) {
+	rater := NewRater(DefaultEloK, DefaultGlickoTau)
+
+	match := players.MatchRecord{TournamentID: "s1", MatchID: "final", Date: time.Now(), Player1: "TY", Player2: "Rogue", Winner: "TY"}
+
+	if !rater.Record(match) {
+		t.Fatal("First recording should be new")
+	}
+
+	if rater.Record(match) {
+		t.Error("Second recording should be ignored")
+	}
+
+	ty, rogue := rater.Rating("TY"), rater.Rating("Rogue")
+
+	// Both start at 1500, so the winner gains exactly K/2 and the loser
+	// drops the same
+	assertClose(t, "TY elo", 1516, ty.Elo, 1e-9)
+	assertClose(t, "Rogue elo", 1484, rogue.Elo, 1e-9)
+
+	if ty.Matches != 1 || len(rater.History("TY")) != 1 {
+		t.Errorf("Expected one match for TY but got %d (history %d)", ty.Matches, len(rater.History("TY")))
+	}
+
+	if ty.Glicko2.Rating <= rogue.Glicko2.Rating || ty.Glicko2.Deviation >= DefaultGlickoDeviation {
+		t.Errorf("Unexpected Glicko-2 ratings TY=%+v Rogue=%+v", ty.Glicko2, rogue.Glicko2)
+	}
+
+	prediction := rater.Predict("TY", "Rogue")
+
+	assertClose(t, "elo prediction", EloExpected(1516, 1484), prediction.Elo, 1e-12)
+
+	if prediction.Glicko2 <= 0.5 || prediction.Glicko2 >= 1 {
+		t.Errorf("Expected TY to be a Glicko-2 favourite but got %v", prediction.Glicko2)
+	}
+
+	// Nobody has heard of this player, so it's a coin flip
+	assertClose(t, "unknown players", 0.5, rater.Predict("Nobody", "Somebody").Elo, 0)
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// view is anything one of our endpoints wants to send back to a client
+//
+// JSON and XML clients get the view itself encoded, so views should be
+// plain structs with the right tags.  Everyone else needs a little help.
+type view interface {
+	// plainText is what text/plain clients see
+	plainText() string
+
+	// title is the heading of the little HTML page
+	title() string
+}
+
+// renderer writes a view in one particular media type
+type renderer struct {
+	mediaType string
+
+	// etagSuffix keeps ETags different for each representation, since a
+	// strong ETag promises byte-for-byte identical responses
+	etagSuffix string
+
+	render func(w io.Writer, v view) error
+}
+
+var htmlPage = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Text}}</p>
+</body>
+</html>
+`))
+
+// renderers are listed in order of preference, so the first one is what
+// clients get when they don't care
+var renderers = []renderer{
+	{
+		mediaType: "text/plain",
+		render: func(w io.Writer, v view) error {
+			_, err := io.WriteString(w, v.plainText())
+			return err
+		},
+	},
+	{
+		mediaType:  "application/json",
+		etagSuffix: "json",
+		render: func(w io.Writer, v view) error {
+			return encodeJSON(w, v)
+		},
+	},
+	{
+		mediaType:  "text/html",
+		etagSuffix: "html",
+		render: func(w io.Writer, v view) error {
+			return htmlPage.Execute(w, struct {
+				Title string
+				Text  string
+			}{v.title(), v.plainText()})
+		},
+	},
+	{
+		mediaType:  "application/xml",
+		etagSuffix: "xml",
+		render: func(w io.Writer, v view) error {
+			return encodeXML(w, v)
+		},
+	},
+}
+
+// dataRenderer writes values that only make sense in one media type, like
+// a bracket as JSON or the champion history as an Atom feed
+//
+// These endpoints still negotiate, they just only have the one answer.
+type dataRenderer struct {
+	mediaType string
+	encode    func(w io.Writer, v interface{}) error
+}
+
+var (
+	jsonData = dataRenderer{mediaType: "application/json", encode: encodeJSON}
+	atomData = dataRenderer{mediaType: "application/atom+xml", encode: encodeXML}
+)
+
+func encodeJSON(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func encodeXML(w io.Writer, v interface{}) error {
+	_, err := io.WriteString(w, xml.Header)
+
+	if err != nil {
+		return err
+	}
+
+	return xml.NewEncoder(w).Encode(v)
+}
+
+// contentType is the full Content-Type header for this renderer
+func (r renderer) contentType() string {
+	return r.mediaType + "; charset=utf-8"
+}
+
+// etag makes a representation specific ETag out of the resource's ETag
+func (r renderer) etag(resourceETag string) string {
+	if r.etagSuffix == "" || !strings.HasSuffix(resourceETag, `"`) {
+		return resourceETag
+	}
+
+	return strings.TrimSuffix(resourceETag, `"`) + "-" + r.etagSuffix + `"`
+}
+
+// resourceETag undoes renderer.etag for one entity tag, so a client can
+// send back whichever representation's ETag it happened to see
+func resourceETag(representationETag string) string {
+	for _, r := range renderers {
+		if r.etagSuffix == "" {
+			continue
+		}
+
+		suffix := "-" + r.etagSuffix + `"`
+
+		if strings.HasSuffix(representationETag, suffix) {
+			return strings.TrimSuffix(representationETag, suffix) + `"`
+		}
+	}
+
+	return representationETag
+}
+
+type acceptedRange struct {
+	mediaRange string
+	quality    float64
+}
+
+// negotiate picks the best renderer for the request's Accept header.  It
+// returns false if we can't make anything the client would accept.
+func negotiate(req *http.Request) (renderer, bool) {
+	accept := req.Header.Get("Accept")
+
+	if strings.TrimSpace(accept) == "" {
+		return renderers[0], true
+	}
+
+	ranges := parseAccept(accept)
+
+	best := -1
+	bestQuality := 0.0
+
+	for i, r := range renderers {
+		quality := qualityFor(ranges, r.mediaType)
+
+		if quality > bestQuality {
+			best = i
+			bestQuality = quality
+		}
+	}
+
+	if best < 0 {
+		return renderer{}, false
+	}
+
+	return renderers[best], true
+}
+
+func parseAccept(accept string) []acceptedRange {
+	var ranges []acceptedRange
+
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaRange := strings.ToLower(strings.TrimSpace(params[0]))
+
+		if mediaRange == "" {
+			continue
+		}
+
+		quality := 1.0
+
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+
+			parsed, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+
+			if err == nil {
+				quality = parsed
+			}
+		}
+
+		ranges = append(ranges, acceptedRange{mediaRange, quality})
+	}
+
+	return ranges
+}
+
+// qualityFor returns how much the client wants this media type, using the
+// most specific range that matches it
+func qualityFor(ranges []acceptedRange, mediaType string) float64 {
+	kind := strings.SplitN(mediaType, "/", 2)[0]
+
+	bestSpecificity := -1
+	quality := 0.0
+
+	for _, r := range ranges {
+		specificity := -1
+
+		switch r.mediaRange {
+		case mediaType:
+			specificity = 2
+		case kind + "/*":
+			specificity = 1
+		case "*/*":
+			specificity = 0
+		}
+
+		if specificity > bestSpecificity {
+			bestSpecificity = specificity
+			quality = r.quality
+		}
+	}
+
+	return quality
+}
+
+// accepts is whether the client will take the given media type at all
+func accepts(req *http.Request, mediaType string) bool {
+	accept := req.Header.Get("Accept")
+
+	return strings.TrimSpace(accept) == "" || qualityFor(parseAccept(accept), mediaType) > 0
+}
+
+// writeNotAcceptable tells the client what we could have sent them
+func writeNotAcceptable(res http.ResponseWriter, supported []string) {
+	supported = append([]string(nil), supported...)
+	sort.Strings(supported)
+
+	res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	res.WriteHeader(http.StatusNotAcceptable)
+	res.Write([]byte("Supported types: " + strings.Join(supported, ", ")))
+}
+
+// writeView renders a view with the renderer we negotiated earlier
+func writeView(res http.ResponseWriter, req *http.Request, logger *slog.Logger, r renderer, v view) {
+	res.Header().Set("Content-Type", r.contentType())
+
+	err := r.render(res, v)
+
+	if err != nil {
+		// Headers are already gone at this point, so all we can do is log it
+		logger.ErrorContext(req.Context(), "Failed to render view", "media_type", r.mediaType, "error", err)
+	}
+}
+
+// viewMediaTypes is every media type a view can be written as
+func viewMediaTypes() []string {
+	mediaTypes := make([]string, 0, len(renderers))
+
+	for _, r := range renderers {
+		mediaTypes = append(mediaTypes, r.mediaType)
+	}
+
+	return mediaTypes
+}
+
+// writeData sends a value in the data renderer's media type, or a 406 if
+// the client said it won't take that
+func writeData(res http.ResponseWriter, req *http.Request, logger *slog.Logger, d dataRenderer, status int, v interface{}) {
+	if !accepts(req, d.mediaType) {
+		writeNotAcceptable(res, []string{d.mediaType})
+		return
+	}
+
+	res.Header().Set("Content-Type", d.mediaType+"; charset=utf-8")
+	res.WriteHeader(status)
+
+	err := d.encode(res, v)
+
+	if err != nil {
+		logger.ErrorContext(req.Context(), "Failed to encode response", "media_type", d.mediaType, "error", err)
+	}
+}
+
+// writeJSON is writeData for the many endpoints that only speak JSON
+func writeJSON(res http.ResponseWriter, req *http.Request, logger *slog.Logger, status int, v interface{}) {
+	writeData(res, req, logger, jsonData, status, v)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveChampionWithAccept(accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/champion", nil)
+	res := httptest.NewRecorder()
+
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	championGetter := &mockCurrentChampionGetter{current: "TY"}
+
+	gslCurrentChampionHandler(championGetter, testLogger)(res, req)
+
+	return res
+}
+
+func TestNegotiatePicksBestRenderer(t *testing.T) {
+	cases := map[string]string{
+		"":                                 "text/plain",
+		"*/*":                              "text/plain",
+		"application/json":                 "application/json",
+		"text/html, application/xml;q=0.9": "text/html",
+		"application/xml, text/*;q=0.5":    "application/xml",
+		"text/*;q=0.5, text/plain;q=0.1":   "text/html",
+		"application/*":                    "application/json",
+		"*/*;q=0.1, application/xml":       "application/xml",
+	}
+
+	for accept, expected := range cases {
+		req := httptest.NewRequest("GET", "/champion", nil)
+		req.Header.Set("Accept", accept)
+
+		got, ok := negotiate(req)
+
+		if !ok {
+			t.Errorf("Expected %q to be acceptable", accept)
+			continue
+		}
+
+		if got.mediaType != expected {
+			t.Errorf("Expected %q to pick %s but got %s", accept, expected, got.mediaType)
+		}
+	}
+}
+
+func TestGSLCurrentChampionRendersJSON(t *testing.T) {
+	res := serveChampionWithAccept("application/json")
+
+	if res.Code != 200 {
+		t.Fatalf("Expected code 200 but got %d", res.Code)
+	}
+
+	var body struct {
+		Name string `json:"name"`
+	}
+
+	err := json.Unmarshal(res.Body.Bytes(), &body)
+
+	if err != nil {
+		t.Fatal("json.Unmarshal:", err)
+	}
+
+	if body.Name != "TY" {
+		t.Errorf("Expected name %q but got %q", "TY", body.Name)
+	}
+
+	if !strings.HasPrefix(res.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("Expected JSON content type but got %q", res.Header().Get("Content-Type"))
+	}
+}
+
+func TestGSLCurrentChampionRendersXML(t *testing.T) {
+	res := serveChampionWithAccept("application/xml")
+
+	var body struct {
+		XMLName xml.Name `xml:"champion"`
+		Name    string   `xml:"name"`
+	}
+
+	err := xml.Unmarshal(res.Body.Bytes(), &body)
+
+	if err != nil {
+		t.Fatal("xml.Unmarshal:", err)
+	}
+
+	if body.Name != "TY" {
+		t.Errorf("Expected name %q but got %q", "TY", body.Name)
+	}
+}
+
+func TestGSLCurrentChampionRendersHTML(t *testing.T) {
+	res := serveChampionWithAccept("text/html")
+
+	if !strings.Contains(res.Body.String(), "<p>TY</p>") {
+		t.Errorf("Expected the champion in the page but got %q", res.Body.String())
+	}
+}
+
+func TestGSLCurrentChampionReturns406ForUnsupportedType(t *testing.T) {
+	res := serveChampionWithAccept("image/png")
+
+	if res.Code != 406 {
+		t.Errorf("Expected code 406 but got %d", res.Code)
+	}
+}
+
+func TestRepresentationETagsRoundTrip(t *testing.T) {
+	for _, r := range renderers {
+		etag := r.etag(`"abc"`)
+
+		if got := resourceETag(etag); got != `"abc"` {
+			t.Errorf("Expected %s ETag %s to map back to %s but got %s", r.mediaType, etag, `"abc"`, got)
+		}
+	}
+}
+
+func TestWriteJSONNegotiatesLikeViews(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		expected int
+	}{
+		{"NoPreference", "", 200},
+		{"JSON", "application/json", 200},
+		{"Anything", "text/html, */*;q=0.8", 200},
+		{"OnlyHTML", "text/html", 406},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/predict", nil)
+			res := httptest.NewRecorder()
+
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+
+			writeJSON(res, req, testLogger, 200, map[string]string{"winner": "TY"})
+
+			if res.Code != test.expected {
+				t.Fatalf("Expected code %d but got %d", test.expected, res.Code)
+			}
+
+			if test.expected == 200 && res.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+				t.Errorf("Unexpected content type %q", res.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
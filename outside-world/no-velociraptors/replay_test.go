@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Evertras/go-interface-examples/replay"
+)
+
+// These use what the real GSLDataStore said about the real champion.txt,
+// recorded in testdata.  Run them with -replay.record to ask it again.
+
+func replayedChampionGetter(t *testing.T, championFile string) CurrentChampionGetter {
+	t.Helper()
+
+	return NewCurrentChampionGetterDecorator(NewGSLDataStore(championFile),
+		replay.Golden(t, "testdata/"+t.Name()+".golden.json"),
+	)
+}
+
+func TestRecordedGSLCurrentChampion(t *testing.T) {
+	req := httptest.NewRequest("GET", "/champion", nil)
+	res := httptest.NewRecorder()
+
+	gslCurrentChampionHandler(replayedChampionGetter(t, "./champion.txt"), testLogger)(res, req)
+
+	if res.Code != 200 || res.Body.String() != "TY" {
+		t.Errorf("Expected 200 with TY but got %d with %q", res.Code, res.Body.String())
+	}
+}
+
+func TestRecordedGSLCurrentChampionWithoutAFile(t *testing.T) {
+	req := httptest.NewRequest("GET", "/champion", nil)
+	res := httptest.NewRecorder()
+
+	gslCurrentChampionHandler(replayedChampionGetter(t, "./testdata/no-such-champion.txt"), testLogger)(res, req)
+
+	if res.Code != 500 {
+		t.Errorf("Expected 500 but got %d", res.Code)
+	}
+}
@@ -1,8 +1,18 @@
 package main
 
 import (
-	"log"
+	"context"
+	"encoding/xml"
+	"errors"
+	"log/slog"
+	"net"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/ratelimit"
+	"github.com/Evertras/go-interface-examples/requestid"
 )
 
 // CurrentChampionGetter can get the current champion somehow
@@ -11,8 +21,47 @@ import (
 // to ask for "something" that can get the current champion.  We don't care how.
 // We don't need to know how.  We don't want to know.  All we know is that
 // it can get the current champion, and we cannot do anything else.
+//
+// The context lets whoever is asking give up.  If the client hangs up or
+// we run out of time, a slow data store can stop working on an answer that
+// nobody is going to read.
 type CurrentChampionGetter interface {
-	GetCurrentChampion() (string, error)
+	GetCurrentChampion(ctx context.Context) (string, error)
+}
+
+// championSourceReporter is something that can also tell us where the
+// champion came from
+//
+// We don't require this, we just take advantage of it if it's there.  This is
+// the same trick net/http uses with http.Flusher.
+type championSourceReporter interface {
+	GetCurrentChampionWithSource(ctx context.Context) (string, string, error)
+}
+
+// championSourceHeader tells the client which source answered, which is
+// really nice when debugging why a stale champion showed up
+const championSourceHeader = "X-Champion-Source"
+
+// defaultRequestTimeout is how long any single request gets before we give up
+const defaultRequestTimeout = 5 * time.Second
+
+// defaultShutdownTimeout is how long requests already in flight get to
+// finish once we've been asked to stop.  It's a little longer than a
+// request is allowed to take, so anything that started can finish.
+const defaultShutdownTimeout = defaultRequestTimeout + time.Second
+
+// championView is what we send back when someone asks about the champion
+type championView struct {
+	XMLName xml.Name `json:"-" xml:"champion"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+func (v championView) plainText() string {
+	return v.Name
+}
+
+func (v championView) title() string {
+	return "Current GSL Champion"
 }
 
 // Creates a handler that writes the current champion to the client.
@@ -20,27 +69,241 @@ type CurrentChampionGetter interface {
 // Now our handler is saying something very powerful.  It's saying
 // "I need something that can get the current champion in order to
 // do my job."  This is much more descriptive than before!
-func gslCurrentChampionHandler(currentChampionGetter CurrentChampionGetter) http.HandlerFunc {
+//
+// The client picks the format with the Accept header.  Plain text is still
+// what you get if you don't ask for anything in particular.
+func gslCurrentChampionHandler(currentChampionGetter CurrentChampionGetter, logger *slog.Logger) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
-		champion, err := currentChampionGetter.GetCurrentChampion()
+		res.Header().Set("Vary", "Accept")
+
+		representation, ok := negotiate(req)
+
+		if !ok {
+			writeNotAcceptable(res, viewMediaTypes())
+			return
+		}
+
+		var (
+			champion string
+			source   string
+			err      error
+		)
+
+		ctx := req.Context()
+
+		if reporter, ok := currentChampionGetter.(championSourceReporter); ok {
+			champion, source, err = reporter.GetCurrentChampionWithSource(ctx)
+		} else {
+			champion, err = currentChampionGetter.GetCurrentChampion(ctx)
+		}
 
 		if err != nil {
-			log.Println("Failed to get current champion:", err)
-			res.WriteHeader(500)
+			logger.ErrorContext(req.Context(), "Failed to get current champion", "error", err)
+			writeDataStoreError(res, err)
 			return
 		}
 
-		res.Write([]byte(champion))
+		if source != "" {
+			res.Header().Set(championSourceHeader, source)
+		}
+
+		writeView(res, req, logger, representation, championView{Name: champion})
+	}
+}
+
+// writeDataStoreError picks a status code based on why the data store
+// couldn't do what we asked
+func writeDataStoreError(res http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		// The client hung up, so there's nobody to tell.  Write something
+		// anyway so our logs and any middleware see a sensible status.
+		res.WriteHeader(http.StatusServiceUnavailable)
+
+	case errors.Is(err, context.DeadlineExceeded):
+		res.WriteHeader(http.StatusGatewayTimeout)
+
+	default:
+		res.WriteHeader(500)
+	}
+}
+
+// withRequestDeadline gives every request a deadline so nothing downstream
+// can hang around forever
+func withRequestDeadline(timeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), timeout)
+		defer cancel()
+
+		next.ServeHTTP(res, req.WithContext(ctx))
+	})
+}
+
+// methodHandlers sends each request to the handler for its HTTP method
+type methodHandlers map[string]http.Handler
+
+func (h methodHandlers) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	handler, ok := h[req.Method]
+
+	if !ok && req.Method == http.MethodHead {
+		handler, ok = h[http.MethodGet]
+	}
+
+	if !ok {
+		allowed := make([]string, 0, len(h))
+
+		for method := range h {
+			allowed = append(allowed, method)
+		}
+
+		sort.Strings(allowed)
+
+		res.Header().Set("Allow", strings.Join(allowed, ", "))
+		res.WriteHeader(http.StatusMethodNotAllowed)
+		return
 	}
+
+	handler.ServeHTTP(res, req)
 }
 
-// Runs the server on the specified address with the given data store
+// RequestDeduplicator makes sure a retried request only happens once
 //
-// Our server is
-func runServer(address string, currentChampionGetter CurrentChampionGetter) error {
+// Main hands us an idempotency cache, but all the server knows is that it
+// can wrap a handler.
+type RequestDeduplicator interface {
+	Middleware(next http.Handler) http.Handler
+}
+
+// serverConfig is everything about how the server runs that isn't a
+// dependency on the outside world
+type serverConfig struct {
+	address         string
+	requestTimeout  time.Duration
+	shutdownTimeout time.Duration
+
+	// Maps bearer tokens to the admin that owns them
+	adminTokens map[string]string
+
+	// Sent as-is in the Cache-Control header of champion responses
+	cacheControl string
+
+	// How fast each client can call each route, keyed by route pattern.
+	// Routes that aren't here aren't limited.
+	rateLimits map[string]ratelimit.Limit
+}
+
+// serverDependencies is everything the server needs from the outside world
+//
+// Every field is one of our small local interfaces.  Reading this tells you
+// exactly what the server is able to do, and nothing more.
+type serverDependencies struct {
+	currentChampionGetter CurrentChampionGetter
+	championVersionGetter ChampionVersionGetter
+	currentChampionSetter CurrentChampionSetter
+	championAuditor       ChampionChangeAuditor
+	championHistoryGetter ChampionHistoryGetter
+	webhookSubscriber     WebhookSubscriber
+	tournamentGetter      TournamentGetter
+	matchResultRecorder   MatchResultRecorder
+	playerGetter          PlayerGetter
+	matchHistoryGetter    MatchHistoryGetter
+	matchPredictor        MatchPredictor
+	ratingHistoryGetter   RatingHistoryGetter
+	ratingsGetter         RatingsGetter
+
+	// Where handlers log what went wrong.  Log with the request's context,
+	// so whatever the logger does with it, like adding the request ID,
+	// happens for free.
+	logger *slog.Logger
+
+	// Optional: we're only ready when this can get the champion
+	readinessProbe CurrentChampionGetter
+
+	// Optional: who hears about requests, and what serves /metrics
+	requestObserver RequestObserver
+	metricsHandler  http.Handler
+
+	// Optional: who replays responses to requests with an Idempotency-Key
+	requestDeduplicator RequestDeduplicator
+}
+
+// newServerHandler wires up all our routes
+//
+// This is split out from runServer so tests can poke at the whole server
+// without actually listening on a port.
+func newServerHandler(config serverConfig, deps serverDependencies) http.Handler {
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/champion", gslCurrentChampionHandler(currentChampionGetter))
+	// Admins are limited as themselves, everyone else by address
+	clientKey := ratelimit.ClientKey(adminPrincipal(config.adminTokens))
+
+	handle := func(pattern string, handler http.Handler) {
+		// Replays still count against the rate limit, so retrying in a
+		// tight loop doesn't get around it
+		if deps.requestDeduplicator != nil {
+			handler = deps.requestDeduplicator.Middleware(handler)
+		}
+
+		if limit, ok := config.rateLimits[pattern]; ok {
+			handler = ratelimit.NewLimiter(limit).Middleware(clientKey, handler)
+		}
+
+		mux.Handle(pattern, instrumentRoute(pattern, deps.requestObserver, handler))
+	}
+
+	handle("/champion", methodHandlers{
+		http.MethodGet: withConditionalGet(deps.championVersionGetter, config.cacheControl, deps.logger, gslCurrentChampionHandler(deps.currentChampionGetter, deps.logger)),
+		http.MethodPut: requireAdmin(config.adminTokens, gslUpdateChampionHandler(deps.currentChampionSetter, deps.championAuditor, deps.logger)),
+	})
+
+	handle("/champions/feed", methodHandlers{
+		http.MethodGet: gslChampionFeedHandler(deps.championHistoryGetter, deps.logger),
+	})
+
+	handle("/webhooks", requireAdmin(config.adminTokens, methodHandlers{
+		http.MethodGet:  gslListWebhooksHandler(deps.webhookSubscriber, deps.logger),
+		http.MethodPost: gslAddWebhookHandler(deps.webhookSubscriber, deps.logger),
+	}))
+
+	handle("/webhooks/", requireAdmin(config.adminTokens, methodHandlers{
+		http.MethodDelete: gslRemoveWebhookHandler(deps.webhookSubscriber, deps.logger),
+	}))
+
+	handle("/tournaments/", tournamentRoutes(config, deps))
+	handle("/players/", playerRoutes(deps))
+
+	handle("/predict", methodHandlers{
+		http.MethodGet: gslPredictHandler(deps.matchPredictor, deps.logger),
+	})
+
+	// Probes are asked constantly, so they stay out of the metrics
+	mux.Handle("/healthz", methodHandlers{
+		http.MethodGet: http.HandlerFunc(healthzHandler),
+	})
+
+	mux.Handle("/readyz", methodHandlers{
+		http.MethodGet: readyzHandler(deps.readinessProbe, deps.logger),
+	})
+
+	if deps.metricsHandler != nil {
+		mux.Handle("/metrics", methodHandlers{
+			http.MethodGet: deps.metricsHandler,
+		})
+	}
+
+	// Everything, probes included, gets a request ID to find its logs by
+	return requestid.Middleware(withRequestDeadline(config.requestTimeout, mux))
+}
+
+// Runs the server with the given config and dependencies, until ctx is done
+//
+// We still aren't creating any of our dependencies here.  That's main's job.
+func runServer(ctx context.Context, config serverConfig, deps serverDependencies) error {
+	listener, err := net.Listen("tcp", config.address)
+
+	if err != nil {
+		return err
+	}
 
-	return http.ListenAndServe(address, mux)
+	return serve(ctx, listener, config, deps)
 }
@@ -1,21 +1,39 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/ratelimit"
+	"github.com/Evertras/go-interface-examples/requestid"
 )
 
+// testLogger keeps what handlers log out of the test output
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
 // A simple mock that lets us specify who the current champion is, or even
 // what error to return to test our error handling!
 type mockCurrentChampionGetter struct {
 	current      string
 	pendingError error
+
+	lastContext context.Context
 }
 
 // We match the interface that our handler requires, so we can pass it in
 // to the handler
-func (g *mockCurrentChampionGetter) GetCurrentChampion() (string, error) {
+func (g *mockCurrentChampionGetter) GetCurrentChampion(ctx context.Context) (string, error) {
+	g.lastContext = ctx
+
 	if g.pendingError != nil {
 		return "", g.pendingError
 	}
@@ -34,7 +52,7 @@ func TestGSLCurrentChampionReturned(t *testing.T) {
 	req := httptest.NewRequest("GET", "/champion", nil)
 	res := httptest.NewRecorder()
 
-	handler := gslCurrentChampionHandler(championGetter)
+	handler := gslCurrentChampionHandler(championGetter, testLogger)
 
 	handler(res, req)
 
@@ -62,7 +80,7 @@ func TestGSLCurrentChampionReturns500WhenGetterFails(t *testing.T) {
 	req := httptest.NewRequest("GET", "/champion", nil)
 	res := httptest.NewRecorder()
 
-	handler := gslCurrentChampionHandler(championGetter)
+	handler := gslCurrentChampionHandler(championGetter, testLogger)
 
 	handler(res, req)
 
@@ -72,3 +90,130 @@ func TestGSLCurrentChampionReturns500WhenGetterFails(t *testing.T) {
 		t.Errorf("Expected code 500 but got %d", gotCode)
 	}
 }
+
+func TestGSLCurrentChampionReturns504WhenGetterTimesOut(t *testing.T) {
+	championGetter := &mockCurrentChampionGetter{
+		pendingError: fmt.Errorf("slow: %w", context.DeadlineExceeded),
+	}
+
+	req := httptest.NewRequest("GET", "/champion", nil)
+	res := httptest.NewRecorder()
+
+	handler := gslCurrentChampionHandler(championGetter, testLogger)
+
+	handler(res, req)
+
+	if res.Code != 504 {
+		t.Errorf("Expected code 504 but got %d", res.Code)
+	}
+}
+
+func TestGSLCurrentChampionPassesRequestDeadlineToGetter(t *testing.T) {
+	championGetter := &mockCurrentChampionGetter{
+		current: "TY",
+	}
+
+	req := httptest.NewRequest("GET", "/champion", nil)
+	res := httptest.NewRecorder()
+
+	handler := withRequestDeadline(time.Minute, gslCurrentChampionHandler(championGetter, testLogger))
+
+	handler.ServeHTTP(res, req)
+
+	if championGetter.lastContext == nil {
+		t.Fatal("Expected getter to be called with a context")
+	}
+
+	if _, ok := championGetter.lastContext.Deadline(); !ok {
+		t.Error("Expected getter context to have a deadline")
+	}
+}
+
+func TestGSLChampionIsRateLimitedPerClient(t *testing.T) {
+	config := serverConfig{
+		requestTimeout: defaultRequestTimeout,
+		adminTokens: map[string]string{
+			"secret-token": "evertras",
+		},
+		rateLimits: map[string]ratelimit.Limit{
+			"/champion": {Requests: 1, Per: time.Minute},
+		},
+	}
+
+	server := newServerHandler(config, serverDependencies{
+		logger:                testLogger,
+		currentChampionGetter: &mockCurrentChampionGetter{current: "TY"},
+		championVersionGetter: &mockChampionVersionGetter{pendingVersion: testChampionVersion},
+	})
+
+	get := func(remoteAddr string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/champion", nil)
+		req.RemoteAddr = remoteAddr
+
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, req)
+
+		return res
+	}
+
+	if res := get("10.0.0.1:1234", ""); res.Code != 200 || res.Header().Get(ratelimit.HeaderRemaining) != "0" {
+		t.Fatalf("Expected the first request through with none remaining but got %d and %v", res.Code, res.Header())
+	}
+
+	res := get("10.0.0.1:5678", "")
+
+	if res.Code != 429 || res.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected 429 with Retry-After 60 from the same address but got %d and %q", res.Code, res.Header().Get("Retry-After"))
+	}
+
+	// An admin from the same address has their own bucket
+	if res := get("10.0.0.1:1234", "secret-token"); res.Code != 200 {
+		t.Errorf("Expected the admin through but got %d", res.Code)
+	}
+
+	if res := get("10.0.0.2:1234", ""); res.Code != 200 {
+		t.Errorf("Expected another address through but got %d", res.Code)
+	}
+}
+
+func TestGSLLogsCarryTheRequestID(t *testing.T) {
+	var logs bytes.Buffer
+
+	server := newServerHandler(serverConfig{requestTimeout: defaultRequestTimeout}, serverDependencies{
+		logger:                slog.New(requestid.NewLogHandler(slog.NewJSONHandler(&logs, nil))),
+		currentChampionGetter: &mockCurrentChampionGetter{pendingError: errors.New("file is on fire")},
+		championVersionGetter: &mockChampionVersionGetter{pendingError: errors.New("file is on fire")},
+	})
+
+	req := httptest.NewRequest("GET", "/champion", nil)
+	req.Header.Set(requestid.Header, "abc-123")
+	res := httptest.NewRecorder()
+
+	server.ServeHTTP(res, req)
+
+	if res.Header().Get(requestid.Header) != "abc-123" {
+		t.Errorf("Expected the request ID back but got %q", res.Header().Get(requestid.Header))
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+
+	for _, line := range lines {
+		var record map[string]interface{}
+
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Expected JSON logs but got %q", line)
+		}
+
+		if record[requestid.LogKey] != "abc-123" {
+			t.Errorf("Expected every log line to have the request ID but got %q", line)
+		}
+	}
+
+	if len(lines) < 2 {
+		t.Errorf("Expected the version and champion failures logged but got %q", logs.String())
+	}
+}
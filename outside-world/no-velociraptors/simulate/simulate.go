@@ -0,0 +1,247 @@
+// Package simulate plays out the rest of a tournament many times over to
+// see how likely each player is to go deep.
+//
+// Runs are split into fixed size batches, and each batch gets its own
+// random number generator seeded from the overall seed and the batch
+// number.  That way the same seed always gives the same odds no matter how
+// many workers share the batches.
+//
+// Simulating is all CPU, so it checks its context between batches.  Give
+// it a deadline and it gives up rather than keep a core busy for nobody.
+package simulate
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/bracket"
+)
+
+// batchSize is how many runs share one random number generator
+const batchSize = 1000
+
+// MapWinProbability returns the chance player1 wins a single map against
+// player2.  It must be safe to call from several goroutines at once.
+type MapWinProbability func(player1 string, player2 string) float64
+
+// Options controls a simulation
+type Options struct {
+	// Runs is how many times to play out the tournament
+	Runs int
+
+	// Seed makes the simulation repeatable
+	Seed int64
+
+	// Workers is how many goroutines to use, defaulting to one per CPU
+	Workers int
+}
+
+// PlayerOdds is how likely one player is to reach each stage, and to win
+// the whole thing
+type PlayerOdds struct {
+	Player   string             `json:"player"`
+	Stages   map[string]float64 `json:"stages"`
+	Champion float64            `json:"champion"`
+}
+
+// Result is the odds for every player, best chance of winning first
+type Result struct {
+	Runs    int          `json:"runs"`
+	Seed    int64        `json:"seed"`
+	Players []PlayerOdds `json:"players"`
+}
+
+type tally struct {
+	err error
+
+	stages    map[string]map[string]int
+	champions map[string]int
+}
+
+func newTally() tally {
+	return tally{
+		stages:    make(map[string]map[string]int),
+		champions: make(map[string]int),
+	}
+}
+
+func (t tally) add(other tally) {
+	for stage, players := range other.stages {
+		if t.stages[stage] == nil {
+			t.stages[stage] = make(map[string]int)
+		}
+
+		for player, count := range players {
+			t.stages[stage][player] += count
+		}
+	}
+
+	for player, count := range other.champions {
+		t.champions[player] += count
+	}
+}
+
+// Run plays out the tournament opts.Runs times from where it currently
+// stands.  Results already recorded, including maps from a match that's
+// still being played, are kept as they are.
+//
+// If ctx is done before every batch has run, Run stops and returns its
+// error.
+func Run(ctx context.Context, tournament *bracket.Tournament, probability MapWinProbability, opts Options) (Result, error) {
+	if opts.Runs < 1 {
+		return Result{}, errors.New("need at least one run")
+	}
+
+	if err := tournament.Validate(); err != nil {
+		return Result{}, err
+	}
+
+	workers := opts.Workers
+
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	batches := (opts.Runs + batchSize - 1) / batchSize
+	batchNumbers := make(chan int)
+	tallies := make(chan tally)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for batch := range batchNumbers {
+				runs := batchSize
+
+				if batch == batches-1 {
+					runs = opts.Runs - batch*batchSize
+				}
+
+				rng := rand.New(rand.NewSource(opts.Seed + int64(batch)))
+				tallies <- runBatch(tournament, probability, rng, runs)
+			}
+		}()
+	}
+
+	go func() {
+		defer func() {
+			close(batchNumbers)
+			wg.Wait()
+			close(tallies)
+		}()
+
+		for batch := 0; batch < batches; batch++ {
+			select {
+			case batchNumbers <- batch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	total := newTally()
+
+	var err error
+
+	for batchTally := range tallies {
+		if batchTally.err != nil {
+			err = batchTally.err
+			continue
+		}
+
+		total.add(batchTally)
+	}
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Batches stop being handed out once ctx is done, so some never ran
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	return buildResult(tournament, total, opts), nil
+}
+
+func runBatch(tournament *bracket.Tournament, probability MapWinProbability, rng *rand.Rand, runs int) tally {
+	result := newTally()
+
+	nextMap := func(player1 string, player2 string) bracket.MapResult {
+		winner := player2
+
+		if rng.Float64() < probability(player1, player2) {
+			winner = player1
+		}
+
+		return bracket.MapResult{Map: "simulated", Winner: winner}
+	}
+
+	for run := 0; run < runs; run++ {
+		played := tournament.Clone()
+
+		if err := played.PlayOut(nextMap); err != nil {
+			return tally{err: err}
+		}
+
+		view := played.View()
+
+		for _, stage := range view.Stages {
+			if result.stages[stage.Name] == nil {
+				result.stages[stage.Name] = make(map[string]int)
+			}
+
+			reached := make(map[string]bool)
+
+			for _, match := range stage.Matches {
+				reached[match.Player1] = true
+				reached[match.Player2] = true
+			}
+
+			for player := range reached {
+				result.stages[stage.Name][player]++
+			}
+		}
+
+		result.champions[view.Champion]++
+	}
+
+	return result
+}
+
+func buildResult(tournament *bracket.Tournament, total tally, opts Options) Result {
+	result := Result{
+		Runs: opts.Runs,
+		Seed: opts.Seed,
+	}
+
+	runs := float64(opts.Runs)
+
+	for _, player := range tournament.Entrants() {
+		odds := PlayerOdds{
+			Player:   player,
+			Stages:   make(map[string]float64),
+			Champion: float64(total.champions[player]) / runs,
+		}
+
+		for _, stage := range tournament.Stages {
+			odds.Stages[stage.Name] = float64(total.stages[stage.Name][player]) / runs
+		}
+
+		result.Players = append(result.Players, odds)
+	}
+
+	sort.SliceStable(result.Players, func(i, j int) bool {
+		return result.Players[i].Champion > result.Players[j].Champion
+	})
+
+	return result
+}
@@ -0,0 +1,163 @@
+package simulate
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/bracket"
+)
+
+func newTestTournament(t *testing.T) *bracket.Tournament {
+	t.Helper()
+
+	groupA, firstA, secondA := bracket.DualTournamentGroup("Group A", "a", [4]bracket.Source{bracket.Seed("TY"), bracket.Seed("Rogue"), bracket.Seed("Maru"), bracket.Seed("Dark")}, 3)
+	groupB, firstB, secondB := bracket.DualTournamentGroup("Group B", "b", [4]bracket.Source{bracket.Seed("Zest"), bracket.Seed("Solar"), bracket.Seed("Stats"), bracket.Seed("Trap")}, 3)
+
+	playoffs, champion, err := bracket.SingleEliminationBracket("Playoffs", "playoffs", []bracket.Source{firstA, secondB, firstB, secondA}, 5, 7)
+
+	if err != nil {
+		t.Fatal("bracket.SingleEliminationBracket:", err)
+	}
+
+	return &bracket.Tournament{
+		ID:     "test",
+		Stages: []bracket.Stage{groupA, groupB, playoffs},
+		Final:  champion.WinnerOf,
+	}
+}
+
+func coinFlip(string, string) float64 {
+	return 0.5
+}
+
+func TestRunIsRepeatableWhateverTheWorkerCount(t *testing.T) {
+	tournament := newTestTournament(t)
+
+	one, err := Run(context.Background(), tournament, coinFlip, Options{Runs: 2500, Seed: 42, Workers: 1})
+
+	if err != nil {
+		t.Fatal("Run:", err)
+	}
+
+	many, err := Run(context.Background(), tournament, coinFlip, Options{Runs: 2500, Seed: 42, Workers: 8})
+
+	if err != nil {
+		t.Fatal("Run:", err)
+	}
+
+	if !reflect.DeepEqual(one, many) {
+		t.Errorf("Same seed gave different results:\n%+v\n%+v", one, many)
+	}
+}
+
+func TestRunLeavesTournamentAlone(t *testing.T) {
+	tournament := newTestTournament(t)
+
+	_, err := Run(context.Background(), tournament, coinFlip, Options{Runs: 10, Seed: 1})
+
+	if err != nil {
+		t.Fatal("Run:", err)
+	}
+
+	if _, ok := tournament.Winner("a-opening-1"); ok {
+		t.Error("Simulated results leaked into the real tournament")
+	}
+}
+
+func TestRunWithEvenPlayersGivesEvenOdds(t *testing.T) {
+	result, err := Run(context.Background(), newTestTournament(t), coinFlip, Options{Runs: 10000, Seed: 7})
+
+	if err != nil {
+		t.Fatal("Run:", err)
+	}
+
+	if len(result.Players) != 8 {
+		t.Fatalf("Expected 8 players but got %d", len(result.Players))
+	}
+
+	total := 0.0
+
+	for _, odds := range result.Players {
+		total += odds.Champion
+
+		// Everyone plays in their group, half make the playoffs
+		if odds.Stages["Playoffs"] < 0.45 || odds.Stages["Playoffs"] > 0.55 {
+			t.Errorf("Expected about 0.5 for %s reaching the playoffs but got %v", odds.Player, odds.Stages["Playoffs"])
+		}
+
+		if math.Abs(odds.Champion-0.125) > 0.015 {
+			t.Errorf("Expected about 0.125 for %s winning but got %v", odds.Player, odds.Champion)
+		}
+	}
+
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("Champion odds should add to 1 but got %v", total)
+	}
+}
+
+func TestRunRespectsRecordedResults(t *testing.T) {
+	tournament := newTestTournament(t)
+
+	// TY has already lost twice in the group, so can't win anything
+	tournament.RecordResult("a-opening-1", []bracket.MapResult{{Map: "Oxide", Winner: "Rogue"}, {Map: "Oxide", Winner: "Rogue"}})
+	tournament.RecordResult("a-opening-2", []bracket.MapResult{{Map: "Oxide", Winner: "Maru"}, {Map: "Oxide", Winner: "Maru"}})
+	tournament.RecordResult("a-losers", []bracket.MapResult{{Map: "Oxide", Winner: "Dark"}, {Map: "Oxide", Winner: "Dark"}})
+
+	// Maru always wins, everyone else flips coins
+	maruWins := func(p1 string, p2 string) float64 {
+		switch {
+		case p1 == "Maru":
+			return 1
+		case p2 == "Maru":
+			return 0
+		}
+
+		return 0.5
+	}
+
+	result, err := Run(context.Background(), tournament, maruWins, Options{Runs: 1000, Seed: 3})
+
+	if err != nil {
+		t.Fatal("Run:", err)
+	}
+
+	for _, odds := range result.Players {
+		switch odds.Player {
+		case "Maru":
+			if odds.Champion != 1 {
+				t.Errorf("Expected Maru to always win but got %v", odds.Champion)
+			}
+
+		case "TY":
+			if odds.Stages["Playoffs"] != 0 || odds.Stages["Group A"] != 1 {
+				t.Errorf("Expected TY to be out in the group but got %+v", odds.Stages)
+			}
+		}
+	}
+
+	if result.Players[0].Player != "Maru" {
+		t.Errorf("Expected Maru first but got %s", result.Players[0].Player)
+	}
+}
+
+func TestRunNeedsRuns(t *testing.T) {
+	_, err := Run(context.Background(), newTestTournament(t), coinFlip, Options{})
+
+	if err == nil {
+		t.Error("Expected an error for zero runs")
+	}
+}
+
+func TestRunStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Run(ctx, newTestTournament(t), coinFlip, Options{Runs: 1000000, Seed: 1})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled but got %v", err)
+	}
+}
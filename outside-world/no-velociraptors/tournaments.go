@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/bracket"
+)
+
+// ErrTournamentNotFound means we don't know about a tournament with that ID
+var ErrTournamentNotFound = errors.New("tournament not found")
+
+// Tournament IDs end up in file names, so keep them boring
+var validTournamentID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// FileTournamentStore keeps each tournament in its own JSON file
+type FileTournamentStore struct {
+	dir string
+
+	// Only one writer at a time, so two results recorded at once can't
+	// clobber each other
+	writeMu sync.Mutex
+}
+
+// NewFileTournamentStore returns a FileTournamentStore that reads and
+// writes <id>.json files in the given directory
+func NewFileTournamentStore(dir string) *FileTournamentStore {
+	return &FileTournamentStore{
+		dir: dir,
+	}
+}
+
+// GetTournament loads a tournament by its ID
+func (s *FileTournamentStore) GetTournament(ctx context.Context, id string) (*bracket.Tournament, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if !validTournamentID.MatchString(id) {
+		return nil, ErrTournamentNotFound
+	}
+
+	return s.load(filepath.Join(s.dir, id+".json"))
+}
+
+// ListTournaments loads every tournament we know about, oldest first
+func (s *FileTournamentStore) ListTournaments(ctx context.Context) ([]*bracket.Tournament, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	filenames, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+
+	if err != nil {
+		return nil, fmt.Errorf("filepath.Glob: %w", err)
+	}
+
+	tournaments := make([]*bracket.Tournament, 0, len(filenames))
+
+	for _, filename := range filenames {
+		tournament, err := s.load(filename)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tournaments = append(tournaments, tournament)
+	}
+
+	sort.Slice(tournaments, func(i, j int) bool {
+		return tournaments[i].Date.Before(tournaments[j].Date)
+	})
+
+	return tournaments, nil
+}
+
+// GetTournamentsVersion changes whenever a tournament file is added,
+// removed or written, without loading any of them
+//
+// It's made from each file's name, size and modification time, which is
+// enough to notice results recorded through the API as well as files
+// edited by hand.
+func (s *FileTournamentStore) GetTournamentsVersion(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	filenames, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+
+	if err != nil {
+		return "", fmt.Errorf("filepath.Glob: %w", err)
+	}
+
+	hash := sha256.New()
+
+	for _, filename := range filenames {
+		info, err := os.Stat(filename)
+
+		// Deleted since we listed them, which the next version will show
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("failed to stat tournament file: %w", err)
+		}
+
+		fmt.Fprintf(hash, "%s %d %d\n", filepath.Base(filename), info.Size(), info.ModTime().UnixNano())
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// RecordMatchResult saves the maps played in one match of a tournament
+func (s *FileTournamentStore) RecordMatchResult(ctx context.Context, tournamentID string, matchID string, maps []bracket.MapResult) (*bracket.Tournament, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	tournament, err := s.GetTournament(ctx, tournamentID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = tournament.RecordResult(matchID, maps)
+
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := json.MarshalIndent(tournament, "", "  ")
+
+	if err != nil {
+		return nil, fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+
+	err = writeFileAtomically(filepath.Join(s.dir, tournamentID+".json"), contents, 0644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tournament, nil
+}
+
+func (s *FileTournamentStore) load(filename string) (*bracket.Tournament, error) {
+	contents, err := os.ReadFile(filename)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrTournamentNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tournament file: %w", err)
+	}
+
+	var tournament bracket.Tournament
+
+	err = json.Unmarshal(contents, &tournament)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(filename), err)
+	}
+
+	err = tournament.Validate()
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid tournament in %s: %w", filepath.Base(filename), err)
+	}
+
+	return &tournament, nil
+}
+
+// TournamentLister can list every tournament, oldest first
+type TournamentLister interface {
+	ListTournaments(ctx context.Context) ([]*bracket.Tournament, error)
+}
+
+// BracketChampionGetter works out the champion from the most recent
+// finished tournament
+//
+// Yet another CurrentChampionGetter.  Nobody has to remember to update
+// champion.txt when the bracket already knows who won.
+type BracketChampionGetter struct {
+	tournamentLister TournamentLister
+}
+
+// NewBracketChampionGetter returns a BracketChampionGetter that looks at
+// the tournaments from the given lister
+func NewBracketChampionGetter(tournamentLister TournamentLister) *BracketChampionGetter {
+	return &BracketChampionGetter{
+		tournamentLister: tournamentLister,
+	}
+}
+
+// GetCurrentChampion returns the winner of the latest completed tournament
+func (g *BracketChampionGetter) GetCurrentChampion(ctx context.Context) (string, error) {
+	tournaments, err := g.tournamentLister.ListTournaments(ctx)
+
+	if err != nil {
+		return "", fmt.Errorf("tournamentLister.ListTournaments: %w", err)
+	}
+
+	for i := len(tournaments) - 1; i >= 0; i-- {
+		champion, ok := tournaments[i].Champion()
+
+		if !ok {
+			continue
+		}
+
+		// Brackets are typed in by hand too, so clean up after them the
+		// same way we do for champion.txt
+		champion = strings.TrimSpace(champion)
+
+		if champion == "" {
+			return "", fmt.Errorf("champion of %s is blank", tournaments[i].ID)
+		}
+
+		return champion, nil
+	}
+
+	return "", errors.New("no tournament has finished yet")
+}
+
+// TournamentGetter can load a single tournament
+type TournamentGetter interface {
+	GetTournament(ctx context.Context, id string) (*bracket.Tournament, error)
+}
+
+// MatchResultRecorder can save the result of a match
+type MatchResultRecorder interface {
+	RecordMatchResult(ctx context.Context, tournamentID string, matchID string, maps []bracket.MapResult) (*bracket.Tournament, error)
+}
+
+// Creates a handler that shows a tournament's bracket as JSON
+func gslTournamentBracketHandler(tournamentGetter TournamentGetter, logger *slog.Logger, tournamentID string) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		tournament, err := tournamentGetter.GetTournament(req.Context(), tournamentID)
+
+		if errors.Is(err, ErrTournamentNotFound) {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "Failed to get tournament", "error", err)
+			writeDataStoreError(res, err)
+			return
+		}
+
+		writeJSON(res, req, logger, http.StatusOK, tournament.View())
+	}
+}
+
+// Creates a handler that records the maps played in a match.  The body is
+// a JSON list of maps, like [{"map": "Pillars of Gold", "winner": "TY"}].
+func gslRecordMatchResultHandler(matchResultRecorder MatchResultRecorder, logger *slog.Logger, tournamentID string, matchID string) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		var maps []bracket.MapResult
+
+		err := json.NewDecoder(http.MaxBytesReader(res, req.Body, 64*1024)).Decode(&maps)
+
+		if err != nil {
+			res.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		tournament, err := matchResultRecorder.RecordMatchResult(req.Context(), tournamentID, matchID, maps)
+
+		switch {
+		case errors.Is(err, ErrTournamentNotFound), errors.Is(err, bracket.ErrMatchNotFound):
+			res.WriteHeader(http.StatusNotFound)
+
+		case errors.Is(err, bracket.ErrInvalidResult):
+			res.WriteHeader(http.StatusBadRequest)
+			res.Write([]byte(err.Error()))
+
+		case errors.Is(err, bracket.ErrPlayersUndecided), errors.Is(err, bracket.ErrResultLocked):
+			res.WriteHeader(http.StatusConflict)
+			res.Write([]byte(err.Error()))
+
+		case err != nil:
+			logger.ErrorContext(req.Context(), "Failed to record match result", "error", err)
+			writeDataStoreError(res, err)
+
+		default:
+			writeJSON(res, req, logger, http.StatusOK, tournament.View())
+		}
+	}
+}
+
+// tournamentRoutes handles everything under /tournaments/
+//
+//	GET /tournaments/{id}/bracket
+//	GET /tournaments/{id}/odds
+//	PUT /tournaments/{id}/matches/{matchID}  (admins only)
+func tournamentRoutes(config serverConfig, deps serverDependencies) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, "/tournaments/"), "/"), "/")
+
+		switch {
+		case len(parts) == 2 && parts[1] == "bracket":
+			methodHandlers{
+				http.MethodGet: gslTournamentBracketHandler(deps.tournamentGetter, deps.logger, parts[0]),
+			}.ServeHTTP(res, req)
+
+		case len(parts) == 2 && parts[1] == "odds":
+			methodHandlers{
+				http.MethodGet: gslTournamentOddsHandler(deps.tournamentGetter, deps.ratingsGetter, deps.logger, parts[0]),
+			}.ServeHTTP(res, req)
+
+		case len(parts) == 3 && parts[1] == "matches":
+			methodHandlers{
+				http.MethodPut: requireAdmin(config.adminTokens, gslRecordMatchResultHandler(deps.matchResultRecorder, deps.logger, parts[0], parts[2])),
+			}.ServeHTTP(res, req)
+
+		default:
+			res.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
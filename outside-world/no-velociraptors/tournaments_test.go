@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/outside-world/no-velociraptors/bracket"
+)
+
+// A tiny four player bracket, enough to crown somebody
+func newTestBracket(id string, date time.Time) *bracket.Tournament {
+	stage, champion, _ := bracket.SingleEliminationBracket("Playoffs", "p", []bracket.Source{
+		bracket.Seed("TY"),
+		bracket.Seed("Rogue"),
+		bracket.Seed("Maru"),
+		bracket.Seed("Dark"),
+	}, 1)
+
+	return &bracket.Tournament{
+		ID:     id,
+		Name:   "Season " + id,
+		Date:   date,
+		Stages: []bracket.Stage{stage},
+		Final:  champion.WinnerOf,
+	}
+}
+
+func writeTestBracket(t *testing.T, dir string, tournament *bracket.Tournament) {
+	t.Helper()
+
+	contents, err := json.Marshal(tournament)
+
+	if err != nil {
+		t.Fatal("json.Marshal:", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, tournament.ID+".json"), contents, 0644)
+
+	if err != nil {
+		t.Fatal("os.WriteFile:", err)
+	}
+}
+
+func TestFileTournamentStoreRecordsResults(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBracket(t, dir, newTestBracket("2020-s2", time.Now()))
+
+	store := NewFileTournamentStore(dir)
+	ctx := context.Background()
+
+	_, err := store.RecordMatchResult(ctx, "2020-s2", "p-r1-m1", []bracket.MapResult{{Map: "Oxide", Winner: "TY"}})
+
+	if err != nil {
+		t.Fatal("store.RecordMatchResult:", err)
+	}
+
+	// Load it fresh to make sure the result actually hit the disk
+	tournament, err := NewFileTournamentStore(dir).GetTournament(ctx, "2020-s2")
+
+	if err != nil {
+		t.Fatal("store.GetTournament:", err)
+	}
+
+	if winner, _ := tournament.Winner("p-r1-m1"); winner != "TY" {
+		t.Errorf("Expected TY to have won p-r1-m1 but got %q", winner)
+	}
+}
+
+func TestFileTournamentStoreVersionChangesWhenResultsAreRecorded(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBracket(t, dir, newTestBracket("2020-s2", time.Now()))
+
+	store := NewFileTournamentStore(dir)
+	ctx := context.Background()
+
+	before, err := store.GetTournamentsVersion(ctx)
+
+	if err != nil {
+		t.Fatal("store.GetTournamentsVersion:", err)
+	}
+
+	if again, _ := store.GetTournamentsVersion(ctx); again != before {
+		t.Errorf("Expected the version to stay %q while nothing changed but got %q", before, again)
+	}
+
+	_, err = store.RecordMatchResult(ctx, "2020-s2", "p-r1-m1", []bracket.MapResult{{Map: "Oxide", Winner: "TY"}})
+
+	if err != nil {
+		t.Fatal("store.RecordMatchResult:", err)
+	}
+
+	after, err := store.GetTournamentsVersion(ctx)
+
+	if err != nil {
+		t.Fatal("store.GetTournamentsVersion:", err)
+	}
+
+	if after == before {
+		t.Errorf("Expected the version to change after recording a result but it stayed %q", before)
+	}
+}
+
+func TestFileTournamentStoreRejectsStrangeIDs(t *testing.T) {
+	store := NewFileTournamentStore(t.TempDir())
+
+	for _, id := range []string{"../champion", "", "a/b", "missing"} {
+		_, err := store.GetTournament(context.Background(), id)
+
+		if !errors.Is(err, ErrTournamentNotFound) {
+			t.Errorf("Expected ErrTournamentNotFound for %q but got %v", id, err)
+		}
+	}
+}
+
+type mockTournamentLister struct {
+	tournaments  []*bracket.Tournament
+	version      string
+	pendingError error
+
+	listCalls int
+}
+
+func (l *mockTournamentLister) ListTournaments(ctx context.Context) ([]*bracket.Tournament, error) {
+	l.listCalls++
+
+	return l.tournaments, l.pendingError
+}
+
+func (l *mockTournamentLister) GetTournamentsVersion(ctx context.Context) (string, error) {
+	return l.version, l.pendingError
+}
+
+func TestBracketChampionGetterUsesLatestFinishedTournament(t *testing.T) {
+	finished := newTestBracket("old", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	finished.RecordResult("p-r1-m1", []bracket.MapResult{{Map: "Oxide", Winner: "Rogue"}})
+	finished.RecordResult("p-r1-m2", []bracket.MapResult{{Map: "Oxide", Winner: "Maru"}})
+	finished.RecordResult("p-r2-m1", []bracket.MapResult{{Map: "Oxide", Winner: "Rogue"}})
+
+	// Newer, but nobody has won it yet
+	inProgress := newTestBracket("new", time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	getter := NewBracketChampionGetter(&mockTournamentLister{
+		tournaments: []*bracket.Tournament{finished, inProgress},
+	})
+
+	champion, err := getter.GetCurrentChampion(context.Background())
+
+	if err != nil {
+		t.Fatal("getter.GetCurrentChampion:", err)
+	}
+
+	if champion != "Rogue" {
+		t.Errorf("Expected Rogue but got %q", champion)
+	}
+}
+
+func TestBracketChampionGetterErrorsWithoutFinishedTournament(t *testing.T) {
+	getter := NewBracketChampionGetter(&mockTournamentLister{
+		tournaments: []*bracket.Tournament{newTestBracket("new", time.Now())},
+	})
+
+	_, err := getter.GetCurrentChampion(context.Background())
+
+	if err == nil {
+		t.Error("Expected an error but got nil")
+	}
+}
+
+func newTestTournamentServer(t *testing.T) http.Handler {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeTestBracket(t, dir, newTestBracket("2020-s2", time.Now()))
+
+	store := NewFileTournamentStore(dir)
+
+	config := serverConfig{
+		requestTimeout: defaultRequestTimeout,
+		adminTokens: map[string]string{
+			"secret-token": "evertras",
+		},
+	}
+
+	return newServerHandler(config, serverDependencies{
+		logger:              testLogger,
+		tournamentGetter:    store,
+		matchResultRecorder: store,
+	})
+}
+
+func TestTournamentBracketEndpoint(t *testing.T) {
+	server := newTestTournamentServer(t)
+
+	res := httptest.NewRecorder()
+	server.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/tournaments/2020-s2/bracket", nil))
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("Expected status %d but got %d", http.StatusOK, res.Code)
+	}
+
+	var view bracket.TournamentView
+
+	err := json.NewDecoder(res.Body).Decode(&view)
+
+	if err != nil {
+		t.Fatal("json.Decode:", err)
+	}
+
+	if len(view.Stages) != 1 || len(view.Stages[0].Matches) != 3 {
+		t.Fatalf("Unexpected bracket %+v", view)
+	}
+
+	first := view.Stages[0].Matches[0]
+
+	if first.Player1 != "TY" || first.Player2 != "Rogue" || first.Status != bracket.StatusReady {
+		t.Errorf("Unexpected first match %+v", first)
+	}
+
+	res = httptest.NewRecorder()
+	server.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/tournaments/nope/bracket", nil))
+
+	if res.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d but got %d", http.StatusNotFound, res.Code)
+	}
+}
+
+func TestRecordMatchResultEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		path     string
+		body     string
+		expected int
+	}{
+		{"NoToken", "", "/tournaments/2020-s2/matches/p-r1-m1", `[{"map":"Oxide","winner":"TY"}]`, http.StatusUnauthorized},
+		{"Recorded", "secret-token", "/tournaments/2020-s2/matches/p-r1-m1", `[{"map":"Oxide","winner":"TY"}]`, http.StatusOK},
+		{"NotJSON", "secret-token", "/tournaments/2020-s2/matches/p-r1-m1", `TY won`, http.StatusBadRequest},
+		{"WrongPlayer", "secret-token", "/tournaments/2020-s2/matches/p-r1-m1", `[{"map":"Oxide","winner":"Maru"}]`, http.StatusBadRequest},
+		{"Undecided", "secret-token", "/tournaments/2020-s2/matches/p-r2-m1", `[{"map":"Oxide","winner":"TY"}]`, http.StatusConflict},
+		{"UnknownMatch", "secret-token", "/tournaments/2020-s2/matches/nope", `[]`, http.StatusNotFound},
+		{"UnknownTournament", "secret-token", "/tournaments/nope/matches/p-r1-m1", `[]`, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newTestTournamentServer(t)
+
+			req := httptest.NewRequest(http.MethodPut, test.path, strings.NewReader(test.body))
+
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, req)
+
+			if res.Code != test.expected {
+				t.Errorf("Expected status %d but got %d: %s", test.expected, res.Code, res.Body.String())
+			}
+		})
+	}
+}
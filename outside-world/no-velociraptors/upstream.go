@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxUpstreamChampionBytes is way more than any sane player handle needs,
+// but stops a misbehaving upstream from making us read forever
+const maxUpstreamChampionBytes = 1024
+
+// UpstreamChampionGetter asks another GSL server who the champion is
+//
+// This is the outside world at its most outside.  It can be slow, it can
+// be down, it can lie.  The context is what lets us stop waiting on it.
+type UpstreamChampionGetter struct {
+	championURL string
+	client      *http.Client
+}
+
+// NewUpstreamChampionGetter returns an UpstreamChampionGetter that asks the
+// given URL, which should behave like our own /champion endpoint
+func NewUpstreamChampionGetter(championURL string, client *http.Client) *UpstreamChampionGetter {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &UpstreamChampionGetter{
+		championURL: championURL,
+		client:      client,
+	}
+}
+
+// GetCurrentChampion returns whatever the upstream server thinks the
+// current champion is
+func (g *UpstreamChampionGetter) GetCurrentChampion(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.championURL, nil)
+
+	if err != nil {
+		return "", fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+
+	res, err := g.client.Do(req)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to reach upstream: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("upstream returned status %d", res.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxUpstreamChampionBytes))
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read upstream response: %w", err)
+	}
+
+	champion := strings.TrimSpace(string(body))
+
+	if champion == "" {
+		return "", fmt.Errorf("upstream returned an empty champion")
+	}
+
+	return champion, nil
+}
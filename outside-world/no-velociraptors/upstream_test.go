@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUpstreamChampionGetterReturnsUpstreamChampion(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte("Dark"))
+	}))
+	defer upstream.Close()
+
+	getter := NewUpstreamChampionGetter(upstream.URL, upstream.Client())
+
+	champion, err := getter.GetCurrentChampion(context.Background())
+
+	if err != nil {
+		t.Fatal("getter.GetCurrentChampion:", err)
+	}
+
+	if champion != "Dark" {
+		t.Errorf("Expected champion %q but got %q", "Dark", champion)
+	}
+}
+
+func TestUpstreamChampionGetterErrorsOnBadStatus(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(500)
+	}))
+	defer upstream.Close()
+
+	getter := NewUpstreamChampionGetter(upstream.URL, upstream.Client())
+
+	_, err := getter.GetCurrentChampion(context.Background())
+
+	if err == nil {
+		t.Error("Expected an error but didn't get one")
+	}
+}
+
+func TestUpstreamChampionGetterStopsWaitingWhenContextIsDone(t *testing.T) {
+	// This upstream never answers until we've given up on it
+	upstream := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	}))
+	defer upstream.Close()
+
+	getter := NewUpstreamChampionGetter(upstream.URL, upstream.Client())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := getter.GetCurrentChampion(ctx)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded but got %v", err)
+	}
+}
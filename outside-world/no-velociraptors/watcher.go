@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ChampionChangeNotifier wants to hear about new champions
+type ChampionChangeNotifier interface {
+	NotifyChampionChanged(ctx context.Context, change ChampionChange)
+}
+
+// watchChampion asks the getter who the champion is every interval, and
+// tells the notifier whenever the answer changes
+//
+// We poll rather than hooking into SetCurrentChampion so we also notice
+// when someone edits champion.txt by hand.  The first answer we get is just
+// where we start from, it isn't a change.  Runs until ctx is done.
+func watchChampion(ctx context.Context, currentChampionGetter CurrentChampionGetter, interval time.Duration, notifier ChampionChangeNotifier, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	known := ""
+
+	for {
+		champion, err := currentChampionGetter.GetCurrentChampion(ctx)
+
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				logger.ErrorContext(ctx, "Champion watcher failed to get current champion", "error", err)
+			}
+
+		case known == "":
+			known = champion
+
+		case champion != known:
+			notifier.NotifyChampionChanged(ctx, ChampionChange{
+				Previous: known,
+				Champion: champion,
+				Time:     time.Now().UTC(),
+			})
+
+			known = champion
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
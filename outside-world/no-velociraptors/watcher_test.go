@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Hands out champions from a list, sticking on the last one
+type sequenceChampionGetter struct {
+	mu        sync.Mutex
+	champions []string
+}
+
+func (g *sequenceChampionGetter) GetCurrentChampion(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	champion := g.champions[0]
+
+	if len(g.champions) > 1 {
+		g.champions = g.champions[1:]
+	}
+
+	return champion, nil
+}
+
+type mockChampionChangeNotifier struct {
+	mu      sync.Mutex
+	changes []ChampionChange
+}
+
+func (n *mockChampionChangeNotifier) NotifyChampionChanged(ctx context.Context, change ChampionChange) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.changes = append(n.changes, change)
+}
+
+func TestWatchChampionNotifiesOnlyOnChange(t *testing.T) {
+	getter := &sequenceChampionGetter{
+		champions: []string{"TY", "TY", "Rogue", "Rogue", "Maru"},
+	}
+	notifier := &mockChampionChangeNotifier{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		watchChampion(ctx, getter, time.Millisecond, notifier, testLogger)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		notifier.mu.Lock()
+		count := len(notifier.changes)
+		notifier.mu.Unlock()
+
+		if count >= 2 || time.Now().After(deadline) {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+
+	if len(notifier.changes) != 2 {
+		t.Fatalf("Expected %d changes but got %d", 2, len(notifier.changes))
+	}
+
+	first, second := notifier.changes[0], notifier.changes[1]
+
+	if first.Previous != "TY" || first.Champion != "Rogue" {
+		t.Errorf("Unexpected first change %+v", first)
+	}
+
+	if second.Previous != "Rogue" || second.Champion != "Maru" {
+		t.Errorf("Unexpected second change %+v", second)
+	}
+}
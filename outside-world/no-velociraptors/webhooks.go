@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSubscriptionNotFound means there's no webhook subscription with that ID
+var ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+
+// WebhookSubscription is somebody who wants to hear about new champions
+type WebhookSubscription struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WebhookRegistry keeps track of webhook subscriptions
+//
+// Subscriptions live in memory, and if a file is given they're also saved
+// there so they survive a restart.
+type WebhookRegistry struct {
+	filename string
+
+	mu            sync.RWMutex
+	subscriptions map[string]WebhookSubscription
+}
+
+// NewWebhookRegistry returns a WebhookRegistry that persists to the given
+// file, loading anything that's already in it.  An empty filename keeps
+// everything in memory only.
+func NewWebhookRegistry(filename string) (*WebhookRegistry, error) {
+	registry := &WebhookRegistry{
+		filename:      filename,
+		subscriptions: make(map[string]WebhookSubscription),
+	}
+
+	if filename == "" {
+		return registry, nil
+	}
+
+	contents, err := os.ReadFile(filename)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return registry, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook file: %w", err)
+	}
+
+	var subscriptions []WebhookSubscription
+
+	err = json.Unmarshal(contents, &subscriptions)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webhook file: %w", err)
+	}
+
+	for _, subscription := range subscriptions {
+		registry.subscriptions[subscription.ID] = subscription
+	}
+
+	return registry, nil
+}
+
+// AddSubscription registers a new callback URL
+func (r *WebhookRegistry) AddSubscription(ctx context.Context, callbackURL string, secret string) (WebhookSubscription, error) {
+	id, err := newWebhookID()
+
+	if err != nil {
+		return WebhookSubscription{}, err
+	}
+
+	subscription := WebhookSubscription{
+		ID:        id,
+		URL:       callbackURL,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscriptions[id] = subscription
+
+	err = r.save()
+
+	if err != nil {
+		delete(r.subscriptions, id)
+		return WebhookSubscription{}, err
+	}
+
+	return subscription, nil
+}
+
+// RemoveSubscription stops sending webhooks to a subscription
+func (r *WebhookRegistry) RemoveSubscription(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subscription, ok := r.subscriptions[id]
+
+	if !ok {
+		return ErrSubscriptionNotFound
+	}
+
+	delete(r.subscriptions, id)
+
+	err := r.save()
+
+	if err != nil {
+		r.subscriptions[id] = subscription
+		return err
+	}
+
+	return nil
+}
+
+// ListSubscriptions returns every subscription, oldest first
+func (r *WebhookRegistry) ListSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sorted(), nil
+}
+
+func (r *WebhookRegistry) sorted() []WebhookSubscription {
+	subscriptions := make([]WebhookSubscription, 0, len(r.subscriptions))
+
+	for _, subscription := range r.subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		if subscriptions[i].CreatedAt.Equal(subscriptions[j].CreatedAt) {
+			return subscriptions[i].ID < subscriptions[j].ID
+		}
+
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+
+	return subscriptions
+}
+
+// save must be called with the lock held
+func (r *WebhookRegistry) save() error {
+	if r.filename == "" {
+		return nil
+	}
+
+	contents, err := json.MarshalIndent(r.sorted(), "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+
+	// Every subscriber's signing secret is in here, so only we get to read it
+	return writeFileAtomically(r.filename, contents, 0600)
+}
+
+func newWebhookID() (string, error) {
+	raw := make([]byte, 8)
+
+	_, err := rand.Read(raw)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to generate webhook ID: %w", err)
+	}
+
+	return hex.EncodeToString(raw), nil
+}
+
+// WebhookSubscriber can manage webhook subscriptions
+type WebhookSubscriber interface {
+	AddSubscription(ctx context.Context, callbackURL string, secret string) (WebhookSubscription, error)
+	RemoveSubscription(ctx context.Context, id string) error
+	ListSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+}
+
+// minWebhookSecretLength keeps people from signing with "password"
+const minWebhookSecretLength = 16
+
+type webhookRequest struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+}
+
+// Creates a handler that registers a webhook
+//
+// The body is JSON with the callback URL and the secret we should sign
+// deliveries with.  We never send the secret back.
+func gslAddWebhookHandler(webhookSubscriber WebhookSubscriber, logger *slog.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		var body webhookRequest
+
+		err := json.NewDecoder(io.LimitReader(req.Body, 4096)).Decode(&body)
+
+		if err != nil {
+			res.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		parsed, err := url.Parse(body.URL)
+
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			res.WriteHeader(http.StatusBadRequest)
+			res.Write([]byte("url must be an absolute http or https URL"))
+			return
+		}
+
+		if len(body.Secret) < minWebhookSecretLength {
+			res.WriteHeader(http.StatusBadRequest)
+			res.Write([]byte(fmt.Sprintf("secret must be at least %d characters", minWebhookSecretLength)))
+			return
+		}
+
+		subscription, err := webhookSubscriber.AddSubscription(req.Context(), body.URL, body.Secret)
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "Failed to add webhook subscription", "error", err)
+			writeDataStoreError(res, err)
+			return
+		}
+
+		subscription.Secret = ""
+
+		res.Header().Set("Location", "/webhooks/"+subscription.ID)
+		writeJSON(res, req, logger, http.StatusCreated, subscription)
+	}
+}
+
+// Creates a handler that lists webhooks, without their secrets
+func gslListWebhooksHandler(webhookSubscriber WebhookSubscriber, logger *slog.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		subscriptions, err := webhookSubscriber.ListSubscriptions(req.Context())
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "Failed to list webhook subscriptions", "error", err)
+			writeDataStoreError(res, err)
+			return
+		}
+
+		for i := range subscriptions {
+			subscriptions[i].Secret = ""
+		}
+
+		writeJSON(res, req, logger, http.StatusOK, subscriptions)
+	}
+}
+
+// Creates a handler that removes the webhook named in the path,
+// like /webhooks/abc123
+func gslRemoveWebhookHandler(webhookSubscriber WebhookSubscriber, logger *slog.Logger) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		id := strings.TrimPrefix(req.URL.Path, "/webhooks/")
+
+		if id == "" || strings.Contains(id, "/") {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		err := webhookSubscriber.RemoveSubscription(req.Context(), id)
+
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if err != nil {
+			logger.ErrorContext(req.Context(), "Failed to remove webhook subscription", "error", err)
+			writeDataStoreError(res, err)
+			return
+		}
+
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
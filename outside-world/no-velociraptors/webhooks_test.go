@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWebhookRegistrySurvivesRestart(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "webhooks.json")
+	ctx := context.Background()
+
+	registry, err := NewWebhookRegistry(filename)
+
+	if err != nil {
+		t.Fatal("NewWebhookRegistry:", err)
+	}
+
+	kept, err := registry.AddSubscription(ctx, "https://example.com/kept", "a-very-secret-secret")
+
+	if err != nil {
+		t.Fatal("registry.AddSubscription:", err)
+	}
+
+	removed, err := registry.AddSubscription(ctx, "https://example.com/removed", "a-very-secret-secret")
+
+	if err != nil {
+		t.Fatal("registry.AddSubscription:", err)
+	}
+
+	err = registry.RemoveSubscription(ctx, removed.ID)
+
+	if err != nil {
+		t.Fatal("registry.RemoveSubscription:", err)
+	}
+
+	reloaded, err := NewWebhookRegistry(filename)
+
+	if err != nil {
+		t.Fatal("NewWebhookRegistry:", err)
+	}
+
+	subscriptions, err := reloaded.ListSubscriptions(ctx)
+
+	if err != nil {
+		t.Fatal("reloaded.ListSubscriptions:", err)
+	}
+
+	if len(subscriptions) != 1 || subscriptions[0].ID != kept.ID {
+		t.Fatalf("Expected only %s to survive but got %+v", kept.ID, subscriptions)
+	}
+
+	if subscriptions[0].Secret != "a-very-secret-secret" {
+		t.Error("Expected the secret to survive so we can still sign deliveries")
+	}
+
+	info, err := os.Stat(filename)
+
+	if err != nil {
+		t.Fatal("os.Stat:", err)
+	}
+
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Expected the file full of secrets to be 0600 but it's %o", perm)
+	}
+}
+
+func TestWebhookRegistryRemoveUnknownSubscription(t *testing.T) {
+	registry, err := NewWebhookRegistry("")
+
+	if err != nil {
+		t.Fatal("NewWebhookRegistry:", err)
+	}
+
+	err = registry.RemoveSubscription(context.Background(), "nope")
+
+	if err != ErrSubscriptionNotFound {
+		t.Errorf("Expected ErrSubscriptionNotFound but got %v", err)
+	}
+}
+
+func TestAddWebhookHandlerValidatesRequest(t *testing.T) {
+	cases := map[string]int{
+		`{"url": "https://example.com/hook", "secret": "a-very-secret-secret"}`: 201,
+		`{"url": "ftp://example.com/hook", "secret": "a-very-secret-secret"}`:   400,
+		`{"url": "/hook", "secret": "a-very-secret-secret"}`:                    400,
+		`{"url": "https://example.com/hook", "secret": "short"}`:                400,
+		`not json`: 400,
+	}
+
+	for body, expectedCode := range cases {
+		registry, _ := NewWebhookRegistry("")
+
+		req := httptest.NewRequest("POST", "/webhooks", bytes.NewBufferString(body))
+		res := httptest.NewRecorder()
+
+		gslAddWebhookHandler(registry, testLogger)(res, req)
+
+		if res.Code != expectedCode {
+			t.Errorf("Expected code %d for %s but got %d", expectedCode, body, res.Code)
+		}
+	}
+}
+
+func TestListWebhooksHandlerHidesSecrets(t *testing.T) {
+	registry, _ := NewWebhookRegistry("")
+
+	_, err := registry.AddSubscription(context.Background(), "https://example.com/hook", "a-very-secret-secret")
+
+	if err != nil {
+		t.Fatal("registry.AddSubscription:", err)
+	}
+
+	req := httptest.NewRequest("GET", "/webhooks", nil)
+	res := httptest.NewRecorder()
+
+	gslListWebhooksHandler(registry, testLogger)(res, req)
+
+	var subscriptions []WebhookSubscription
+
+	err = json.Unmarshal(res.Body.Bytes(), &subscriptions)
+
+	if err != nil {
+		t.Fatal("json.Unmarshal:", err)
+	}
+
+	if len(subscriptions) != 1 {
+		t.Fatalf("Expected %d subscription but got %d", 1, len(subscriptions))
+	}
+
+	if subscriptions[0].Secret != "" {
+		t.Error("Expected the secret to be hidden")
+	}
+}
+
+func TestWebhookRoutesRequireAdmin(t *testing.T) {
+	registry, _ := NewWebhookRegistry("")
+
+	req := httptest.NewRequest("GET", "/webhooks", nil)
+	res := httptest.NewRecorder()
+
+	newServerHandler(serverConfig{requestTimeout: defaultRequestTimeout}, serverDependencies{webhookSubscriber: registry, logger: testLogger}).ServeHTTP(res, req)
+
+	if res.Code != 401 {
+		t.Errorf("Expected code 401 but got %d", res.Code)
+	}
+}
+
+func TestRemoveWebhookHandler(t *testing.T) {
+	registry, _ := NewWebhookRegistry("")
+
+	subscription, err := registry.AddSubscription(context.Background(), "https://example.com/hook", "a-very-secret-secret")
+
+	if err != nil {
+		t.Fatal("registry.AddSubscription:", err)
+	}
+
+	for _, expectedCode := range []int{204, 404} {
+		req := httptest.NewRequest("DELETE", "/webhooks/"+subscription.ID, nil)
+		res := httptest.NewRecorder()
+
+		gslRemoveWebhookHandler(registry, testLogger)(res, req)
+
+		if res.Code != expectedCode {
+			t.Errorf("Expected code %d but got %d", expectedCode, res.Code)
+		}
+	}
+}
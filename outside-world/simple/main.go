@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"log"
+
+	"github.com/Evertras/go-interface-examples/graceful"
 )
 
 func main() {
+	ctx, stop := graceful.SignalContext(context.Background())
+	defer stop()
+
 	log.Println("Running GSL server on :8080")
 
-	err := runServer(":8080")
+	err := runServer(ctx, ":8080")
 
 	if err != nil {
 		log.Fatal(err)
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
+	"net"
 	"net/http"
+
+	"github.com/Evertras/go-interface-examples/graceful"
 )
 
 func gslCurrentChampionHandler(res http.ResponseWriter, req *http.Request) {
@@ -9,12 +13,38 @@ func gslCurrentChampionHandler(res http.ResponseWriter, req *http.Request) {
 	res.Write([]byte("TY"))
 }
 
-// Runs a server that lets us see GSL info
-func runServer(address string) error {
+// The process is up and able to answer
+func healthzHandler(res http.ResponseWriter, req *http.Request) {
+	res.Write([]byte("ok"))
+}
+
+// Runs a server that lets us see GSL info, until ctx is done
+func runServer(ctx context.Context, address string) error {
+	listener, err := net.Listen("tcp", address)
+
+	if err != nil {
+		return err
+	}
+
+	return serve(ctx, listener)
+}
+
+// serve is runServer without picking the address
+func serve(ctx context.Context, listener net.Listener) error {
+	return graceful.Serve(ctx, &http.Server{Handler: newServerHandler()}, listener, graceful.DefaultTimeout)
+}
+
+// newServerHandler decides which URL gets which handler
+func newServerHandler() http.Handler {
 	mux := http.NewServeMux()
 
-	// This is our only handler for now, for demonstration's sake.
+	// This is our only real handler for now, for demonstration's sake.
 	mux.HandleFunc("/champion", gslCurrentChampionHandler)
 
-	return http.ListenAndServe(address, mux)
+	// The champion is hardcoded, so there's nothing that could make us not
+	// ready.  Being alive is enough.
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.HandleFunc("/readyz", healthzHandler)
+
+	return mux
 }
@@ -19,3 +19,19 @@ func TestGSLCurrentChampionIsTY(t *testing.T) {
 		t.Errorf("Expected world champion to be %q but got %q", expectedWorldChampion, gotWorldChampion)
 	}
 }
+
+// Starting and stopping is graceful's job, so all we check is that every
+// route is there.  Nothing can make us not ready, so readyz is always fine.
+func TestServerAnswersEveryRoute(t *testing.T) {
+	handler := newServerHandler()
+
+	for _, path := range []string{"/healthz", "/readyz", "/champion"} {
+		res := httptest.NewRecorder()
+
+		handler.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+
+		if res.Code != 200 {
+			t.Errorf("Expected GET %s to be 200 but got %d", path, res.Code)
+		}
+	}
+}
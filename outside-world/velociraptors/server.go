@@ -1,9 +1,13 @@
 package main
 
 import (
-	"os"
+	"context"
 	"log"
+	"net"
 	"net/http"
+	"os"
+
+	"github.com/Evertras/go-interface-examples/graceful"
 )
 
 func gslCurrentChampionHandler(res http.ResponseWriter, req *http.Request) {
@@ -20,10 +24,47 @@ func gslCurrentChampionHandler(res http.ResponseWriter, req *http.Request) {
 	res.Write(contents)
 }
 
-func runServer(address string) error {
+// The process is up and able to answer
+func healthzHandler(res http.ResponseWriter, req *http.Request) {
+	res.Write([]byte("ok"))
+}
+
+// We're ready when we can read the file.  Yes, we magically know about the
+// file here too.  Now there are two places to change when it moves.
+func readyzHandler(res http.ResponseWriter, req *http.Request) {
+	_, err := os.ReadFile("./champion.txt")
+
+	if err != nil {
+		log.Println("Not ready, failed to read file:", err)
+		res.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	res.Write([]byte("ok"))
+}
+
+func runServer(ctx context.Context, address string) error {
+	listener, err := net.Listen("tcp", address)
+
+	if err != nil {
+		return err
+	}
+
+	return serve(ctx, listener)
+}
+
+// serve is runServer without picking the address
+func serve(ctx context.Context, listener net.Listener) error {
+	return graceful.Serve(ctx, &http.Server{Handler: newServerHandler()}, listener, graceful.DefaultTimeout)
+}
+
+// newServerHandler decides which URL gets which handler
+func newServerHandler() http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/champion", gslCurrentChampionHandler)
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.HandleFunc("/readyz", readyzHandler)
 
-	return http.ListenAndServe(address, mux)
+	return mux
 }
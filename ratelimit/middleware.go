@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers we send with every limited response.  These are the ones from
+// the IETF RateLimit header fields draft, which most clients understand.
+const (
+	HeaderLimit     = "RateLimit-Limit"
+	HeaderRemaining = "RateLimit-Remaining"
+	HeaderReset     = "RateLimit-Reset"
+	HeaderPolicy    = "RateLimit-Policy"
+)
+
+// KeyFunc decides whose bucket a request comes out of
+type KeyFunc func(req *http.Request) string
+
+// ClientKey keys requests by whoever principal says made them, or by
+// client IP if principal doesn't know.  Users and IPs never share a bucket.
+//
+// principal has to be someone we've checked, like the owner of a token.
+// A header the client fills in however it likes would let it make up a new
+// principal, and a new bucket, for every request.
+//
+// principal can be nil to only go by IP.  We don't trust X-Forwarded-For,
+// since anyone can send it; put something in front that sets RemoteAddr
+// properly if you're behind a proxy.
+func ClientKey(principal func(req *http.Request) string) KeyFunc {
+	return func(req *http.Request) string {
+		if principal != nil {
+			if id := principal(req); id != "" {
+				return "user:" + id
+			}
+		}
+
+		host, _, err := net.SplitHostPort(req.RemoteAddr)
+
+		if err != nil {
+			host = req.RemoteAddr
+		}
+
+		return "ip:" + host
+	}
+}
+
+// Middleware takes a token for every request, and answers 429 Too Many
+// Requests with a Retry-After if there isn't one.  Either way the response
+// says how much of the limit is left.
+func (l *Limiter) Middleware(key KeyFunc, next http.Handler) http.Handler {
+	if l.limit.Unlimited() {
+		return next
+	}
+
+	policy := strconv.Itoa(l.limit.Requests) + ";w=" + strconv.Itoa(seconds(l.limit.Per))
+
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		decision := l.Allow(key(req))
+
+		header := res.Header()
+		header.Set(HeaderLimit, strconv.Itoa(decision.Limit))
+		header.Set(HeaderRemaining, strconv.Itoa(decision.Remaining))
+		header.Set(HeaderReset, strconv.Itoa(seconds(decision.Reset)))
+		header.Set(HeaderPolicy, policy)
+
+		if !decision.Allowed {
+			header.Set("Retry-After", strconv.Itoa(seconds(decision.RetryAfter)))
+			http.Error(res, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(res, req)
+	})
+}
+
+// seconds rounds up, so nobody is told to come back before they can.
+// Headers only do whole seconds, and telling a client 0 invites it to
+// retry straight away, so anything waiting gets at least 1.
+func seconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+
+	return int((d + time.Second - 1) / time.Second)
+}
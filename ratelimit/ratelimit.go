@@ -0,0 +1,160 @@
+// Package ratelimit keeps any one client from hammering a route, with a
+// token bucket per client.
+//
+// Each client's bucket holds up to Limit.Requests tokens and refills evenly
+// over Limit.Per.  Every request takes a token, and when the bucket is empty
+// the request is turned away with a 429 until a token comes back.  That
+// lets a client burst up to the whole limit at once, but never go faster
+// than the limit for long.
+//
+//	limiter := ratelimit.NewLimiter(ratelimit.Limit{Requests: 10, Per: time.Second})
+//	handler := limiter.Middleware(ratelimit.ClientKey(userID), next)
+//
+// Limit has yaml tags, so it can sit straight in a config struct.
+package ratelimit
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is how many requests a client gets, and how long it takes for them
+// all to come back.  Zero Requests means no limit.
+type Limit struct {
+	Requests int           `yaml:"requests" help:"Requests each client can make in a burst, 0 for no limit"`
+	Per      time.Duration `yaml:"per" help:"How long it takes a client to get all of its requests back"`
+}
+
+// Unlimited is true if this limit lets everything through
+func (l Limit) Unlimited() bool {
+	return l.Requests <= 0
+}
+
+// Validate catches limits that can't work, so a config can refuse them
+func (l Limit) Validate() error {
+	if l.Requests < 0 {
+		return errors.New("requests can't be negative")
+	}
+
+	if !l.Unlimited() && l.Per <= 0 {
+		return errors.New("per must be positive when there's a limit")
+	}
+
+	return nil
+}
+
+// interval is how long it takes to get one token back
+func (l Limit) interval() time.Duration {
+	return l.Per / time.Duration(l.Requests)
+}
+
+// Decision is what the limiter made of one request
+type Decision struct {
+	Allowed bool
+
+	// Limit is the size of the bucket, Remaining how many tokens are left
+	// in it after this request
+	Limit     int
+	Remaining int
+
+	// RetryAfter is how long until the next token, zero if there's one now
+	RetryAfter time.Duration
+
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// bucket is one client's tokens as of when they were last counted
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter hands out tokens, with a bucket for every key it's seen recently
+type Limiter struct {
+	limit Limit
+
+	// now is swapped out by tests
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewLimiter returns a limiter that gives every key the same limit
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from key's bucket if there is one
+func (l *Limiter) Allow(key string) Decision {
+	if l.limit.Unlimited() {
+		return Decision{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	capacity := float64(l.limit.Requests)
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		l.buckets[key] = b
+	}
+
+	// Top up for the time since we last looked
+	elapsed := now.Sub(b.updated)
+
+	if elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+float64(elapsed)/float64(l.limit.interval()))
+		b.updated = now
+	}
+
+	decision := Decision{Limit: l.limit.Requests}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = l.untilTokens(1 - b.tokens)
+	}
+
+	decision.Remaining = int(b.tokens)
+	decision.Reset = l.untilTokens(capacity - b.tokens)
+
+	return decision
+}
+
+// untilTokens is how long it takes for this many tokens to come back
+func (l *Limiter) untilTokens(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens * float64(l.limit.interval())))
+}
+
+// sweep forgets buckets that have had time to fill up again, since a full
+// bucket is the same as no bucket.  It only bothers once per Per so a busy
+// limiter isn't forever walking the map.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.limit.Per {
+		return
+	}
+
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= l.limit.Per {
+			delete(l.buckets, key)
+		}
+	}
+}
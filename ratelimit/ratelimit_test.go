@@ -0,0 +1,198 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// clock is a time we move by hand
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestLimiter(limit Limit) (*Limiter, *clock) {
+	c := &clock{now: time.Date(2020, 7, 18, 12, 0, 0, 0, time.UTC)}
+
+	limiter := NewLimiter(limit)
+	limiter.now = c.Now
+
+	return limiter, c
+}
+
+func TestBucketEmptiesThenRefills(t *testing.T) {
+	limiter, c := newTestLimiter(Limit{Requests: 3, Per: 3 * time.Second})
+
+	for i := 0; i < 3; i++ {
+		decision := limiter.Allow("a")
+
+		if !decision.Allowed || decision.Remaining != 2-i {
+			t.Fatalf("Request %d: expected allowed with %d remaining but got %+v", i, 2-i, decision)
+		}
+	}
+
+	decision := limiter.Allow("a")
+
+	if decision.Allowed {
+		t.Fatal("Expected the fourth request to be refused")
+	}
+
+	if decision.RetryAfter != time.Second || decision.Reset != 3*time.Second {
+		t.Errorf("Expected to retry after 1s and reset after 3s but got %+v", decision)
+	}
+
+	// Someone else's bucket is untouched
+	if !limiter.Allow("b").Allowed {
+		t.Error("Expected another key to have its own bucket")
+	}
+
+	// One token comes back every second
+	c.Advance(time.Second)
+
+	if !limiter.Allow("a").Allowed {
+		t.Error("Expected a token back after a second")
+	}
+
+	if limiter.Allow("a").Allowed {
+		t.Error("Expected only one token back after a second")
+	}
+
+	// But never more than the bucket holds
+	c.Advance(time.Hour)
+
+	if decision := limiter.Allow("a"); decision.Remaining != 2 {
+		t.Errorf("Expected a full bucket after a long wait but got %+v", decision)
+	}
+}
+
+func TestUnlimitedLetsEverythingThrough(t *testing.T) {
+	limiter, _ := newTestLimiter(Limit{})
+
+	for i := 0; i < 1000; i++ {
+		if !limiter.Allow("a").Allowed {
+			t.Fatal("Expected no limit")
+		}
+	}
+}
+
+func TestFullBucketsAreForgotten(t *testing.T) {
+	limiter, c := newTestLimiter(Limit{Requests: 1, Per: time.Second})
+
+	limiter.Allow("a")
+	limiter.Allow("b")
+
+	c.Advance(2 * time.Second)
+
+	limiter.Allow("c")
+
+	if len(limiter.buckets) != 1 {
+		t.Errorf("Expected only the new bucket to be kept but have %d", len(limiter.buckets))
+	}
+}
+
+func TestLimitValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit Limit
+		valid bool
+	}{
+		{"Unlimited", Limit{}, true},
+		{"Limited", Limit{Requests: 10, Per: time.Second}, true},
+		{"NoPer", Limit{Requests: 10}, false},
+		{"Negative", Limit{Requests: -1, Per: time.Second}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.limit.Validate()
+
+			if (err == nil) != test.valid {
+				t.Errorf("Expected valid to be %v but got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRefusesWithHeaders(t *testing.T) {
+	limiter, _ := newTestLimiter(Limit{Requests: 2, Per: time.Minute})
+
+	handler := limiter.Middleware(ClientKey(func(req *http.Request) string {
+		return req.Header.Get("x-user-id")
+	}), http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusNoContent)
+	}))
+
+	send := func(userID string, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/score", nil)
+		req.RemoteAddr = remoteAddr
+
+		if userID != "" {
+			req.Header.Set("x-user-id", userID)
+		}
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		return res
+	}
+
+	send("evertras", "10.0.0.1:1234")
+	res := send("evertras", "10.0.0.2:1234")
+
+	if res.Code != http.StatusNoContent {
+		t.Fatalf("Expected the second request through but got %d", res.Code)
+	}
+
+	expected := map[string]string{
+		HeaderLimit:     "2",
+		HeaderRemaining: "0",
+		HeaderReset:     "60",
+		HeaderPolicy:    "2;w=60",
+	}
+
+	for header, value := range expected {
+		if res.Header().Get(header) != value {
+			t.Errorf("Expected %s %q but got %q", header, value, res.Header().Get(header))
+		}
+	}
+
+	// Same user from a different address is still the same user
+	res = send("evertras", "10.0.0.3:1234")
+
+	if res.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 but got %d", res.Code)
+	}
+
+	if res.Header().Get("Retry-After") != "30" {
+		t.Errorf("Expected Retry-After 30 but got %q", res.Header().Get("Retry-After"))
+	}
+
+	// Anonymous requests are limited by address instead
+	if res := send("", "10.0.0.1:1234"); res.Code != http.StatusNoContent {
+		t.Errorf("Expected an anonymous request from a fresh address through but got %d", res.Code)
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "[::1]:5555"
+
+	if key := ClientKey(nil)(req); key != "ip:::1" {
+		t.Errorf("Expected the IP without the port but got %q", key)
+	}
+
+	principal := func(req *http.Request) string { return "ty" }
+
+	if key := ClientKey(principal)(req); key != "user:ty" {
+		t.Errorf("Expected the principal but got %q", key)
+	}
+}
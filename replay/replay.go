@@ -0,0 +1,450 @@
+// Package replay records calls to a real dependency into a golden file,
+// and plays them back in tests.
+//
+// Hand-written mocks say whatever the test author thinks the dependency
+// does.  A recording says what it actually did the last time someone
+// checked.  Re-record when the real thing changes, and the diff in the
+// golden file shows exactly what changed.
+//
+// Recording and replaying are both decorate.Middleware, so they work with
+// any decorator from cmd/localdecorate:
+//
+//	store := NewCurrentChampionGetterDecorator(NewGSLDataStore("./champion.txt"),
+//		replay.Golden(t, "testdata/champion.golden.json"),
+//	)
+//
+// Normally that replays the golden file and never touches the real data
+// store.  Run the tests with -replay.record to call the real thing and
+// write down what happened.  Only packages that use replay know the flag,
+// so name them:
+//
+//	go test ./local-interfaces/handlers -replay.record
+//
+// A call that isn't in the recording fails the test with a diff against
+// the closest thing that is, and so does a recorded call that never
+// happened.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Evertras/go-interface-examples/decorate"
+)
+
+var record = flag.Bool("replay.record", false, "call real dependencies and rewrite golden files instead of replaying them")
+
+// ErrUnrecorded is returned for a call that isn't in the recording
+var ErrUnrecorded = errors.New("call was not recorded")
+
+// Interaction is one recorded call
+type Interaction struct {
+	Interface string          `json:"interface"`
+	Method    string          `json:"method"`
+	Args      json.RawMessage `json:"args"`
+	Results   json.RawMessage `json:"results,omitempty"`
+
+	// Error is the error's message, if there was one
+	Error string `json:"error,omitempty"`
+
+	// Is is the message of the sentinel error it wrapped, so errors.Is
+	// still works after replaying
+	Is string `json:"is,omitempty"`
+}
+
+// Option changes how recording and replaying work
+type Option func(*options)
+
+type options struct {
+	sentinels []error
+}
+
+// Sentinels are errors that callers check for with errors.Is.  Recorded
+// errors remember which of these they wrapped, and replayed errors wrap the
+// same one.  context.Canceled and context.DeadlineExceeded are always
+// included.
+func Sentinels(errs ...error) Option {
+	return func(o *options) {
+		o.sentinels = append(o.sentinels, errs...)
+	}
+}
+
+func buildOptions(opts []Option) options {
+	o := options{
+		sentinels: []error{context.Canceled, context.DeadlineExceeded},
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// Golden replays the file, or records to it when -replay.record is set
+func Golden(t testing.TB, filename string, opts ...Option) decorate.Middleware {
+	t.Helper()
+
+	if *record {
+		return Record(t, filename, opts...)
+	}
+
+	return Replay(t, filename, opts...)
+}
+
+// Record calls through to the real thing and writes every call to the
+// file when the test finishes
+func Record(t testing.TB, filename string, opts ...Option) decorate.Middleware {
+	o := buildOptions(opts)
+
+	var mu sync.Mutex
+	interactions := []Interaction{}
+
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		err := writeGolden(filename, interactions)
+
+		if err != nil {
+			t.Errorf("Failed to write %s: %v", filename, err)
+		}
+	})
+
+	return func(ctx context.Context, call decorate.Call, next decorate.Invoke) error {
+		err := next(ctx)
+
+		interaction, marshalErr := newInteraction(call, err, o.sentinels)
+
+		if marshalErr != nil {
+			t.Errorf("Failed to record %s.%s: %v", call.Interface, call.Method, marshalErr)
+			return err
+		}
+
+		mu.Lock()
+		interactions = append(interactions, interaction)
+		mu.Unlock()
+
+		return err
+	}
+}
+
+func newInteraction(call decorate.Call, err error, sentinels []error) (Interaction, error) {
+	interaction := Interaction{
+		Interface: call.Interface,
+		Method:    call.Method,
+	}
+
+	args, marshalErr := json.Marshal(call.Args)
+
+	if marshalErr != nil {
+		return interaction, fmt.Errorf("args: %w", marshalErr)
+	}
+
+	interaction.Args = args
+
+	// A failed call's results are whatever zero values came back with the
+	// error, which nobody should be looking at
+	if err != nil {
+		interaction.Error = err.Error()
+
+		for _, sentinel := range sentinels {
+			if errors.Is(err, sentinel) {
+				interaction.Is = sentinel.Error()
+				break
+			}
+		}
+
+		return interaction, nil
+	}
+
+	if len(call.Results) > 0 {
+		results, marshalErr := json.Marshal(call.Results)
+
+		if marshalErr != nil {
+			return interaction, fmt.Errorf("results: %w", marshalErr)
+		}
+
+		interaction.Results = results
+	}
+
+	return interaction, nil
+}
+
+func writeGolden(filename string, interactions []Interaction) error {
+	data, err := json.MarshalIndent(interactions, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+
+	if err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// Replay answers calls from the file without ever calling the real thing.
+// Calls can come in any order, but each recorded call is only used once.
+func Replay(t testing.TB, filename string, opts ...Option) decorate.Middleware {
+	t.Helper()
+
+	o := buildOptions(opts)
+
+	data, err := os.ReadFile(filename)
+
+	if err != nil {
+		t.Fatalf("Failed to read %s, record it with -replay.record: %v", filename, err)
+	}
+
+	var interactions []Interaction
+
+	err = json.Unmarshal(data, &interactions)
+
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", filename, err)
+	}
+
+	var mu sync.Mutex
+	used := make([]bool, len(interactions))
+
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		for i, interaction := range interactions {
+			if !used[i] {
+				t.Errorf("%s: recorded %s.%s%s was never called", filename, interaction.Interface, interaction.Method, compact(interaction.Args))
+			}
+		}
+	})
+
+	return func(ctx context.Context, call decorate.Call, next decorate.Invoke) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		args, err := json.Marshal(call.Args)
+
+		if err != nil {
+			t.Errorf("Failed to marshal args for %s.%s: %v", call.Interface, call.Method, err)
+			return err
+		}
+
+		mu.Lock()
+		match := -1
+
+		for i, interaction := range interactions {
+			if !used[i] && interaction.Interface == call.Interface && interaction.Method == call.Method && compact(interaction.Args) == compact(args) {
+				match = i
+				used[i] = true
+				break
+			}
+		}
+		mu.Unlock()
+
+		if match == -1 {
+			t.Errorf("%s: unrecorded call %s.%s%s\n%s", filename, call.Interface, call.Method, compact(args), closest(interactions, used, call, args))
+			return fmt.Errorf("%w: %s.%s%s", ErrUnrecorded, call.Interface, call.Method, compact(args))
+		}
+
+		return replayInteraction(interactions[match], call, o.sentinels)
+	}
+}
+
+func replayInteraction(interaction Interaction, call decorate.Call, sentinels []error) error {
+	if interaction.Error != "" {
+		replayed := &replayedError{message: interaction.Error}
+
+		for _, sentinel := range sentinels {
+			if sentinel.Error() == interaction.Is {
+				replayed.sentinel = sentinel
+				break
+			}
+		}
+
+		return replayed
+	}
+
+	if len(interaction.Results) == 0 {
+		return nil
+	}
+
+	var results []json.RawMessage
+
+	err := json.Unmarshal(interaction.Results, &results)
+
+	if err != nil {
+		return fmt.Errorf("replaying %s.%s results: %w", call.Interface, call.Method, err)
+	}
+
+	if len(results) != len(call.Results) {
+		return fmt.Errorf("replaying %s.%s: recorded %d results but the method has %d, re-record it", call.Interface, call.Method, len(results), len(call.Results))
+	}
+
+	// The results are pointers into the decorator, so this is what it
+	// returns
+	for i, result := range results {
+		err = json.Unmarshal(result, call.Results[i])
+
+		if err != nil {
+			return fmt.Errorf("replaying %s.%s result %d: %w", call.Interface, call.Method, i, err)
+		}
+	}
+
+	return nil
+}
+
+// replayedError has the recorded message, and still matches the sentinel
+// the original wrapped
+type replayedError struct {
+	message  string
+	sentinel error
+}
+
+func (e *replayedError) Error() string { return e.message }
+func (e *replayedError) Unwrap() error { return e.sentinel }
+
+func compact(raw json.RawMessage) string {
+	var out bytes.Buffer
+
+	if err := json.Compact(&out, raw); err != nil {
+		return string(raw)
+	}
+
+	return out.String()
+}
+
+// closest explains what was recorded for the same method, diffed against
+// the call that wasn't, so it's obvious whether the test or the recording
+// is wrong
+func closest(interactions []Interaction, used []bool, call decorate.Call, args json.RawMessage) string {
+	got := indent(args)
+
+	var candidates []string
+	var best []string
+	bestScore := -1
+
+	for i, interaction := range interactions {
+		if interaction.Interface != call.Interface || interaction.Method != call.Method {
+			continue
+		}
+
+		status := ""
+
+		if used[i] {
+			status = " (already used)"
+		}
+
+		candidates = append(candidates, compact(interaction.Args)+status)
+
+		recorded := indent(interaction.Args)
+
+		if score := sameLines(recorded, got); score > bestScore {
+			best = recorded
+			bestScore = score
+		}
+	}
+
+	if len(candidates) == 0 {
+		return fmt.Sprintf("nothing was recorded for %s.%s", call.Interface, call.Method)
+	}
+
+	return fmt.Sprintf("recorded %s.%s calls:\n\t%s\nclosest recorded args (-) vs this call (+):\n%s",
+		call.Interface, call.Method, strings.Join(candidates, "\n\t"), diff(best, got))
+}
+
+func indent(raw json.RawMessage) []string {
+	var out bytes.Buffer
+
+	if err := json.Indent(&out, raw, "", "  "); err != nil {
+		return []string{string(raw)}
+	}
+
+	return strings.Split(out.String(), "\n")
+}
+
+func sameLines(a, b []string) int {
+	return len(lcs(a, b))
+}
+
+// lcs is the longest common subsequence of lines, which is plenty for the
+// handful of lines in a call's args
+func lcs(a, b []string) []string {
+	lengths := make([][]int, len(a)+1)
+
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	var common []string
+
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			common = append(common, a[i])
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return common
+}
+
+// diff is a unified-ish line diff of a against b
+func diff(a, b []string) string {
+	var out strings.Builder
+	i, j := 0, 0
+
+	for _, line := range lcs(a, b) {
+		for ; a[i] != line; i++ {
+			fmt.Fprintf(&out, "\t- %s\n", a[i])
+		}
+
+		for ; b[j] != line; j++ {
+			fmt.Fprintf(&out, "\t+ %s\n", b[j])
+		}
+
+		fmt.Fprintf(&out, "\t  %s\n", line)
+		i++
+		j++
+	}
+
+	for ; i < len(a); i++ {
+		fmt.Fprintf(&out, "\t- %s\n", a[i])
+	}
+
+	for ; j < len(b); j++ {
+		fmt.Fprintf(&out, "\t+ %s\n", b[j])
+	}
+
+	return out.String()
+}
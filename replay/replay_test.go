@@ -0,0 +1,199 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Evertras/go-interface-examples/decorate"
+)
+
+var errNoUser = errors.New("no such user")
+
+// fakeT collects failures instead of failing, so we can check what a
+// failing replay says
+type fakeT struct {
+	testing.TB
+
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Cleanup(cleanup func()) {
+	f.cleanups = append(f.cleanups, cleanup)
+}
+
+func (f *fakeT) finish() {
+	for _, cleanup := range f.cleanups {
+		cleanup()
+	}
+}
+
+// getScore is what a generated decorator does for a method like
+// GetUserScore(ctx, id) (int, error)
+func getScore(middleware decorate.Middleware, id string, real func(id string) (int, error)) (int, error) {
+	var result0 int
+
+	call := decorate.Call{
+		Interface: "UserDataStore",
+		Method:    "GetUserScore",
+		Args:      []interface{}{id},
+		Results:   []interface{}{&result0},
+	}
+
+	err := middleware(context.Background(), call, func(ctx context.Context) error {
+		var err error
+
+		result0, err = real(id)
+
+		return err
+	})
+
+	return result0, err
+}
+
+func realScores(id string) (int, error) {
+	switch id {
+	case "evertras":
+		return 9000, nil
+	case "tasteless":
+		return 3, nil
+	}
+
+	return 0, fmt.Errorf("%w: %q", errNoUser, id)
+}
+
+func notCalled(t *testing.T) func(id string) (int, error) {
+	return func(id string) (int, error) {
+		t.Errorf("Replaying shouldn't call the real thing, but it was asked for %q", id)
+		return 0, nil
+	}
+}
+
+func recordScores(t *testing.T, ids ...string) string {
+	filename := filepath.Join(t.TempDir(), "testdata", "scores.golden.json")
+	recorder := &fakeT{TB: t}
+
+	middleware := Record(recorder, filename, Sentinels(errNoUser))
+
+	for _, id := range ids {
+		_, _ = getScore(middleware, id, realScores)
+	}
+
+	recorder.finish()
+
+	if len(recorder.errors) > 0 {
+		t.Fatal("Recording failed:", recorder.errors)
+	}
+
+	return filename
+}
+
+func TestReplayGivesBackWhatWasRecorded(t *testing.T) {
+	filename := recordScores(t, "evertras", "nobody")
+
+	middleware := Replay(t, filename, Sentinels(errNoUser))
+
+	// Order doesn't matter
+	_, err := getScore(middleware, "nobody", notCalled(t))
+
+	if !errors.Is(err, errNoUser) {
+		t.Errorf("Expected the replayed error to still be errNoUser but got %v", err)
+	}
+
+	if err == nil || err.Error() != `no such user: "nobody"` {
+		t.Errorf("Expected the recorded message but got %v", err)
+	}
+
+	score, err := getScore(middleware, "evertras", notCalled(t))
+
+	if err != nil || score != 9000 {
+		t.Errorf("Expected 9000 and no error but got %d and %v", score, err)
+	}
+}
+
+func TestReplayFailsOnUnrecordedCallsWithADiff(t *testing.T) {
+	filename := recordScores(t, "evertras")
+	replayer := &fakeT{TB: t}
+
+	middleware := Replay(replayer, filename)
+
+	_, err := getScore(middleware, "tasteless", notCalled(t))
+
+	if !errors.Is(err, ErrUnrecorded) {
+		t.Errorf("Expected ErrUnrecorded but got %v", err)
+	}
+
+	if len(replayer.errors) != 1 {
+		t.Fatalf("Expected one failure but got %v", replayer.errors)
+	}
+
+	for _, expected := range []string{
+		`unrecorded call UserDataStore.GetUserScore["tasteless"]`,
+		"\t-   \"evertras\"\n",
+		"\t+   \"tasteless\"\n",
+	} {
+		if !strings.Contains(replayer.errors[0], expected) {
+			t.Errorf("Expected the failure to contain %q but got:\n%s", expected, replayer.errors[0])
+		}
+	}
+}
+
+func TestReplayUsesEachRecordingOnce(t *testing.T) {
+	filename := recordScores(t, "evertras")
+	replayer := &fakeT{TB: t}
+
+	middleware := Replay(replayer, filename)
+
+	_, _ = getScore(middleware, "evertras", notCalled(t))
+	_, err := getScore(middleware, "evertras", notCalled(t))
+
+	if !errors.Is(err, ErrUnrecorded) {
+		t.Errorf("Expected the second call to be unrecorded but got %v", err)
+	}
+
+	if len(replayer.errors) != 1 || !strings.Contains(replayer.errors[0], "(already used)") {
+		t.Errorf("Expected the failure to say the recording was used up but got %v", replayer.errors)
+	}
+}
+
+func TestReplayFailsWhenRecordedCallsDontHappen(t *testing.T) {
+	filename := recordScores(t, "evertras", "tasteless")
+	replayer := &fakeT{TB: t}
+
+	middleware := Replay(replayer, filename)
+
+	_, _ = getScore(middleware, "evertras", notCalled(t))
+
+	replayer.finish()
+
+	if len(replayer.errors) != 1 || !strings.Contains(replayer.errors[0], `GetUserScore["tasteless"] was never called`) {
+		t.Errorf("Expected a failure about tasteless never being asked for but got %v", replayer.errors)
+	}
+}
+
+func TestReplayHonorsCanceledContexts(t *testing.T) {
+	filename := recordScores(t, "evertras")
+	middleware := Replay(&fakeT{TB: t}, filename)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := middleware(ctx, decorate.Call{Interface: "UserDataStore", Method: "GetUserScore"}, func(ctx context.Context) error {
+		t.Error("Shouldn't be called")
+		return nil
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled but got %v", err)
+	}
+}
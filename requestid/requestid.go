@@ -0,0 +1,112 @@
+// Package requestid gives every request an ID and carries it through the
+// context, so everything logged while serving it can be found together.
+//
+// Nothing downstream needs to know about HTTP or even this package.  Wrap
+// the log handler with NewLogHandler and anything that logs with the
+// request's context, like a decorated data store or a notifier, gets a
+// request_id attribute for free.
+//
+//	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(os.Stderr, nil)))
+//	server := requestid.Middleware(mux)
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+)
+
+// Header is where the ID comes in from the client or a proxy in front of
+// us, and where we send it back
+const Header = "X-Request-ID"
+
+// LogKey is the attribute the ID is logged as
+const LogKey = "request_id"
+
+// maxLength keeps clients from stuffing whatever they like into our logs
+const maxLength = 128
+
+type contextKey struct{}
+
+// NewContext returns a context that carries the request ID
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID, or an empty string if there isn't one
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+
+	return id
+}
+
+// Middleware uses the X-Request-ID the request came with, or makes one up
+// if it didn't have a usable one.  Either way the ID goes into the request's
+// context and back out in the response headers.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(Header)
+
+		if !valid(id) {
+			id = newID()
+		}
+
+		res.Header().Set(Header, id)
+
+		next.ServeHTTP(res, req.WithContext(NewContext(req.Context(), id)))
+	})
+}
+
+// valid only lets through IDs that are safe to put in a log line or a
+// header as they are
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newID() string {
+	id := make([]byte, 16)
+
+	// crypto/rand doesn't fail on any platform we run on
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// logHandler adds the request ID from the context to every record
+type logHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler wraps a slog.Handler so records logged with a context that
+// carries a request ID get it as an attribute
+func NewLogHandler(next slog.Handler) slog.Handler {
+	return logHandler{Handler: next}
+}
+
+func (h logHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := FromContext(ctx); id != "" {
+		record.AddAttrs(slog.String(LogKey, id))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return logHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h logHandler) WithGroup(name string) slog.Handler {
+	return logHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,95 @@
+package requestid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serve(header string) (string, string) {
+	var seen string
+
+	handler := Middleware(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		seen = FromContext(req.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+
+	if header != "" {
+		req.Header.Set(Header, header)
+	}
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	return seen, res.Header().Get(Header)
+}
+
+func TestMiddlewareKeepsIncomingID(t *testing.T) {
+	seen, sent := serve("abc-123")
+
+	if seen != "abc-123" || sent != "abc-123" {
+		t.Errorf("Expected abc-123 in context and response but got %q and %q", seen, sent)
+	}
+}
+
+func TestMiddlewareReplacesMissingOrUnsafeIDs(t *testing.T) {
+	for _, header := range []string{"", "has spaces", "line\nbreak", strings.Repeat("x", maxLength+1)} {
+		seen, sent := serve(header)
+
+		if seen == "" || seen == header {
+			t.Errorf("Expected a new ID for %q but got %q", header, seen)
+		}
+
+		if sent != seen {
+			t.Errorf("Expected response header %q to match context %q", sent, seen)
+		}
+	}
+}
+
+func TestMiddlewareMakesDifferentIDs(t *testing.T) {
+	first, _ := serve("")
+	second, _ := serve("")
+
+	if first == second {
+		t.Errorf("Expected different IDs but got %q twice", first)
+	}
+}
+
+func TestLogHandlerAddsIDFromContext(t *testing.T) {
+	var out bytes.Buffer
+
+	logger := slog.New(NewLogHandler(slog.NewJSONHandler(&out, nil))).With("component", "test")
+
+	logger.InfoContext(NewContext(context.Background(), "abc-123"), "hello")
+	logger.InfoContext(context.Background(), "no id")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines but got %d:\n%s", len(lines), out.String())
+	}
+
+	var first, second map[string]interface{}
+
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal("json.Unmarshal:", err)
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal("json.Unmarshal:", err)
+	}
+
+	if first[LogKey] != "abc-123" || first["component"] != "test" {
+		t.Errorf("Expected request ID and component but got %v", first)
+	}
+
+	if _, ok := second[LogKey]; ok {
+		t.Errorf("Expected no request ID but got %v", second)
+	}
+}
@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// maxExportSize is the biggest batch the collector will read
+const maxExportSize = 4 << 20
+
+// Collector is a stand-in for an OpenTelemetry collector.  It accepts
+// OTLP/HTTP JSON on any path, keeps the spans in memory and writes each
+// batch out as a line, which is plenty for local development and tests.
+type Collector struct {
+	mu    sync.Mutex
+	out   io.Writer
+	spans []OTLPSpan
+}
+
+// NewCollector returns a Collector that writes batches to out, or only
+// keeps them in memory if out is nil
+func NewCollector(out io.Writer) *Collector {
+	return &Collector{out: out}
+}
+
+// Spans returns everything received so far
+func (c *Collector) Spans() []OTLPSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]OTLPSpan(nil), c.spans...)
+}
+
+func (c *Collector) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		res.Header().Set("Allow", http.MethodPost)
+		res.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var export ExportRequest
+
+	err := json.NewDecoder(io.LimitReader(req.Body, maxExportSize)).Decode(&export)
+
+	if err != nil {
+		http.Error(res, "Invalid OTLP JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, resource := range export.ResourceSpans {
+		for _, scope := range resource.ScopeSpans {
+			c.spans = append(c.spans, scope.Spans...)
+		}
+	}
+
+	if c.out != nil {
+		line, _ := json.Marshal(export)
+		c.out.Write(append(line, '\n'))
+	}
+
+	// An empty ExportTraceServiceResponse means everything was accepted
+	res.Header().Set("Content-Type", "application/json")
+	res.Write([]byte("{}"))
+}
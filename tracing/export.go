@@ -0,0 +1,242 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// scopeName is who OTLP says made the spans
+const scopeName = "github.com/Evertras/go-interface-examples/tracing"
+
+// The OTLP JSON encoding of an ExportTraceServiceRequest.  Only the parts
+// we fill in are here.  IDs are hex and 64 bit numbers are strings, which
+// is what OTLP/JSON asks for.
+
+// ExportRequest is one batch of spans in OTLP JSON
+type ExportRequest struct {
+	ResourceSpans []ResourceSpans `json:"resourceSpans"`
+}
+
+// ResourceSpans is the spans from one service
+type ResourceSpans struct {
+	Resource   Resource     `json:"resource"`
+	ScopeSpans []ScopeSpans `json:"scopeSpans"`
+}
+
+// Resource describes the service
+type Resource struct {
+	Attributes []KeyValue `json:"attributes"`
+}
+
+// ScopeSpans is the spans from one instrumentation library
+type ScopeSpans struct {
+	Scope Scope      `json:"scope"`
+	Spans []OTLPSpan `json:"spans"`
+}
+
+// Scope names the instrumentation library
+type Scope struct {
+	Name string `json:"name"`
+}
+
+// OTLPSpan is one span
+type OTLPSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              SpanKind   `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []KeyValue `json:"attributes,omitempty"`
+	Status            Status     `json:"status"`
+}
+
+// Status codes, as OTLP numbers them
+const (
+	StatusUnset = 0
+	StatusOK    = 1
+	StatusError = 2
+)
+
+// Status says whether the span failed
+type Status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// KeyValue is an attribute
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+// AnyValue holds exactly one of its fields
+type AnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+// NewExportRequest encodes spans from one service as OTLP
+func NewExportRequest(service string, spans []SpanData) ExportRequest {
+	encoded := make([]OTLPSpan, len(spans))
+
+	for i, span := range spans {
+		encoded[i] = OTLPSpan{
+			TraceID:           span.SpanContext.TraceID.String(),
+			SpanID:            span.SpanContext.SpanID.String(),
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        keyValues(span.Attributes),
+		}
+
+		if span.Parent.IsValid() {
+			encoded[i].ParentSpanID = span.Parent.String()
+		}
+
+		if span.Failed {
+			encoded[i].Status = Status{Code: StatusError, Message: span.Message}
+		}
+	}
+
+	return ExportRequest{
+		ResourceSpans: []ResourceSpans{
+			{
+				Resource: Resource{
+					Attributes: keyValues([]Attribute{String("service.name", service)}),
+				},
+				ScopeSpans: []ScopeSpans{
+					{
+						Scope: Scope{Name: scopeName},
+						Spans: encoded,
+					},
+				},
+			},
+		},
+	}
+}
+
+func keyValues(attributes []Attribute) []KeyValue {
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	encoded := make([]KeyValue, len(attributes))
+
+	for i, attribute := range attributes {
+		encoded[i] = KeyValue{Key: attribute.Key, Value: anyValue(attribute.Value)}
+	}
+
+	return encoded
+}
+
+func anyValue(value interface{}) AnyValue {
+	switch v := value.(type) {
+	case string:
+		return AnyValue{StringValue: &v}
+
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return AnyValue{IntValue: &s}
+
+	case float64:
+		return AnyValue{DoubleValue: &v}
+
+	case bool:
+		return AnyValue{BoolValue: &v}
+
+	default:
+		s := fmt.Sprint(v)
+		return AnyValue{StringValue: &s}
+	}
+}
+
+// WriterExporter writes each batch as a line of OTLP JSON, the same shape
+// the OpenTelemetry collector's file exporter uses
+type WriterExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterExporter writes batches to w, usually a file
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// ExportSpans writes the spans as one line
+func (e *WriterExporter) ExportSpans(ctx context.Context, service string, spans []SpanData) error {
+	line, err := json.Marshal(NewExportRequest(service, spans))
+
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, err = e.w.Write(append(line, '\n'))
+
+	return err
+}
+
+// HTTPExporter posts each batch as OTLP/HTTP JSON, to a real collector or
+// to cmd/tracecollector
+type HTTPExporter struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPExporter posts to the full URL, usually ending in /v1/traces
+func NewHTTPExporter(url string, client *http.Client) *HTTPExporter {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &HTTPExporter{
+		url:    url,
+		client: client,
+	}
+}
+
+// ExportSpans posts the spans in one request
+func (e *HTTPExporter) ExportSpans(ctx context.Context, service string, spans []SpanData) error {
+	body, err := json.Marshal(NewExportRequest(service, spans))
+
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+
+	if err != nil {
+		return fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := e.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("collector returned status %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Evertras/go-interface-examples/decorate"
+	"github.com/Evertras/go-interface-examples/internal/httpstatus"
+)
+
+// Middleware starts a span around every call through a generated
+// decorator, named after the interface and method.  Use KindClient for
+// things outside this process like the db, and KindInternal for the rest.
+func (t *Tracer) Middleware(kind SpanKind) decorate.Middleware {
+	return func(ctx context.Context, call decorate.Call, next decorate.Invoke) error {
+		ctx, span := t.Start(ctx, call.Interface+"."+call.Method, kind,
+			String("code.namespace", call.Interface),
+			String("code.function", call.Method),
+		)
+		defer span.End()
+
+		err := next(ctx)
+
+		span.RecordError(err)
+
+		return err
+	}
+}
+
+// HTTPMiddleware starts a server span for every request to the route,
+// carrying on the caller's trace if they sent a traceparent.  The span
+// context goes back out in the response's traceparent, so a client can
+// find the trace for a request it made.
+//
+// Pass the route pattern rather than the path, so spans are named
+// "GET /score" and not after every user ID.
+func (t *Tracer) HTTPMiddleware(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		if remote, err := ParseTraceparent(req.Header.Get(TraceparentHeader)); err == nil {
+			ctx = ContextWithRemoteSpanContext(ctx, remote)
+		}
+
+		ctx, span := t.Start(ctx, req.Method+" "+route, KindServer,
+			String("http.request.method", req.Method),
+			String("http.route", route),
+			String("url.path", req.URL.Path),
+		)
+		defer span.End()
+
+		Inject(span.SpanContext(), res.Header())
+
+		recorder := httpstatus.NewRecorder(res)
+
+		next.ServeHTTP(recorder, req.WithContext(ctx))
+
+		status := recorder.Status()
+
+		span.SetAttributes(Int("http.response.status_code", status))
+
+		// Client errors are the client's problem, so only 5xx fail the span
+		if status >= 500 {
+			span.RecordError(errStatus(status))
+		}
+	})
+}
+
+type errStatus int
+
+func (e errStatus) Error() string {
+	return http.StatusText(int(e))
+}
@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TraceparentHeader is the W3C Trace Context header that carries a span
+// from one service to the next
+const TraceparentHeader = "traceparent"
+
+// TraceID identifies a whole trace, across every service it touches
+type TraceID [16]byte
+
+// SpanID identifies one span within a trace
+type SpanID [8]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+func (id SpanID) String() string  { return hex.EncodeToString(id[:]) }
+
+// IsValid is false for the all-zero ID, which the spec reserves for
+// "no trace"
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+// IsValid is false for the all-zero ID
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// SpanContext is the part of a span that crosses process boundaries
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether both IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats the span context as a version 00 traceparent header
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent reads a traceparent header.  Anything we can't make
+// sense of is an error, and the caller should start a fresh trace rather
+// than guess.
+//
+// Versions after 00 are allowed to add fields on the end, so for those we
+// only insist that the fields we know about are there.
+func ParseTraceparent(header string) (SpanContext, error) {
+	var sc SpanContext
+
+	parts := strings.Split(strings.TrimSpace(header), "-")
+
+	if len(parts) < 4 {
+		return sc, fmt.Errorf("traceparent %q: expected 4 fields but got %d", header, len(parts))
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+
+	if len(version) != 2 || !isLowerHex(version) || version == "ff" {
+		return sc, fmt.Errorf("traceparent %q: invalid version", header)
+	}
+
+	if version == "00" && len(parts) != 4 {
+		return sc, fmt.Errorf("traceparent %q: version 00 has exactly 4 fields", header)
+	}
+
+	if len(traceID) != 32 || !isLowerHex(traceID) {
+		return sc, fmt.Errorf("traceparent %q: invalid trace ID", header)
+	}
+
+	if len(spanID) != 16 || !isLowerHex(spanID) {
+		return sc, fmt.Errorf("traceparent %q: invalid parent ID", header)
+	}
+
+	if len(flags) != 2 || !isLowerHex(flags) {
+		return sc, fmt.Errorf("traceparent %q: invalid flags", header)
+	}
+
+	_, _ = hex.Decode(sc.TraceID[:], []byte(traceID))
+	_, _ = hex.Decode(sc.SpanID[:], []byte(spanID))
+
+	var flagBits [1]byte
+	_, _ = hex.Decode(flagBits[:], []byte(flags))
+
+	sc.Sampled = flagBits[0]&0x01 == 1
+
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("traceparent %q: all-zero IDs aren't allowed", header)
+	}
+
+	return sc, nil
+}
+
+// Inject adds the traceparent for the given span context to outgoing
+// headers, so whoever we call can carry on the same trace
+func Inject(sc SpanContext, header http.Header) {
+	if sc.IsValid() {
+		header.Set(TraceparentHeader, sc.Traceparent())
+	}
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+
+	return id
+}
@@ -0,0 +1,368 @@
+// Package tracing records where the time goes in a request, as spans that
+// can be followed across services with W3C traceparent headers.
+//
+// Like metrics, nothing in the handlers, leaderboard or stores knows about
+// this package.  Main wraps them with decorators and middleware from here,
+// and the spans nest through the context that's already being passed
+// along:
+//
+//	tracer := tracing.NewTracer("leaderboard", tracing.NewWriterExporter(file), logger)
+//
+//	store := newUserStoreDecorator(db.New(), tracer.Middleware(tracing.KindClient))
+//	server := tracer.HTTPMiddleware("/score", handler)
+//
+// Finished spans are exported as OTLP JSON, either to a file or to
+// anything that accepts OTLP over HTTP, like cmd/tracecollector.
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Exporter sends finished spans from the named service somewhere
+type Exporter interface {
+	ExportSpans(ctx context.Context, service string, spans []SpanData) error
+}
+
+// SpanKind says which side of a call a span is on.  The values are the
+// ones OTLP uses.
+type SpanKind int
+
+const (
+	// KindInternal is work inside this service
+	KindInternal SpanKind = 1
+
+	// KindServer is a request someone made to us
+	KindServer SpanKind = 2
+
+	// KindClient is a call we made to something else, like the db
+	KindClient SpanKind = 3
+)
+
+// Attribute is a key and a string, int64, float64 or bool value
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String makes a string attribute
+func String(key string, value string) Attribute { return Attribute{key, value} }
+
+// Int makes an integer attribute
+func Int(key string, value int) Attribute { return Attribute{key, int64(value)} }
+
+// Bool makes a boolean attribute
+func Bool(key string, value bool) Attribute { return Attribute{key, value} }
+
+// SpanData is a finished span, as handed to an Exporter
+type SpanData struct {
+	Name        string
+	SpanContext SpanContext
+	Parent      SpanID
+	Kind        SpanKind
+	Start       time.Time
+	End         time.Time
+	Attributes  []Attribute
+
+	// Failed spans have an error status with this message
+	Failed  bool
+	Message string
+}
+
+// maxBatch is how many finished spans we hold on to before exporting,
+// even if the request they belong to hasn't finished
+const maxBatch = 512
+
+// maxQueuedBatches is how many batches can wait on a slow exporter before
+// we start dropping them
+const maxQueuedBatches = 64
+
+// exportTimeout is as long as one batch gets, in case the exporter has no
+// timeout of its own
+const exportTimeout = 10 * time.Second
+
+// exportJob is a batch for the exporter, and who to tell when it's gone
+type exportJob struct {
+	spans []SpanData
+	done  chan struct{}
+}
+
+// Tracer starts spans and exports them once they're done
+//
+// Spans are batched up and handed to the exporter when the first span this
+// service started for a trace ends, which is usually the end of a request.
+// Exporting happens in the background, so a request never waits on a
+// collector.  If the collector can't keep up, batches are dropped rather
+// than queued forever, and how many spans went missing is logged.
+type Tracer struct {
+	service  string
+	exporter Exporter
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	finished []SpanData
+
+	queue   chan exportJob
+	dropped atomic.Int64
+
+	// Injected so tests get predictable times
+	now func() time.Time
+}
+
+// NewTracer returns a Tracer for the named service, and starts exporting
+// in the background for the life of the program.  Export failures are
+// logged rather than returned, since there's nobody to return them to
+// when a span ends.
+func NewTracer(service string, exporter Exporter, logger *slog.Logger) *Tracer {
+	t := &Tracer{
+		service:  service,
+		exporter: exporter,
+		logger:   logger,
+		queue:    make(chan exportJob, maxQueuedBatches),
+		now:      time.Now,
+	}
+
+	go t.run()
+
+	return t
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// Span is one piece of timed work.  Every method is safe to call on a nil
+// Span, so code that might not be traced doesn't have to check.
+type Span struct {
+	tracer *Tracer
+
+	// localRoot is set on the first span this service started for the
+	// trace, and ending it exports the batch
+	localRoot bool
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// ContextWithRemoteSpanContext returns a context whose next span will be a
+// child of a span in another service
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanFromContext returns the current span, or nil if there isn't one
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+
+	return span
+}
+
+// SpanContextFromContext returns the span context to propagate, whether
+// the current span is ours or came from a traceparent
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+
+	return sc
+}
+
+// Start begins a span as a child of whatever span is in the context, or a
+// new trace if there isn't one.  End it when the work is done.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attributes ...Attribute) (context.Context, *Span) {
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:       name,
+			Kind:       kind,
+			Start:      t.now(),
+			Attributes: append([]Attribute(nil), attributes...),
+		},
+	}
+
+	if parent := SpanFromContext(ctx); parent != nil {
+		parentContext := parent.SpanContext()
+
+		span.data.SpanContext.TraceID = parentContext.TraceID
+		span.data.SpanContext.Sampled = parentContext.Sampled
+		span.data.Parent = parentContext.SpanID
+	} else if remote, ok := ctx.Value(remoteKey{}).(SpanContext); ok && remote.IsValid() {
+		span.data.SpanContext.TraceID = remote.TraceID
+		span.data.SpanContext.Sampled = remote.Sampled
+		span.data.Parent = remote.SpanID
+		span.localRoot = true
+	} else {
+		span.data.SpanContext.TraceID = newTraceID()
+		span.data.SpanContext.Sampled = true
+		span.localRoot = true
+	}
+
+	span.data.SpanContext.SpanID = newSpanID()
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SpanContext is what to propagate to anything this span calls
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+
+	return s.data.SpanContext
+}
+
+// SetAttributes adds attributes, or replaces ones with the same key
+func (s *Span) SetAttributes(attributes ...Attribute) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	for _, attribute := range attributes {
+		replaced := false
+
+		for i := range s.data.Attributes {
+			if s.data.Attributes[i].Key == attribute.Key {
+				s.data.Attributes[i] = attribute
+				replaced = true
+			}
+		}
+
+		if !replaced {
+			s.data.Attributes = append(s.data.Attributes, attribute)
+		}
+	}
+}
+
+// RecordError marks the span as failed.  Nil errors are ignored, so the
+// result of a call can be passed straight in.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	s.data.Failed = true
+	s.data.Message = err.Error()
+}
+
+// End finishes the span.  Only the first call counts.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+
+	s.ended = true
+	s.data.End = s.tracer.now()
+	data := s.data
+
+	s.mu.Unlock()
+
+	// Unsampled spans still pass their IDs along, they just aren't kept
+	if !data.SpanContext.Sampled {
+		return
+	}
+
+	s.tracer.finish(data, s.localRoot)
+}
+
+func (t *Tracer) finish(data SpanData, flush bool) {
+	t.mu.Lock()
+
+	t.finished = append(t.finished, data)
+
+	if !flush && len(t.finished) < maxBatch {
+		t.mu.Unlock()
+		return
+	}
+
+	batch := t.finished
+	t.finished = nil
+
+	t.mu.Unlock()
+
+	select {
+	case t.queue <- exportJob{spans: batch}:
+	default:
+		t.dropped.Add(int64(len(batch)))
+	}
+}
+
+// Flush exports any finished spans that are still waiting, like the ones
+// from a request that hasn't finished yet, and waits for everything
+// already queued to go out or for ctx to end.  Call it before exiting.
+func (t *Tracer) Flush(ctx context.Context) {
+	t.mu.Lock()
+
+	batch := t.finished
+	t.finished = nil
+
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+
+	// Unlike a span ending, this waits for room rather than dropping
+	select {
+	case t.queue <- exportJob{spans: batch, done: done}:
+	case <-ctx.Done():
+		return
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// run exports batches one at a time, in the order they finished
+func (t *Tracer) run() {
+	for job := range t.queue {
+		if len(job.spans) > 0 {
+			t.export(job.spans)
+		}
+
+		if dropped := t.dropped.Swap(0); dropped > 0 {
+			t.logger.Warn("Dropped spans because the exporter couldn't keep up", "spans", dropped)
+		}
+
+		if job.done != nil {
+			close(job.done)
+		}
+	}
+}
+
+func (t *Tracer) export(batch []SpanData) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	err := t.exporter.ExportSpans(ctx, t.service, batch)
+
+	if err != nil {
+		t.logger.ErrorContext(ctx, "Failed to export spans", "spans", len(batch), "error", err)
+	}
+}
@@ -0,0 +1,297 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Evertras/go-interface-examples/decorate"
+)
+
+type mockExporter struct {
+	mu       sync.Mutex
+	services []string
+	batches  [][]SpanData
+}
+
+func (e *mockExporter) ExportSpans(ctx context.Context, service string, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.services = append(e.services, service)
+	e.batches = append(e.batches, spans)
+
+	return nil
+}
+
+func newTestTracer() (*Tracer, *mockExporter) {
+	exporter := &mockExporter{}
+	tracer := NewTracer("test", exporter, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	return tracer, exporter
+}
+
+func TestParseTraceparent(t *testing.T) {
+	const header = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	sc, err := ParseTraceparent(header)
+
+	if err != nil {
+		t.Fatal("ParseTraceparent:", err)
+	}
+
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || !sc.Sampled {
+		t.Errorf("Unexpected span context %+v", sc)
+	}
+
+	if sc.Traceparent() != header {
+		t.Errorf("Expected %q back but got %q", header, sc.Traceparent())
+	}
+
+	// Later versions can add fields we don't know about
+	_, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+
+	if err != nil {
+		t.Error("Expected a future version to parse but got:", err)
+	}
+}
+
+func TestParseTraceparentRejectsNonsense(t *testing.T) {
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(header); err == nil {
+			t.Errorf("Expected %q to be rejected", header)
+		}
+	}
+}
+
+func TestSpansNestAndExportWhenTheRootEnds(t *testing.T) {
+	tracer, exporter := newTestTracer()
+
+	ctx, root := tracer.Start(context.Background(), "root", KindServer)
+	_, child := tracer.Start(ctx, "child", KindClient)
+
+	child.RecordError(errors.New("oh no"))
+	child.End()
+
+	if len(exporter.batches) != 0 {
+		t.Fatalf("Expected nothing exported before the root ends but got %d batches", len(exporter.batches))
+	}
+
+	root.End()
+	tracer.Flush(context.Background())
+
+	if len(exporter.batches) != 1 || len(exporter.batches[0]) != 2 {
+		t.Fatalf("Expected one batch of 2 spans but got %+v", exporter.batches)
+	}
+
+	exportedChild, exportedRoot := exporter.batches[0][0], exporter.batches[0][1]
+
+	if exportedChild.SpanContext.TraceID != exportedRoot.SpanContext.TraceID {
+		t.Error("Expected both spans in the same trace")
+	}
+
+	if exportedChild.Parent != exportedRoot.SpanContext.SpanID {
+		t.Error("Expected the child's parent to be the root")
+	}
+
+	if exportedRoot.Parent.IsValid() {
+		t.Error("Expected the root to have no parent")
+	}
+
+	if !exportedChild.Failed || exportedChild.Message != "oh no" {
+		t.Errorf("Expected the child to have failed with oh no but got %+v", exportedChild)
+	}
+}
+
+func TestUnsampledTracesPropagateButArentExported(t *testing.T) {
+	tracer, exporter := newTestTracer()
+
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+	_, span := tracer.Start(ContextWithRemoteSpanContext(context.Background(), remote), "root", KindServer)
+	span.End()
+
+	if span.SpanContext().TraceID != remote.TraceID {
+		t.Error("Expected the remote trace ID to carry on")
+	}
+
+	tracer.Flush(context.Background())
+
+	if len(exporter.batches) != 0 {
+		t.Errorf("Expected nothing exported but got %+v", exporter.batches)
+	}
+}
+
+func TestNilSpanIsSafe(t *testing.T) {
+	span := SpanFromContext(context.Background())
+
+	span.SetAttributes(String("a", "b"))
+	span.RecordError(errors.New("oh no"))
+	span.End()
+}
+
+func TestDecorateMiddlewareMakesChildSpans(t *testing.T) {
+	tracer, exporter := newTestTracer()
+
+	ctx, root := tracer.Start(context.Background(), "root", KindServer)
+
+	var seen SpanContext
+
+	err := tracer.Middleware(KindClient)(ctx, decorate.Call{Interface: "userStore", Method: "GetUserScore"}, func(ctx context.Context) error {
+		seen = SpanContextFromContext(ctx)
+		return errors.New("not found")
+	})
+
+	root.End()
+	tracer.Flush(context.Background())
+
+	if err == nil || err.Error() != "not found" {
+		t.Errorf("Expected the call's error back but got %v", err)
+	}
+
+	call := exporter.batches[0][0]
+
+	if call.Name != "userStore.GetUserScore" || call.Kind != KindClient || !call.Failed {
+		t.Errorf("Unexpected call span %+v", call)
+	}
+
+	if seen != call.SpanContext {
+		t.Error("Expected the call to run inside its own span")
+	}
+}
+
+func TestHTTPMiddlewareContinuesTraceIntoCollector(t *testing.T) {
+	var out bytes.Buffer
+
+	collector := NewCollector(&out)
+	collectorServer := httptest.NewServer(collector)
+	defer collectorServer.Close()
+
+	tracer := NewTracer("leaderboard", NewHTTPExporter(collectorServer.URL+"/v1/traces", collectorServer.Client()), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	tracer.now = func() time.Time { return time.Unix(1595030400, 0) }
+
+	handler := tracer.HTTPMiddleware("/score", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		_, span := tracer.Start(req.Context(), "lookup", KindInternal)
+		span.End()
+
+		res.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	req := httptest.NewRequest("GET", "/score", nil)
+	req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+	tracer.Flush(context.Background())
+
+	spans := collector.Spans()
+
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans collected but got %+v", spans)
+	}
+
+	server := spans[1]
+
+	if server.Name != "GET /score" || server.Kind != KindServer {
+		t.Errorf("Unexpected server span %+v", server)
+	}
+
+	if server.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || server.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("Expected the server span to continue the incoming trace but got %+v", server)
+	}
+
+	if spans[0].ParentSpanID != server.SpanID {
+		t.Error("Expected the handler's span to be a child of the server span")
+	}
+
+	if server.Status.Code != StatusError || server.StartTimeUnixNano != "1595030400000000000" {
+		t.Errorf("Expected a failed span at the fake time but got %+v", server)
+	}
+
+	expectedTraceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + server.SpanID + "-01"
+
+	if res.Header().Get(TraceparentHeader) != expectedTraceparent {
+		t.Errorf("Expected traceparent %q in the response but got %q", expectedTraceparent, res.Header().Get(TraceparentHeader))
+	}
+
+	var line ExportRequest
+
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatal("Expected the collector to write OTLP JSON:", err)
+	}
+
+	service := line.ResourceSpans[0].Resource.Attributes[0]
+
+	if service.Key != "service.name" || *service.Value.StringValue != "leaderboard" {
+		t.Errorf("Expected service.name leaderboard but got %+v", service)
+	}
+}
+
+// stuckExporter doesn't come back until it's released
+type stuckExporter struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (e *stuckExporter) ExportSpans(ctx context.Context, service string, spans []SpanData) error {
+	e.started <- struct{}{}
+	<-e.release
+
+	return nil
+}
+
+func TestSlowExporterDoesntHoldUpSpansAndDropsWhenFull(t *testing.T) {
+	exporter := &stuckExporter{started: make(chan struct{}, maxQueuedBatches+2), release: make(chan struct{})}
+
+	var logs bytes.Buffer
+
+	tracer := NewTracer("test", exporter, slog.New(slog.NewTextHandler(&logs, nil)))
+
+	// One batch the exporter is stuck on, a full queue behind it, and one
+	// more that has nowhere to go
+	_, first := tracer.Start(context.Background(), "request", KindServer)
+	first.End()
+
+	<-exporter.started
+
+	ended := make(chan struct{})
+
+	go func() {
+		for i := 0; i < maxQueuedBatches+1; i++ {
+			_, span := tracer.Start(context.Background(), "request", KindServer)
+			span.End()
+		}
+
+		close(ended)
+	}()
+
+	select {
+	case <-ended:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected ending spans not to wait on the exporter")
+	}
+
+	close(exporter.release)
+	tracer.Flush(context.Background())
+
+	if !bytes.Contains(logs.Bytes(), []byte("spans=1")) {
+		t.Errorf("Expected one dropped span to be logged but got %q", logs.String())
+	}
+}